@@ -0,0 +1,98 @@
+//go:build web
+
+package api
+
+import (
+	"net/http"
+	"tinyrdm/backend/services"
+	"tinyrdm/backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerACLRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("/acl")
+
+	g.POST("/users", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().ListUsers(req.Server))
+	})
+
+	g.POST("/save-user", func(c *gin.Context) {
+		var req struct {
+			Server string            `json:"server"`
+			User   types.ACLUserForm `json:"user"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().SaveUser(req.Server, req.User))
+	})
+
+	g.POST("/delete-user", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Name   string `json:"name"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().DeleteUser(req.Server, req.Name))
+	})
+
+	g.POST("/dry-run", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Name   string `json:"name"`
+			Cmd    string `json:"cmd"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().DryRun(req.Server, req.Name, req.Cmd))
+	})
+
+	g.POST("/log", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Count  int64  `json:"count"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().GetLog(req.Server, req.Count))
+	})
+
+	g.POST("/reset-log", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().ResetLog(req.Server))
+	})
+
+	g.POST("/save", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Load   bool   `json:"load"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.ACL().SaveACL(req.Server, req.Load))
+	})
+}
@@ -0,0 +1,49 @@
+//go:build web
+
+package api
+
+import (
+	"net/http"
+	"tinyrdm/backend/services"
+	"tinyrdm/backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerAlertRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("/alert")
+
+	g.GET("/settings", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Alert().GetAlertSettings())
+	})
+
+	g.POST("/save-settings", func(c *gin.Context) {
+		var req struct {
+			Settings types.AlertSettings `json:"settings"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Alert().SaveAlertSettings(req.Settings))
+	})
+
+	g.POST("/test", func(c *gin.Context) {
+		var req struct {
+			Settings types.AlertSettings `json:"settings"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Alert().TestAlertNotification(req.Settings))
+	})
+
+	g.GET("/history", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Alert().GetAlertHistory())
+	})
+
+	g.POST("/clean-history", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Alert().CleanAlertHistory())
+	})
+}
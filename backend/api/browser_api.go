@@ -425,6 +425,75 @@ func registerBrowserRoutes(rg *gin.RouterGroup) {
 		c.JSON(http.StatusOK, services.Browser().GetSlowLogs(req.Server, req.Num))
 	})
 
+	g.POST("/analyze-slow-logs", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Num    int64  `json:"num"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().AnalyzeSlowLogs(req.Server, req.Num))
+	})
+
+	g.POST("/reset-slow-logs", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ResetSlowLogs(req.Server))
+	})
+
+	g.POST("/latest-latency", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().GetLatestLatency(req.Server))
+	})
+
+	g.POST("/latency-history", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Node   string `json:"node"`
+			Event  string `json:"event"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().GetLatencyHistory(req.Server, req.Node, req.Event))
+	})
+
+	g.POST("/latency-doctor", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().GetLatencyDoctor(req.Server))
+	})
+
+	g.POST("/reset-latency", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ResetLatency(req.Server))
+	})
+
 	g.POST("/get-client-list", func(c *gin.Context) {
 		var req struct {
 			Server string `json:"server"`
@@ -436,6 +505,147 @@ func registerBrowserRoutes(rg *gin.RouterGroup) {
 		c.JSON(http.StatusOK, services.Browser().GetClientList(req.Server))
 	})
 
+	g.POST("/server-nodes", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ListServerNodes(req.Server))
+	})
+
+	g.POST("/replication-status", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().GetReplicationStatus(req.Server))
+	})
+
+	g.POST("/replica-of", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Addr   string `json:"addr"`
+			Host   string `json:"host"`
+			Port   int    `json:"port"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ReplicaOf(req.Server, req.Addr, req.Host, req.Port))
+	})
+
+	g.POST("/sentinel-overview", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().GetSentinelOverview(req.Server))
+	})
+
+	g.POST("/sentinel-failover", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Master string `json:"master"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().SentinelFailover(req.Server, req.Master))
+	})
+
+	g.POST("/sentinel-reset", func(c *gin.Context) {
+		var req struct {
+			Server  string `json:"server"`
+			Pattern string `json:"pattern"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().SentinelReset(req.Server, req.Pattern))
+	})
+
+	g.POST("/sentinel-ckquorum", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Master string `json:"master"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().SentinelCheckQuorum(req.Server, req.Master))
+	})
+
+	g.POST("/cluster-topology", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().GetClusterTopology(req.Server))
+	})
+
+	g.POST("/cluster-failover", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			NodeID string `json:"nodeId"`
+			Mode   string `json:"mode"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ClusterFailover(req.Server, req.NodeID, req.Mode))
+	})
+
+	g.POST("/cluster-forget", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			NodeID string `json:"nodeId"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ClusterForget(req.Server, req.NodeID))
+	})
+
+	g.POST("/cluster-meet", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Host   string `json:"host"`
+			Port   int    `json:"port"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().ClusterMeet(req.Server, req.Host, req.Port))
+	})
+
+	g.POST("/migrate-cluster-slots", func(c *gin.Context) {
+		var param types.ClusterMigrateParam
+		if err := c.ShouldBindJSON(&param); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Browser().MigrateClusterSlots(param))
+	})
+
 	g.POST("/get-cmd-history", func(c *gin.Context) {
 		var req struct {
 			PageNo   int `json:"pageNo"`
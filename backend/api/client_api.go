@@ -0,0 +1,92 @@
+//go:build web
+
+package api
+
+import (
+	"net/http"
+	"tinyrdm/backend/services"
+	"tinyrdm/backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerClientRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("/client")
+
+	g.POST("/list", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Node   string `json:"node"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Client().ListClients(req.Server, req.Node))
+	})
+
+	g.POST("/info", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Node   string `json:"node"`
+			ID     int64  `json:"id"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Client().GetClientInfo(req.Server, req.Node, req.ID))
+	})
+
+	g.POST("/kill", func(c *gin.Context) {
+		var req struct {
+			Server     string `json:"server"`
+			Node       string `json:"node"`
+			FilterType string `json:"filterType"`
+			Value      string `json:"value"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Client().KillClients(req.Server, req.Node, req.FilterType, req.Value))
+	})
+
+	g.POST("/pause", func(c *gin.Context) {
+		var req struct {
+			Server  string `json:"server"`
+			Node    string `json:"node"`
+			Timeout int64  `json:"timeout"`
+			Mode    string `json:"mode"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Client().PauseClients(req.Server, req.Node, req.Timeout, req.Mode))
+	})
+
+	g.POST("/unpause", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Node   string `json:"node"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Client().UnpauseClients(req.Server, req.Node))
+	})
+
+	g.POST("/no-evict", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Enable bool   `json:"enable"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Client().SetNoEvict(req.Server, req.Enable))
+	})
+}
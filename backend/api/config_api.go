@@ -0,0 +1,75 @@
+//go:build web
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"tinyrdm/backend/services"
+	"tinyrdm/backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerConfigRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("/config")
+
+	g.POST("/get", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Node   string `json:"node"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Config().GetConfig(req.Server, req.Node))
+	})
+
+	g.POST("/set", func(c *gin.Context) {
+		var req struct {
+			Server string            `json:"server"`
+			Node   string            `json:"node"`
+			Params map[string]string `json:"params"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Config().SetConfig(req.Server, req.Node, req.Params))
+	})
+
+	g.POST("/rewrite", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			Node   string `json:"node"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Config().RewriteConfig(req.Server, req.Node))
+	})
+
+	// Web-specific: download snapshot of parameters as redis.conf
+	g.POST("/export-download", func(c *gin.Context) {
+		var req struct {
+			Server      string `json:"server"`
+			Node        string `json:"node"`
+			WithSecrets bool   `json:"withSecrets"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		data, filename, err := services.Config().ExportConfigToBytes(req.Server, req.Node, req.WithSecrets)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, types.JSResp{Msg: err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Header("Content-Type", "text/plain")
+		c.Header("Content-Length", fmt.Sprintf("%d", len(data)))
+		c.Data(http.StatusOK, "text/plain", data)
+	})
+}
@@ -102,6 +102,18 @@ func registerConnectionRoutes(rg *gin.RouterGroup) {
 		c.JSON(http.StatusOK, services.Connection().SaveLastDB(req.Name, req.DB))
 	})
 
+	g.POST("/save-pinned-node", func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name"`
+			Addr string `json:"addr"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().SavePinnedNode(req.Name, req.Addr))
+	})
+
 	g.POST("/save-refresh-interval", func(c *gin.Context) {
 		var req struct {
 			Name     string `json:"name"`
@@ -115,18 +127,28 @@ func registerConnectionRoutes(rg *gin.RouterGroup) {
 	})
 
 	g.POST("/export", func(c *gin.Context) {
-		c.JSON(http.StatusOK, services.Connection().ExportConnections())
+		var param types.ExportConnectionParam
+		if err := c.ShouldBindJSON(&param); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().ExportConnections(param))
 	})
 
 	g.POST("/import", func(c *gin.Context) {
 		c.JSON(http.StatusOK, services.Connection().ImportConnections())
 	})
 
-	// Web-specific: download connections as zip file
-	g.GET("/export-download", func(c *gin.Context) {
-		data, filename, err := services.Connection().ExportConnectionsToBytes()
+	// Web-specific: download selected connections as zip file
+	g.POST("/export-download", func(c *gin.Context) {
+		var param types.ExportConnectionParam
+		if err := c.ShouldBindJSON(&param); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		data, filename, err := services.Connection().ExportConnectionsToBytes(param)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, types.JSResp{Msg: "export failed"})
+			c.JSON(http.StatusInternalServerError, types.JSResp{Msg: err.Error()})
 			return
 		}
 		c.Header("Content-Disposition", "attachment; filename="+filename)
@@ -135,7 +157,7 @@ func registerConnectionRoutes(rg *gin.RouterGroup) {
 		c.Data(http.StatusOK, "application/zip", data)
 	})
 
-	// Web-specific: import connections from uploaded zip file
+	// Web-specific: import connections from uploaded file
 	g.POST("/import-upload", func(c *gin.Context) {
 		file, err := c.FormFile("file")
 		if err != nil {
@@ -159,6 +181,15 @@ func registerConnectionRoutes(rg *gin.RouterGroup) {
 		c.JSON(http.StatusOK, resp)
 	})
 
+	g.POST("/import-apply", func(c *gin.Context) {
+		var param types.ImportConnectionParam
+		if err := c.ShouldBindJSON(&param); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().ApplyImportConnections(param))
+	})
+
 	g.POST("/list-sentinel-masters", func(c *gin.Context) {
 		var param types.ConnectionConfig
 		if err := c.ShouldBindJSON(&param); err != nil {
@@ -168,6 +199,73 @@ func registerConnectionRoutes(rg *gin.RouterGroup) {
 		c.JSON(http.StatusOK, services.Connection().ListSentinelMasters(param))
 	})
 
+	g.GET("/secret-status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Connection().GetSecretStatus())
+	})
+
+	g.POST("/unlock-secrets", func(c *gin.Context) {
+		var req struct {
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().UnlockSecrets(req.Password))
+	})
+
+	g.POST("/lock-secrets", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Connection().LockSecrets())
+	})
+
+	g.POST("/switch-secret-mode", func(c *gin.Context) {
+		var req struct {
+			Mode     string `json:"mode"`
+			Password string `json:"password"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().SwitchSecretMode(req.Mode, req.Password))
+	})
+
+	g.POST("/trust-host-key", func(c *gin.Context) {
+		var req struct {
+			Host        string `json:"host"`
+			Fingerprint string `json:"fingerprint"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().TrustSSHHostKey(req.Host, req.Fingerprint))
+	})
+
+	g.GET("/ssh-tunnels", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Connection().ListSSHTunnels())
+	})
+
+	g.GET("/ssh-config-hosts", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Connection().ListSSHConfigHosts())
+	})
+
+	g.GET("/cipher-suites", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Connection().ListCipherSuites())
+	})
+
+	g.POST("/snippets", func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name"`
+			Mask bool   `json:"mask"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Connection().GetConnectionSnippets(req.Name, req.Mask))
+	})
+
 	g.POST("/parse-url", func(c *gin.Context) {
 		var req struct {
 			URL string `json:"url"`
@@ -0,0 +1,75 @@
+//go:build web
+
+package api
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"tinyrdm/backend/services"
+	"tinyrdm/backend/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+func registerMetricsRoutes(rg *gin.RouterGroup) {
+	g := rg.Group("/metrics")
+
+	g.POST("/get", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+			From   int64  `json:"from"`
+			To     int64  `json:"to"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Metrics().GetMetrics(req.Server, req.From, req.To))
+	})
+
+	g.POST("/clear", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Metrics().ClearMetrics(req.Server))
+	})
+}
+
+// registerPrometheusRoute exposes metrics in prometheus text format on /metrics,
+// only if METRICS_ENABLED is set. Scrapers can not log in, so bearer token
+// in METRICS_TOKEN is checked instead of session if provided.
+// Login session is still required if no token is set and login is enabled
+func registerPrometheusRoute(r *gin.Engine) {
+	if enabled := strings.ToLower(os.Getenv("METRICS_ENABLED")); enabled != "true" && enabled != "1" {
+		return
+	}
+	token := os.Getenv("METRICS_TOKEN")
+	var handlers []gin.HandlerFunc
+	if token == "" && IsAuthEnabled() {
+		log.Println("Metrics endpoint enabled without METRICS_TOKEN, login session is required to access it")
+		handlers = append(handlers, AuthMiddleware())
+	}
+
+	handlers = append(handlers, func(c *gin.Context) {
+		if token != "" {
+			auth := c.GetHeader("Authorization")
+			if subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) != 1 {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+		}
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := services.Metrics().WritePrometheus(c.Writer, Hub().ClientCount()); err != nil {
+			log.Printf("[metrics] write error: %v", err)
+		}
+	})
+	r.GET("/metrics", handlers...)
+}
@@ -47,6 +47,27 @@ func registerPreferencesRoutes(rg *gin.RouterGroup) {
 		c.JSON(http.StatusOK, services.Preferences().GetBuildInDecoder())
 	})
 
+	g.POST("/cipher-key/set", func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name"`
+			Key  string `json:"key"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Preferences().SetCipherKey(req.Name, req.Key))
+	})
+
+	g.DELETE("/cipher-key/delete", func(c *gin.Context) {
+		name := c.Query("name")
+		c.JSON(http.StatusOK, services.Preferences().DeleteCipherKey(name))
+	})
+
+	g.GET("/cipher-key/list", func(c *gin.Context) {
+		c.JSON(http.StatusOK, services.Preferences().ListCipherKeys())
+	})
+
 	g.GET("/check-update", func(c *gin.Context) {
 		c.JSON(http.StatusOK, services.Preferences().CheckForUpdate())
 	})
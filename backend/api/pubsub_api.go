@@ -47,4 +47,53 @@ func registerPubsubRoutes(rg *gin.RouterGroup) {
 		}
 		c.JSON(http.StatusOK, services.Pubsub().StopSubscribe(req.Server))
 	})
+
+	g.POST("/keyspace-config", func(c *gin.Context) {
+		var req struct {
+			Server string   `json:"server"`
+			Events []string `json:"events"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Pubsub().GetKeyspaceNotifyConfig(req.Server, req.Events))
+	})
+
+	g.POST("/enable-keyspace-notify", func(c *gin.Context) {
+		var req struct {
+			Server string   `json:"server"`
+			Events []string `json:"events"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Pubsub().EnableKeyspaceNotify(req.Server, req.Events))
+	})
+
+	g.POST("/keyspace-subscribe", func(c *gin.Context) {
+		var req struct {
+			Server  string   `json:"server"`
+			DB      int      `json:"db"`
+			Pattern string   `json:"pattern"`
+			Events  []string `json:"events"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Pubsub().StartKeyspaceSubscribe(req.Server, req.DB, req.Pattern, req.Events))
+	})
+
+	g.POST("/keyspace-unsubscribe", func(c *gin.Context) {
+		var req struct {
+			Server string `json:"server"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, types.JSResp{Msg: "invalid request"})
+			return
+		}
+		c.JSON(http.StatusOK, services.Pubsub().StopKeyspaceSubscribe(req.Server))
+	})
 }
@@ -59,6 +59,8 @@ func SetupRouter() *gin.Engine {
 		c.JSON(http.StatusOK, services.Preferences().GetAppVersion())
 	})
 
+	registerPrometheusRoute(r)
+
 	// WebSocket endpoint (auth checked via cookie + origin)
 	r.GET("/ws", wsAuthCheck(), Hub().HandleWebSocket)
 
@@ -70,6 +72,11 @@ func SetupRouter() *gin.Engine {
 	registerCLIRoutes(api)
 	registerMonitorRoutes(api)
 	registerPubsubRoutes(api)
+	registerACLRoutes(api)
+	registerConfigRoutes(api)
+	registerClientRoutes(api)
+	registerMetricsRoutes(api)
+	registerAlertRoutes(api)
 	registerPreferencesRoutes(api)
 	registerSystemRoutes(api)
 
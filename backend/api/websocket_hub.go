@@ -74,6 +74,13 @@ func (h *WSHub) Emit(event string, data any) {
 	}
 }
 
+// ClientCount returns the number of connected WebSocket clients
+func (h *WSHub) ClientCount() int {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.clients)
+}
+
 // HandleWebSocket handles WebSocket upgrade and connection lifecycle
 func (h *WSHub) HandleWebSocket(c *gin.Context) {
 	// Check max clients
@@ -8,3 +8,6 @@ const MIN_WINDOW_WIDTH = 960
 const MIN_WINDOW_HEIGHT = 640
 const DEFAULT_LOAD_SIZE = 10000
 const DEFAULT_SCAN_SIZE = 3000
+const DEFAULT_METRICS_INTERVAL = 5
+const DEFAULT_METRICS_CAPACITY = 720
+const DEFAULT_ALERT_HISTORY_SIZE = 500
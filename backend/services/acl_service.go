@@ -0,0 +1,439 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"tinyrdm/backend/types"
+	strutil "tinyrdm/backend/utils/string"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type aclService struct {
+	ctx context.Context
+}
+
+var acl *aclService
+var onceACL sync.Once
+
+func ACL() *aclService {
+	if acl == nil {
+		onceACL.Do(func() {
+			acl = &aclService{}
+		})
+	}
+	return acl
+}
+
+func (a *aclService) Start(ctx context.Context) {
+	a.ctx = ctx
+}
+
+// getClient get client of whole server, ACL changes should be applied to every node in cluster mode
+func (a *aclService) getClient(server string) (redis.UniversalClient, context.Context, error) {
+	return Browser().getAdminClient(server)
+}
+
+// aclReplyMap convert reply of field-value pairs to map, could be array in RESP2 or map in RESP3
+func aclReplyMap(val any) map[string]any {
+	m := map[string]any{}
+	switch v := val.(type) {
+	case []any:
+		for i := 0; i+1 < len(v); i += 2 {
+			m[fmt.Sprint(v[i])] = v[i+1]
+		}
+	case map[any]any:
+		for k, vv := range v {
+			m[fmt.Sprint(k)] = vv
+		}
+	}
+	return m
+}
+
+func aclReplyStrings(val any) []string {
+	switch v := val.(type) {
+	case []any:
+		list := make([]string, 0, len(v))
+		for _, s := range v {
+			list = append(list, fmt.Sprint(s))
+		}
+		return list
+	case string:
+		return strings.Fields(v)
+	}
+	return nil
+}
+
+func aclReplyInt(val any) int64 {
+	switch v := val.(type) {
+	case int64:
+		return v
+	case string:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	}
+	return 0
+}
+
+func aclReplyFloat(val any) float64 {
+	switch v := val.(type) {
+	case float64:
+		return v
+	case int64:
+		return float64(v)
+	case string:
+		f, _ := strconv.ParseFloat(v, 64)
+		return f
+	}
+	return 0
+}
+
+// aclPatterns join patterns of keys or channels, patterns in redis 6.x are returned without prefix
+func aclPatterns(val any, prefix string) string {
+	if s, ok := val.(string); ok {
+		return s
+	}
+	patterns := aclReplyStrings(val)
+	for i := range patterns {
+		if !strings.HasPrefix(patterns[i], prefix) {
+			patterns[i] = prefix + patterns[i]
+		}
+	}
+	return strings.Join(patterns, " ")
+}
+
+// parseACLUser parse reply of "ACL GETUSER"
+func parseACLUser(name string, val any) types.ACLUser {
+	info := aclReplyMap(val)
+	user := types.ACLUser{
+		Name:      name,
+		Flags:     aclReplyStrings(info["flags"]),
+		Passwords: aclReplyStrings(info["passwords"]),
+		Commands:  fmt.Sprint(info["commands"]),
+		Keys:      aclPatterns(info["keys"], "~"),
+		Channels:  aclPatterns(info["channels"], "&"),
+	}
+	for _, flag := range user.Flags {
+		switch flag {
+		case "on":
+			user.Enabled = true
+		case "nopass":
+			user.NoPass = true
+		case "allkeys":
+			if len(user.Keys) <= 0 {
+				user.Keys = "~*"
+			}
+		case "allchannels":
+			if len(user.Channels) <= 0 {
+				user.Channels = "&*"
+			}
+		}
+	}
+	if selectors, ok := info["selectors"].([]any); ok {
+		for _, s := range selectors {
+			selector := aclReplyMap(s)
+			user.Selectors = append(user.Selectors, types.ACLSelector{
+				Commands: fmt.Sprint(selector["commands"]),
+				Keys:     aclPatterns(selector["keys"], "~"),
+				Channels: aclPatterns(selector["channels"], "&"),
+			})
+		}
+	}
+	return user
+}
+
+// buildACLRules build rules of "ACL SETUSER" from form, user is reset before applying rules
+func buildACLRules(form types.ACLUserForm) []string {
+	rules := []string{"reset"}
+	if form.Enabled {
+		rules = append(rules, "on")
+	} else {
+		rules = append(rules, "off")
+	}
+	if form.NoPass {
+		rules = append(rules, "nopass")
+	} else {
+		for _, hash := range form.Passwords {
+			if hash = strings.TrimSpace(hash); len(hash) > 0 {
+				rules = append(rules, "#"+hash)
+			}
+		}
+		for _, pwd := range form.AddPasswords {
+			if len(pwd) > 0 {
+				rules = append(rules, ">"+pwd)
+			}
+		}
+	}
+	for _, key := range form.Keys {
+		if key = strings.TrimSpace(key); len(key) > 0 {
+			if key != "allkeys" && key != "resetkeys" && !strings.HasPrefix(key, "~") && !strings.HasPrefix(key, "%") {
+				key = "~" + key
+			}
+			rules = append(rules, key)
+		}
+	}
+	for _, channel := range form.Channels {
+		if channel = strings.TrimSpace(channel); len(channel) > 0 {
+			if channel != "allchannels" && channel != "resetchannels" && !strings.HasPrefix(channel, "&") {
+				channel = "&" + channel
+			}
+			rules = append(rules, channel)
+		}
+	}
+	for _, cmd := range form.Commands {
+		if cmd = strings.TrimSpace(cmd); len(cmd) > 0 {
+			if cmd != "allcommands" && cmd != "nocommands" && !strings.HasPrefix(cmd, "+") && !strings.HasPrefix(cmd, "-") {
+				cmd = "+" + cmd
+			}
+			rules = append(rules, cmd)
+		}
+	}
+	for _, selector := range form.Selectors {
+		if selector = strings.TrimSpace(selector); len(selector) > 0 {
+			if !strings.HasPrefix(selector, "(") {
+				selector = "(" + selector + ")"
+			}
+			rules = append(rules, selector)
+		}
+	}
+	return rules
+}
+
+// ListUsers list all users with their rules, and categories of command for editor
+func (a *aclService) ListUsers(server string) (resp types.JSResp) {
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	// rule is like "user default on nopass sanitize-payload ~* &* +@all"
+	lines, err := client.ACLList(ctx).Result()
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	users := make([]types.ACLUser, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 || fields[0] != "user" {
+			continue
+		}
+		name := fields[1]
+		res, err := client.Do(ctx, "acl", "getuser", name).Result()
+		if err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+		user := parseACLUser(name, res)
+		user.Rule = line
+		users = append(users, user)
+	}
+
+	categories, _ := client.ACLCat(ctx).Result()
+	sort.Strings(categories)
+	current, _ := client.ACLWhoAmI(ctx).Result()
+	resp.Success = true
+	resp.Data = map[string]any{
+		"users":      users,
+		"categories": categories,
+		"current":    current,
+	}
+	return
+}
+
+// SaveUser create or overwrite user with structured rules
+func (a *aclService) SaveUser(server string, form types.ACLUserForm) (resp types.JSResp) {
+	form.Name = strings.TrimSpace(form.Name)
+	if len(form.Name) <= 0 || strings.ContainsAny(form.Name, " \t\r\n") {
+		resp.Msg = "invalid user name"
+		return
+	}
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	rules := buildACLRules(form)
+	// ACL is not propagated between nodes in cluster
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.ACLSetUser(ctx, form.Name, rules...).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// DeleteUser delete user, all clients authenticated by this user will be disconnected
+func (a *aclService) DeleteUser(server, name string) (resp types.JSResp) {
+	if name == "default" {
+		resp.Msg = "default user could not be deleted"
+		return
+	}
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.ACLDelUser(ctx, name).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// DryRun test whether a command could be executed by user without actually running it, requires redis 7.0+
+func (a *aclService) DryRun(server, name, cmd string) (resp types.JSResp) {
+	args := strutil.SplitCmd(cmd)
+	if len(args) <= 0 || len(args[0]) <= 0 {
+		resp.Msg = "command is empty"
+		return
+	}
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	params := make([]any, len(args))
+	for i := range args {
+		params[i] = args[i]
+	}
+	// denied reason is replied as a normal string
+	res, err := client.ACLDryRun(ctx, name, params...).Result()
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"allowed": strings.EqualFold(res, "OK"),
+		"msg":     res,
+	}
+	return
+}
+
+// GetLog get recent security events of denied commands and failed authentications
+func (a *aclService) GetLog(server string, count int64) (resp types.JSResp) {
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	count = max(1, count)
+
+	var mutex sync.Mutex
+	var entries []types.ACLLogEntry
+	_, isCluster := client.(*redis.ClusterClient)
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		res, err := cli.Do(ctx, "acl", "log", count).Slice()
+		if err != nil {
+			return err
+		}
+		var node string
+		if c, ok := cli.(*redis.Client); ok && isCluster {
+			node = c.Options().Addr
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, r := range res {
+			entries = append(entries, parseACLLogEntry(node, aclReplyMap(r)))
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUpdated > entries[j].LastUpdated
+	})
+	if len(entries) > int(count) {
+		entries = entries[:count]
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"entries": entries,
+	}
+	return
+}
+
+// parseACLLogEntry parse entry of "ACL LOG", client info is like "id=3 addr=127.0.0.1:57275 ... user=default"
+func parseACLLogEntry(node string, info map[string]any) types.ACLLogEntry {
+	entry := types.ACLLogEntry{
+		Node:        node,
+		EntryID:     aclReplyInt(info["entry-id"]),
+		Count:       aclReplyInt(info["count"]),
+		Reason:      fmt.Sprint(info["reason"]),
+		Context:     fmt.Sprint(info["context"]),
+		Object:      fmt.Sprint(info["object"]),
+		Username:    fmt.Sprint(info["username"]),
+		AgeSeconds:  aclReplyFloat(info["age-seconds"]),
+		Client:      map[string]string{},
+		CreatedAt:   aclReplyInt(info["timestamp-created"]),
+		LastUpdated: aclReplyInt(info["timestamp-last-updated"]),
+	}
+	for _, field := range strings.Fields(fmt.Sprint(info["client-info"])) {
+		if k, v, ok := strings.Cut(field, "="); ok {
+			entry.Client[k] = v
+		}
+	}
+	return entry
+}
+
+// ResetLog clear all entries of ACL log
+func (a *aclService) ResetLog(server string) (resp types.JSResp) {
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.ACLLogReset(ctx).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// SaveACL save current ACL rules to acl file by "ACL SAVE", or reload from acl file by "ACL LOAD",
+// only available when aclfile is configured
+func (a *aclService) SaveACL(server string, load bool) (resp types.JSResp) {
+	client, ctx, err := a.getClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	subCmd := "save"
+	if load {
+		subCmd = "load"
+	}
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.Do(ctx, "acl", subCmd).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
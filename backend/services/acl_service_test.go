@@ -0,0 +1,58 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+func TestBuildACLRules(t *testing.T) {
+	tests := []struct {
+		name string
+		form types.ACLUserForm
+		want []string
+	}{
+		{
+			name: "disabled user",
+			form: types.ACLUserForm{Name: "u"},
+			want: []string{"reset", "off"},
+		},
+		{
+			name: "nopass ignores passwords",
+			form: types.ACLUserForm{Enabled: true, NoPass: true, Passwords: []string{"abc"}, AddPasswords: []string{"pwd"}},
+			want: []string{"reset", "on", "nopass"},
+		},
+		{
+			name: "keep hashes and add passwords",
+			form: types.ACLUserForm{Enabled: true, Passwords: []string{" abc ", ""}, AddPasswords: []string{"pwd", ""}},
+			want: []string{"reset", "on", "#abc", ">pwd"},
+		},
+		{
+			name: "prefix keys",
+			form: types.ACLUserForm{Enabled: true, Keys: []string{"user:*", "~order:*", "%R~cache:*", "allkeys", " "}},
+			want: []string{"reset", "on", "~user:*", "~order:*", "%R~cache:*", "allkeys"},
+		},
+		{
+			name: "prefix channels",
+			form: types.ACLUserForm{Enabled: true, Channels: []string{"news.*", "&chat", "allchannels"}},
+			want: []string{"reset", "on", "&news.*", "&chat", "allchannels"},
+		},
+		{
+			name: "prefix commands",
+			form: types.ACLUserForm{Enabled: true, Commands: []string{"get", "+@read", "-keys", "allcommands"}},
+			want: []string{"reset", "on", "+get", "+@read", "-keys", "allcommands"},
+		},
+		{
+			name: "wrap selectors",
+			form: types.ACLUserForm{Enabled: true, Selectors: []string{"~tmp:* +@write", "(~a +get)"}},
+			want: []string{"reset", "on", "(~tmp:* +@write)", "(~a +get)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildACLRules(tt.form); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildACLRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
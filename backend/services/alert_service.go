@@ -0,0 +1,469 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinyrdm/backend/consts"
+	"tinyrdm/backend/storage"
+	"tinyrdm/backend/types"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+var alertMetrics = []string{
+	types.ALERT_METRIC_MEMORY_RATIO,
+	types.ALERT_METRIC_MEMORY_USED,
+	types.ALERT_METRIC_CLIENTS,
+	types.ALERT_METRIC_REPLICA_LAG,
+	types.ALERT_METRIC_REJECTED_CONNS,
+	types.ALERT_METRIC_EVICTED_RATE,
+	types.ALERT_METRIC_SLOWLOG_GROWTH,
+}
+
+var alertOperators = []string{">", ">=", "<", "<="}
+
+// alertCounters cumulative counters of node in last sample, to calculate increment
+type alertCounters struct {
+	timestamp int64
+	rejected  int64
+	evicted   int64
+	slowLogID int64 // id of the latest slow log, -1 if unknown
+}
+
+// alertState condition state of a rule on a node
+type alertState struct {
+	since  int64 // timestamp when condition began to be satisfied
+	firing bool
+}
+
+type alertService struct {
+	ctx      context.Context
+	storage  *storage.AlertsStorage
+	settings types.AlertSettings
+	counters map[string]alertCounters // key by server and node
+	states   map[string]*alertState   // key by rule id, server and node
+	history  []types.AlertEvent
+	mutex    sync.Mutex
+}
+
+var alert *alertService
+var onceAlert sync.Once
+
+func Alert() *alertService {
+	if alert == nil {
+		onceAlert.Do(func() {
+			alert = &alertService{
+				storage:  storage.NewAlerts(),
+				counters: map[string]alertCounters{},
+				states:   map[string]*alertState{},
+			}
+		})
+	}
+	return alert
+}
+
+func (a *alertService) Start(ctx context.Context) {
+	a.ctx = ctx
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.settings = a.storage.GetSettings()
+	a.history = a.storage.GetHistory()
+}
+
+func alertNodeKey(server, node string) string {
+	return server + "\n" + node
+}
+
+func alertStateKey(ruleID, server, node string) string {
+	return ruleID + "\n" + server + "\n" + node
+}
+
+// rulesOf enabled rules of connection
+func (a *alertService) rulesOf(server string) []types.AlertRule {
+	var rules []types.AlertRule
+	for _, rule := range a.settings.Rules {
+		if rule.Enable && (rule.Server == "" || rule.Server == server) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// replicaLag max lag of replicas in master, or seconds since last interaction with master in replica
+func replicaLag(info map[string]string) (float64, bool) {
+	switch info["role"] {
+	case "master":
+		// replica is like "slave0:ip=127.0.0.1,port=6380,state=online,offset=1,lag=0"
+		count, _ := strconv.Atoi(info["connected_slaves"])
+		var lag float64
+		var found bool
+		for i := 0; i < count; i++ {
+			for _, field := range strings.Split(info["slave"+strconv.Itoa(i)], ",") {
+				if k, v, ok := strings.Cut(field, "="); ok && k == "lag" {
+					if n, err := strconv.ParseFloat(v, 64); err == nil {
+						lag, found = max(lag, n), true
+					}
+				}
+			}
+		}
+		return lag, found
+	case "slave":
+		field := "master_last_io_seconds_ago"
+		if info["master_link_status"] != "up" {
+			field = "master_link_down_since_seconds"
+		}
+		n, err := strconv.ParseFloat(info[field], 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// metricValues extract values of metrics from INFO, metrics based on increment are absent in the first sample
+func (a *alertService) metricValues(key string, now int64, info map[string]map[string]string, slowLogID int64) map[string]float64 {
+	parseInt := func(section, field string) int64 {
+		n, _ := strconv.ParseInt(info[section][field], 10, 64)
+		return n
+	}
+
+	values := map[string]float64{
+		types.ALERT_METRIC_MEMORY_USED: float64(parseInt("Memory", "used_memory")) / 1024 / 1024,
+		types.ALERT_METRIC_CLIENTS:     float64(parseInt("Clients", "connected_clients")),
+	}
+	if maxMemory := parseInt("Memory", "maxmemory"); maxMemory > 0 {
+		values[types.ALERT_METRIC_MEMORY_RATIO] = float64(parseInt("Memory", "used_memory")) * 100 / float64(maxMemory)
+	}
+	if lag, ok := replicaLag(info["Replication"]); ok {
+		values[types.ALERT_METRIC_REPLICA_LAG] = lag
+	}
+
+	cur := alertCounters{
+		timestamp: now,
+		rejected:  parseInt("Stats", "rejected_connections"),
+		evicted:   parseInt("Stats", "evicted_keys"),
+		slowLogID: slowLogID,
+	}
+	last, ok := a.counters[key]
+	if cur.slowLogID < 0 && ok {
+		cur.slowLogID = last.slowLogID
+	}
+	a.counters[key] = cur
+	// counters are reset after server restarted
+	if ok && cur.rejected >= last.rejected && cur.evicted >= last.evicted {
+		values[types.ALERT_METRIC_REJECTED_CONNS] = float64(cur.rejected - last.rejected)
+		if elapsed := float64(cur.timestamp-last.timestamp) / 1000; elapsed > 0 {
+			values[types.ALERT_METRIC_EVICTED_RATE] = float64(cur.evicted-last.evicted) / elapsed
+		}
+	}
+	if ok && last.slowLogID >= 0 && cur.slowLogID >= last.slowLogID {
+		values[types.ALERT_METRIC_SLOWLOG_GROWTH] = float64(cur.slowLogID - last.slowLogID)
+	}
+	return values
+}
+
+func compareAlert(value float64, operator string, threshold float64) bool {
+	switch operator {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	}
+	return false
+}
+
+// evaluate check rules of connection with sampled INFO of node, called by metrics collector
+func (a *alertService) evaluate(ctx context.Context, server, node string, cli redis.UniversalClient, info map[string]map[string]string) {
+	a.mutex.Lock()
+	rules := a.rulesOf(server)
+	a.mutex.Unlock()
+	if len(rules) <= 0 {
+		return
+	}
+
+	// the id of slow log keeps increasing even after "SLOWLOG RESET"
+	slowLogID := int64(-1)
+	if slices.ContainsFunc(rules, func(r types.AlertRule) bool { return r.Metric == types.ALERT_METRIC_SLOWLOG_GROWTH }) {
+		if logs, err := cli.SlowLogGet(ctx, 1).Result(); err == nil && len(logs) > 0 {
+			slowLogID = logs[0].ID
+		}
+	}
+
+	now := time.Now().UnixMilli()
+	a.mutex.Lock()
+	values := a.metricValues(alertNodeKey(server, node), now, info, slowLogID)
+	events := a.checkRules(rules, server, node, now, values)
+	a.mutex.Unlock()
+
+	for _, event := range events {
+		a.deliver(event)
+	}
+}
+
+// checkRules update condition states of rules on node, returns events of rules fired or resolved.
+// should be called with lock held
+func (a *alertService) checkRules(rules []types.AlertRule, server, node string, now int64, values map[string]float64) (events []types.AlertEvent) {
+	for _, rule := range rules {
+		value, ok := values[rule.Metric]
+		if !ok {
+			continue
+		}
+		stateKey := alertStateKey(rule.ID, server, node)
+		state := a.states[stateKey]
+		event := types.AlertEvent{
+			Timestamp: now,
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Server:    server,
+			Node:      node,
+			Metric:    rule.Metric,
+			Operator:  rule.Operator,
+			Threshold: rule.Threshold,
+			Value:     value,
+		}
+		if compareAlert(value, rule.Operator, rule.Threshold) {
+			if state == nil {
+				state = &alertState{since: now}
+				a.states[stateKey] = state
+			}
+			if !state.firing && now-state.since >= int64(rule.Duration)*1000 {
+				state.firing = true
+				event.State = types.ALERT_STATE_FIRING
+				events = append(events, event)
+			}
+		} else if state != nil {
+			if state.firing {
+				event.State = types.ALERT_STATE_RESOLVED
+				events = append(events, event)
+			}
+			delete(a.states, stateKey)
+		}
+	}
+	return
+}
+
+// alertMessage title and body of event for notification
+func alertMessage(event types.AlertEvent) (title, body string) {
+	title = fmt.Sprintf("[%s] %s", strings.ToUpper(event.State), event.RuleName)
+	target := event.Server
+	if len(event.Node) > 0 {
+		target += " (" + event.Node + ")"
+	}
+	body = fmt.Sprintf("%s: %s = %s, threshold %s %s", target, event.Metric,
+		strconv.FormatFloat(event.Value, 'f', -1, 64), event.Operator,
+		strconv.FormatFloat(event.Threshold, 'f', -1, 64))
+	return
+}
+
+// postWebhook post event to webhook, "text" field is compatible with Slack and Mattermost
+func postWebhook(ctx context.Context, webhook string, event types.AlertEvent) error {
+	title, body := alertMessage(event)
+	b, err := json.Marshal(map[string]any{
+		"text":  title + "\n" + body,
+		"event": event,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancelFunc := context.WithTimeout(ctx, 10*time.Second)
+	defer cancelFunc()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// notify send event by desktop notification, or webhook in web mode
+func (a *alertService) notify(settings types.AlertSettings, event types.AlertEvent) error {
+	if IsWeb() {
+		if len(settings.Webhook) <= 0 {
+			return nil
+		}
+		return postWebhook(context.Background(), settings.Webhook, event)
+	}
+	if !settings.Notify {
+		return nil
+	}
+	title, body := alertMessage(event)
+	return SendNotification(a.ctx, title, body)
+}
+
+// deliver record event into history and notify
+func (a *alertService) deliver(event types.AlertEvent) {
+	a.mutex.Lock()
+	a.history = append(a.history, event)
+	if len(a.history) > consts.DEFAULT_ALERT_HISTORY_SIZE {
+		a.history = a.history[len(a.history)-consts.DEFAULT_ALERT_HISTORY_SIZE:]
+	}
+	history := slices.Clone(a.history)
+	settings := a.settings
+	a.mutex.Unlock()
+
+	if err := a.storage.SaveHistory(history); err != nil {
+		log.Printf("save alert history error: %v\n", err)
+	}
+	EventsEmit(a.ctx, "alert:event", event)
+	go func() {
+		if err := a.notify(settings, event); err != nil {
+			log.Printf("send alert notification error: %v\n", err)
+		}
+	}()
+}
+
+// validateAlertSettings check rules and webhook, id is generated for new rule
+func validateAlertSettings(settings *types.AlertSettings) error {
+	if len(settings.Webhook) > 0 {
+		u, err := url.Parse(settings.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) <= 0 {
+			return errors.New("invalid webhook url")
+		}
+	}
+	if settings.Rules == nil {
+		settings.Rules = []types.AlertRule{}
+	}
+	for i := range settings.Rules {
+		rule := &settings.Rules[i]
+		if !slices.Contains(alertMetrics, rule.Metric) {
+			return fmt.Errorf("unknown metric \"%s\"", rule.Metric)
+		}
+		if !slices.Contains(alertOperators, rule.Operator) {
+			return fmt.Errorf("unknown operator \"%s\"", rule.Operator)
+		}
+		rule.Name = strings.TrimSpace(rule.Name)
+		if len(rule.Name) <= 0 {
+			rule.Name = rule.Metric
+		}
+		rule.Duration = max(0, rule.Duration)
+		if len(rule.ID) <= 0 {
+			rule.ID = uuid.NewString()
+		}
+	}
+	return nil
+}
+
+// GetAlertSettings get alert rules and notification settings
+func (a *alertService) GetAlertSettings() (resp types.JSResp) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	resp.Success = true
+	resp.Data = a.settings
+	return
+}
+
+// SaveAlertSettings save alert rules and notification settings,
+// states of modified rules are reset
+func (a *alertService) SaveAlertSettings(settings types.AlertSettings) (resp types.JSResp) {
+	if err := validateAlertSettings(&settings); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if err := a.storage.SaveSettings(settings); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	unchanged := map[string]bool{}
+	for _, rule := range settings.Rules {
+		if idx := slices.IndexFunc(a.settings.Rules, func(r types.AlertRule) bool { return r.ID == rule.ID }); idx >= 0 {
+			unchanged[rule.ID] = reflect.DeepEqual(a.settings.Rules[idx], rule)
+		}
+	}
+	for key := range a.states {
+		ruleID, _, _ := strings.Cut(key, "\n")
+		if !unchanged[ruleID] {
+			delete(a.states, key)
+		}
+	}
+	a.settings = settings
+	resp.Success = true
+	resp.Data = settings
+	return
+}
+
+// TestAlertNotification send a test event with settings not saved yet
+func (a *alertService) TestAlertNotification(settings types.AlertSettings) (resp types.JSResp) {
+	if err := validateAlertSettings(&settings); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if IsWeb() && len(settings.Webhook) <= 0 {
+		resp.Msg = "webhook url is required"
+		return
+	}
+	settings.Notify = true
+	event := types.AlertEvent{
+		Timestamp: time.Now().UnixMilli(),
+		State:     types.ALERT_STATE_FIRING,
+		RuleName:  "Test",
+		Server:    "Tiny RDM",
+		Metric:    types.ALERT_METRIC_MEMORY_RATIO,
+		Operator:  ">",
+		Threshold: 90,
+		Value:     95,
+	}
+	if err := a.notify(settings, event); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// GetAlertHistory get fired and resolved alert events, the latest comes first
+func (a *alertService) GetAlertHistory() (resp types.JSResp) {
+	a.mutex.Lock()
+	history := slices.Clone(a.history)
+	a.mutex.Unlock()
+
+	slices.Reverse(history)
+	if history == nil {
+		history = []types.AlertEvent{}
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"history": history,
+	}
+	return
+}
+
+// CleanAlertHistory remove all alert events
+func (a *alertService) CleanAlertHistory() (resp types.JSResp) {
+	a.mutex.Lock()
+	a.history = nil
+	a.mutex.Unlock()
+
+	if err := a.storage.CleanHistory(); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+func newTestAlertService() *alertService {
+	return &alertService{
+		counters: map[string]alertCounters{},
+		states:   map[string]*alertState{},
+	}
+}
+
+func TestReplicaLag(t *testing.T) {
+	tests := []struct {
+		name   string
+		info   map[string]string
+		want   float64
+		wantOk bool
+	}{
+		{"master", map[string]string{
+			"role": "master", "connected_slaves": "2",
+			"slave0": "ip=10.0.0.2,port=6379,state=online,offset=1,lag=1",
+			"slave1": "ip=10.0.0.3,port=6379,state=online,offset=1,lag=4",
+		}, 4, true},
+		{"master without replica", map[string]string{"role": "master", "connected_slaves": "0"}, 0, false},
+		{"replica linked", map[string]string{
+			"role": "slave", "master_link_status": "up", "master_last_io_seconds_ago": "2",
+		}, 2, true},
+		{"replica link down", map[string]string{
+			"role": "slave", "master_link_status": "down", "master_last_io_seconds_ago": "-1",
+			"master_link_down_since_seconds": "30",
+		}, 30, true},
+		{"unknown role", map[string]string{}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := replicaLag(tt.info)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("replicaLag() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestAlertMetricValues(t *testing.T) {
+	sample := func(used, rejected, evicted string) map[string]map[string]string {
+		return map[string]map[string]string{
+			"Memory":  {"used_memory": used, "maxmemory": "2097152"},
+			"Clients": {"connected_clients": "3"},
+			"Stats":   {"rejected_connections": rejected, "evicted_keys": evicted},
+		}
+	}
+	tests := []struct {
+		name      string
+		now       int64
+		info      map[string]map[string]string
+		slowLogID int64
+		want      map[string]float64
+	}{
+		{
+			name:      "first sample",
+			now:       1000,
+			info:      sample("1048576", "5", "100"),
+			slowLogID: 10,
+			want: map[string]float64{
+				types.ALERT_METRIC_MEMORY_USED:  1,
+				types.ALERT_METRIC_MEMORY_RATIO: 50,
+				types.ALERT_METRIC_CLIENTS:      3,
+			},
+		},
+		{
+			name:      "increments",
+			now:       3000,
+			info:      sample("1048576", "7", "140"),
+			slowLogID: 13,
+			want: map[string]float64{
+				types.ALERT_METRIC_MEMORY_USED:    1,
+				types.ALERT_METRIC_MEMORY_RATIO:   50,
+				types.ALERT_METRIC_CLIENTS:        3,
+				types.ALERT_METRIC_REJECTED_CONNS: 2,
+				types.ALERT_METRIC_EVICTED_RATE:   20,
+				types.ALERT_METRIC_SLOWLOG_GROWTH: 3,
+			},
+		},
+		{
+			name:      "slow log unknown",
+			now:       4000,
+			info:      sample("1048576", "7", "140"),
+			slowLogID: -1,
+			want: map[string]float64{
+				types.ALERT_METRIC_MEMORY_USED:    1,
+				types.ALERT_METRIC_MEMORY_RATIO:   50,
+				types.ALERT_METRIC_CLIENTS:        3,
+				types.ALERT_METRIC_REJECTED_CONNS: 0,
+				types.ALERT_METRIC_EVICTED_RATE:   0,
+				types.ALERT_METRIC_SLOWLOG_GROWTH: 0,
+			},
+		},
+		{
+			name:      "server restarted",
+			now:       5000,
+			info:      sample("1048576", "0", "0"),
+			slowLogID: 14,
+			want: map[string]float64{
+				types.ALERT_METRIC_MEMORY_USED:    1,
+				types.ALERT_METRIC_MEMORY_RATIO:   50,
+				types.ALERT_METRIC_CLIENTS:        3,
+				types.ALERT_METRIC_SLOWLOG_GROWTH: 1,
+			},
+		},
+	}
+	// samples of the same node in order
+	a := newTestAlertService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := a.metricValues("node", tt.now, tt.info, tt.slowLogID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("metricValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAlertCheckRules(t *testing.T) {
+	rules := []types.AlertRule{
+		{ID: "mem", Name: "memory", Enable: true, Metric: types.ALERT_METRIC_MEMORY_RATIO, Operator: ">=", Threshold: 90},
+		{ID: "lag", Name: "lag", Enable: true, Metric: types.ALERT_METRIC_REPLICA_LAG, Operator: ">", Threshold: 10, Duration: 5},
+	}
+	tests := []struct {
+		name   string
+		now    int64
+		values map[string]float64
+		want   []string // rule id and state of events
+	}{
+		{"normal", 0, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 50, types.ALERT_METRIC_REPLICA_LAG: 1}, nil},
+		{"memory fired at once", 1000, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 90, types.ALERT_METRIC_REPLICA_LAG: 20}, []string{"mem firing"}},
+		{"lag lasts not long enough", 4000, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 95, types.ALERT_METRIC_REPLICA_LAG: 20}, nil},
+		{"lag fired after duration", 6000, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 95, types.ALERT_METRIC_REPLICA_LAG: 20}, []string{"lag firing"}},
+		{"fired only once", 7000, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 95, types.ALERT_METRIC_REPLICA_LAG: 20}, nil},
+		{"metric absent keeps state", 8000, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 95}, nil},
+		{"resolved", 9000, map[string]float64{types.ALERT_METRIC_MEMORY_RATIO: 10, types.ALERT_METRIC_REPLICA_LAG: 0}, []string{"mem resolved", "lag resolved"}},
+		{"lag restarts duration", 10000, map[string]float64{types.ALERT_METRIC_REPLICA_LAG: 20}, nil},
+		{"lag recovered before fired", 12000, map[string]float64{types.ALERT_METRIC_REPLICA_LAG: 0}, nil},
+	}
+	a := newTestAlertService()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, event := range a.checkRules(rules, "server", "node", tt.now, tt.values) {
+				got = append(got, event.RuleID+" "+event.State)
+				if event.Server != "server" || event.Node != "node" || event.Timestamp != tt.now {
+					t.Errorf("event = %+v", event)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAlertSettings(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings types.AlertSettings
+		wantErr  bool
+	}{
+		{"empty", types.AlertSettings{}, false},
+		{"webhook", types.AlertSettings{Webhook: "https://hooks.local/alert"}, false},
+		{"webhook not http", types.AlertSettings{Webhook: "ftp://hooks.local"}, true},
+		{"webhook without host", types.AlertSettings{Webhook: "http://"}, true},
+		{"unknown metric", types.AlertSettings{Rules: []types.AlertRule{{Metric: "cpu", Operator: ">"}}}, true},
+		{"unknown operator", types.AlertSettings{Rules: []types.AlertRule{{Metric: types.ALERT_METRIC_CLIENTS, Operator: "=="}}}, true},
+		{"rule", types.AlertSettings{Rules: []types.AlertRule{{Name: " ", Metric: types.ALERT_METRIC_CLIENTS, Operator: ">", Duration: -1}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAlertSettings(&tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateAlertSettings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tt.settings.Rules == nil {
+				t.Errorf("rules should not be nil")
+			}
+			for _, rule := range tt.settings.Rules {
+				if len(rule.ID) <= 0 || rule.Name != rule.Metric || rule.Duration != 0 {
+					t.Errorf("rule = %+v, want id generated, name defaulted and duration not negative", rule)
+				}
+			}
+		})
+	}
+}
+
+func TestPostWebhook(t *testing.T) {
+	event := types.AlertEvent{
+		State: types.ALERT_STATE_FIRING, RuleName: "memory", Server: "local", Node: "10.0.0.1:6379",
+		Metric: types.ALERT_METRIC_MEMORY_RATIO, Operator: ">", Threshold: 90, Value: 95.5,
+	}
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{"delivered", http.StatusOK, false},
+		{"rejected", http.StatusBadRequest, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&body)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := postWebhook(context.Background(), server.URL, event)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("postWebhook() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			want := "[FIRING] memory\nlocal (10.0.0.1:6379): memory_ratio = 95.5, threshold > 90"
+			if body["text"] != want {
+				t.Errorf("text = %q, want %q", body["text"], want)
+			}
+		})
+	}
+}
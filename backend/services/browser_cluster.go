@@ -0,0 +1,584 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const clusterSlotCount = 16384
+const clusterMigrateBatch = 100
+const clusterMigrateTimeout = 10 * time.Second
+
+// clusterNodes clients of cluster nodes, nodes unknown by cluster client(e.g. master without slots)
+// are connected temporarily with the same options
+type clusterNodes struct {
+	cluster *redis.ClusterClient
+	mutex   sync.Mutex
+	clients map[string]*redis.Client
+	temp    []*redis.Client
+}
+
+func newClusterNodes(ctx context.Context, cluster *redis.ClusterClient) *clusterNodes {
+	n := &clusterNodes{
+		cluster: cluster,
+		clients: map[string]*redis.Client{},
+	}
+	cluster.ForEachShard(ctx, func(ctx context.Context, cli *redis.Client) error {
+		n.mutex.Lock()
+		n.clients[cli.Options().Addr] = cli
+		n.mutex.Unlock()
+		return nil
+	})
+	return n
+}
+
+func (n *clusterNodes) client(addr string) *redis.Client {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	if cli, ok := n.clients[addr]; ok {
+		return cli
+	}
+	opt := n.cluster.Options()
+	cli := redis.NewClient(&redis.Options{
+		Addr:            addr,
+		Dialer:          opt.Dialer,
+		Protocol:        opt.Protocol,
+		Username:        opt.Username,
+		Password:        opt.Password,
+		DialTimeout:     opt.DialTimeout,
+		ReadTimeout:     opt.ReadTimeout,
+		WriteTimeout:    opt.WriteTimeout,
+		TLSConfig:       opt.TLSConfig,
+		DisableIdentity: opt.DisableIdentity,
+	})
+	n.clients[addr] = cli
+	n.temp = append(n.temp, cli)
+	return cli
+}
+
+func (n *clusterNodes) Close() {
+	for _, cli := range n.temp {
+		cli.Close()
+	}
+}
+
+func (b *browserService) getClusterClient(server string) (*redis.ClusterClient, context.Context, error) {
+	client, ctx, err := b.getAdminClient(server)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster, ctx, nil
+	}
+	return nil, nil, errors.New("not a cluster connection")
+}
+
+// parseClusterNodes parse content of "CLUSTER NODES", each line is like
+// <id> <ip:port@cport[,hostname]> <flags> <master> <ping-sent> <pong-recv> <config-epoch> <link-state> <slot> ...
+func parseClusterNodes(content string) (nodes []types.ClusterNode) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			continue
+		}
+		node := types.ClusterNode{
+			ID:    fields[0],
+			Flags: strings.Split(fields[2], ","),
+		}
+		addr, hostname, _ := strings.Cut(fields[1], ",")
+		node.Addr, _, _ = strings.Cut(addr, "@")
+		node.Hostname = hostname
+		for _, flag := range node.Flags {
+			switch flag {
+			case "myself":
+				node.Myself = true
+			case "master":
+				node.Role = "master"
+			case "slave":
+				node.Role = "replica"
+			case "fail", "fail?":
+				node.Failing = true
+			}
+		}
+		if fields[3] != "-" {
+			node.MasterID = fields[3]
+		}
+		node.ConfigEpoch, _ = strconv.ParseInt(fields[6], 10, 64)
+		node.Connected = fields[7] == "connected"
+
+		for _, slot := range fields[8:] {
+			if strings.HasPrefix(slot, "[") {
+				// slot in migration, like [slot->-target] or [slot-<-source]
+				slot = strings.Trim(slot, "[]")
+				if s, id, ok := strings.Cut(slot, "->-"); ok {
+					if node.Migrating == nil {
+						node.Migrating = map[int]string{}
+					}
+					idx, _ := strconv.Atoi(s)
+					node.Migrating[idx] = id
+				} else if s, id, ok = strings.Cut(slot, "-<-"); ok {
+					if node.Importing == nil {
+						node.Importing = map[int]string{}
+					}
+					idx, _ := strconv.Atoi(s)
+					node.Importing[idx] = id
+				}
+				continue
+			}
+			startStr, endStr, isRange := strings.Cut(slot, "-")
+			start, err := strconv.Atoi(startStr)
+			if err != nil {
+				continue
+			}
+			end := start
+			if isRange {
+				if end, err = strconv.Atoi(endStr); err != nil {
+					continue
+				}
+			}
+			node.Slots = append(node.Slots, [2]int{start, end})
+			node.SlotCount += end - start + 1
+		}
+		nodes = append(nodes, node)
+	}
+	return
+}
+
+func (b *browserService) loadClusterTopology(ctx context.Context, cluster *redis.ClusterClient, nodes *clusterNodes, withStats bool) (topo types.ClusterTopology, err error) {
+	var content string
+	if content, err = cluster.ClusterNodes(ctx).Result(); err != nil {
+		return
+	}
+	topo.Nodes = parseClusterNodes(content)
+
+	if info, infoErr := cluster.ClusterInfo(ctx).Result(); infoErr == nil {
+		for _, line := range strings.Split(info, "\n") {
+			k, v, _ := strings.Cut(strings.TrimSpace(line), ":")
+			switch k {
+			case "cluster_state":
+				topo.State = v
+			case "cluster_slots_assigned":
+				topo.SlotsAssigned, _ = strconv.Atoi(v)
+			case "cluster_slots_pfail":
+				topo.SlotsPFail, _ = strconv.Atoi(v)
+			case "cluster_slots_fail":
+				topo.SlotsFail, _ = strconv.Atoi(v)
+			case "cluster_size":
+				topo.Size, _ = strconv.Atoi(v)
+			}
+		}
+	}
+
+	// health of nodes is only available by "CLUSTER SHARDS" since redis 7.0
+	if shards, shardErr := cluster.ClusterShards(ctx).Result(); shardErr == nil {
+		health := map[string]redis.Node{}
+		for _, shard := range shards {
+			for _, node := range shard.Nodes {
+				health[node.ID] = node
+			}
+		}
+		for i := range topo.Nodes {
+			if node, ok := health[topo.Nodes[i].ID]; ok {
+				topo.Nodes[i].Health = node.Health
+				if len(topo.Nodes[i].Hostname) <= 0 {
+					topo.Nodes[i].Hostname = node.Hostname
+				}
+			}
+		}
+	}
+
+	if withStats {
+		var wg sync.WaitGroup
+		for i := range topo.Nodes {
+			node := &topo.Nodes[i]
+			if slices.Contains(node.Flags, "noaddr") || slices.Contains(node.Flags, "handshake") {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				res, infoErr := nodes.client(node.Addr).Info(ctx).Result()
+				if infoErr != nil {
+					node.Error = infoErr.Error()
+					return
+				}
+				info := b.parseInfo(res)
+				node.UsedMemory, _ = strconv.ParseInt(info["Memory"]["used_memory"], 10, 64)
+				node.OpsPerSec, _ = strconv.ParseInt(info["Stats"]["instantaneous_ops_per_sec"], 10, 64)
+				node.Clients, _ = strconv.ParseInt(info["Clients"]["connected_clients"], 10, 64)
+				node.ReplOffset, _ = strconv.ParseInt(info["Replication"]["master_repl_offset"], 10, 64)
+				if db0, ok := info["Keyspace"]["db0"]; ok {
+					node.Keys = int64(b.parseDBItemInfo(db0)["keys"])
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	slices.SortFunc(topo.Nodes, func(a, b types.ClusterNode) int {
+		return strings.Compare(a.Addr, b.Addr)
+	})
+	return
+}
+
+func findClusterNode(topo types.ClusterTopology, id string) (types.ClusterNode, bool) {
+	for _, node := range topo.Nodes {
+		if node.ID == id {
+			return node, true
+		}
+	}
+	return types.ClusterNode{}, false
+}
+
+// GetClusterTopology get nodes, slots and stats of cluster
+func (b *browserService) GetClusterTopology(server string) (resp types.JSResp) {
+	cluster, ctx, err := b.getClusterClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	nodes := newClusterNodes(ctx, cluster)
+	defer nodes.Close()
+
+	topo, err := b.loadClusterTopology(ctx, cluster, nodes, true)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	resp.Data = topo
+	return
+}
+
+// ClusterFailover promote replica to master, mode could be empty, "force" or "takeover"
+func (b *browserService) ClusterFailover(server, nodeID, mode string) (resp types.JSResp) {
+	cluster, ctx, err := b.getClusterClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	nodes := newClusterNodes(ctx, cluster)
+	defer nodes.Close()
+
+	topo, err := b.loadClusterTopology(ctx, cluster, nodes, false)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	node, ok := findClusterNode(topo, nodeID)
+	if !ok {
+		resp.Msg = "no node with id: " + nodeID
+		return
+	}
+	if node.Role != "replica" {
+		resp.Msg = "failover could only be executed on replica"
+		return
+	}
+
+	args := []any{"cluster", "failover"}
+	switch strings.ToLower(mode) {
+	case "force", "takeover":
+		args = append(args, strings.ToUpper(mode))
+	}
+	if err = nodes.client(node.Addr).Do(ctx, args...).Err(); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	cluster.ReloadState(ctx)
+	resp.Success = true
+	return
+}
+
+// ClusterForget remove node from node table of all other nodes
+func (b *browserService) ClusterForget(server, nodeID string) (resp types.JSResp) {
+	cluster, ctx, err := b.getClusterClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	nodes := newClusterNodes(ctx, cluster)
+	defer nodes.Close()
+
+	topo, err := b.loadClusterTopology(ctx, cluster, nodes, false)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if _, ok := findClusterNode(topo, nodeID); !ok {
+		resp.Msg = "no node with id: " + nodeID
+		return
+	}
+
+	// node should be forgotten by all other nodes in 60 seconds, or it will be added back by gossip
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	failed := map[string]string{}
+	for _, node := range topo.Nodes {
+		if node.ID == nodeID || slices.Contains(node.Flags, "noaddr") {
+			continue
+		}
+		wg.Add(1)
+		go func(addr string) {
+			defer wg.Done()
+			if forgetErr := nodes.client(addr).ClusterForget(ctx, nodeID).Err(); forgetErr != nil {
+				mutex.Lock()
+				failed[addr] = forgetErr.Error()
+				mutex.Unlock()
+			}
+		}(node.Addr)
+	}
+	wg.Wait()
+	cluster.ReloadState(ctx)
+
+	if len(failed) > 0 {
+		msgs := make([]string, 0, len(failed))
+		for addr, msg := range failed {
+			msgs = append(msgs, addr+": "+msg)
+		}
+		slices.Sort(msgs)
+		resp.Msg = strings.Join(msgs, "\n")
+		resp.Data = map[string]any{
+			"failed": failed,
+		}
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// ClusterMeet add node to cluster
+func (b *browserService) ClusterMeet(server, host string, port int) (resp types.JSResp) {
+	cluster, ctx, err := b.getClusterClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if len(host) <= 0 || port <= 0 {
+		resp.Msg = "invalid node address"
+		return
+	}
+
+	// meet by one master, the new node will be known by others through gossip
+	cli, err := cluster.MasterForKey(ctx, "")
+	if err == nil {
+		err = cli.ClusterMeet(ctx, host, strconv.Itoa(port)).Err()
+	}
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	cluster.ReloadState(ctx)
+	resp.Success = true
+	return
+}
+
+// MigrateClusterSlots move slot range from source master to target master with keys inside,
+// keys are moved one by one by "MIGRATE" like redis-cli does
+func (b *browserService) MigrateClusterSlots(param types.ClusterMigrateParam) (resp types.JSResp) {
+	if param.StartSlot < 0 || param.EndSlot >= clusterSlotCount || param.StartSlot > param.EndSlot {
+		resp.Msg = "invalid slot range"
+		return
+	}
+	if param.Source == param.Target {
+		resp.Msg = "source and target node should be different"
+		return
+	}
+	cluster, _, err := b.getClusterClient(param.Server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	ctx, cancelFunc := context.WithCancel(b.ctx)
+	defer cancelFunc()
+
+	nodes := newClusterNodes(ctx, cluster)
+	defer nodes.Close()
+
+	topo, err := b.loadClusterTopology(ctx, cluster, nodes, false)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	source, sourceOk := findClusterNode(topo, param.Source)
+	target, targetOk := findClusterNode(topo, param.Target)
+	if !sourceOk || !targetOk || source.Role != "master" || target.Role != "master" {
+		resp.Msg = "source and target should be masters in cluster"
+		return
+	}
+	for slot := param.StartSlot; slot <= param.EndSlot; slot++ {
+		owned := slices.ContainsFunc(source.Slots, func(r [2]int) bool {
+			return slot >= r[0] && slot <= r[1]
+		})
+		if !owned {
+			resp.Msg = fmt.Sprintf("slot %d is not served by source node %s", slot, source.Addr)
+			return
+		}
+	}
+
+	targetHost, targetPort, err := net.SplitHostPort(target.Addr)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	// target node may require authentication too
+	var authArgs []any
+	if opt := cluster.Options(); len(opt.Password) > 0 {
+		if len(opt.Username) > 0 {
+			authArgs = []any{"auth2", opt.Username, opt.Password}
+		} else {
+			authArgs = []any{"auth", opt.Password}
+		}
+	}
+
+	sourceCli, targetCli := nodes.client(source.Addr), nodes.client(target.Addr)
+	cancelStopEvent := EventsOnce(ctx, "migrate:stop:"+param.SerialNo, func(data ...any) {
+		cancelFunc()
+	})
+	defer cancelStopEvent()
+	processEvent := "migrating:" + param.SerialNo
+	total := param.EndSlot - param.StartSlot + 1
+	var result types.ClusterMigrateResult
+	lastEmit := time.Now().Add(-10 * time.Second)
+	emitProgress := func(slot int, force bool) {
+		if force || time.Since(lastEmit).Milliseconds() > 100 {
+			lastEmit = time.Now()
+			EventsEmit(ctx, processEvent, map[string]any{
+				"total":    total,
+				"progress": result.MigratedSlots,
+				"slot":     slot,
+				"keys":     result.MigratedKeys,
+			})
+		}
+	}
+
+	// other masters are notified after slot assigned, it will be spread by gossip if failed
+	var others []slotMigrateNode
+	for _, node := range topo.Nodes {
+		if node.Role == "master" && node.ID != source.ID && node.ID != target.ID && !node.Failing {
+			others = append(others, nodes.client(node.Addr))
+		}
+	}
+	migration := &slotMigration{
+		source:     sourceCli,
+		target:     targetCli,
+		sourceNode: source,
+		targetNode: target,
+		others:     others,
+		migrateArgs: func(key string) []any {
+			args := []any{"migrate", targetHost, targetPort, key, 0, clusterMigrateTimeout.Milliseconds()}
+			return append(args, authArgs...)
+		},
+		onKeyMoved: func(slot int) {
+			result.MigratedKeys += 1
+			emitProgress(slot, false)
+		},
+	}
+
+	for slot := param.StartSlot; slot <= param.EndSlot; slot++ {
+		emitProgress(slot, true)
+		if err = migration.migrate(ctx, slot); err != nil {
+			if errors.Is(err, context.Canceled) {
+				result.Canceled = true
+			} else {
+				result.Error = err.Error()
+			}
+			if !migration.stabilize(b.ctx, slot) {
+				result.UnstableSlot = &slot
+			}
+			break
+		}
+		result.MigratedSlots += 1
+	}
+	cluster.ReloadState(b.ctx)
+
+	resp.Success = true
+	resp.Data = result
+	return
+}
+
+// slotMigrateNode commands of cluster node used by slot migration
+type slotMigrateNode interface {
+	Do(ctx context.Context, args ...any) *redis.Cmd
+	ClusterGetKeysInSlot(ctx context.Context, slot int, count int) *redis.StringSliceCmd
+}
+
+// slotMigration moves slot from source to target master, the slot states are changed like:
+// target IMPORTING -> source MIGRATING -> keys moved -> target NODE -> source NODE -> other masters NODE
+type slotMigration struct {
+	source      slotMigrateNode
+	target      slotMigrateNode
+	sourceNode  types.ClusterNode
+	targetNode  types.ClusterNode
+	others      []slotMigrateNode
+	migrateArgs func(key string) []any
+	onKeyMoved  func(slot int)
+	moved       int // count of keys moved in current slot
+}
+
+func (m *slotMigration) migrate(ctx context.Context, slot int) error {
+	m.moved = 0
+	source, target := m.sourceNode, m.targetNode
+	if err := m.target.Do(ctx, "cluster", "setslot", slot, "importing", source.ID).Err(); err != nil {
+		return fmt.Errorf("set slot %d importing on %s fail: %w", slot, target.Addr, err)
+	}
+	if err := m.source.Do(ctx, "cluster", "setslot", slot, "migrating", target.ID).Err(); err != nil {
+		return fmt.Errorf("set slot %d migrating on %s fail: %w", slot, source.Addr, err)
+	}
+	for {
+		keys, err := m.source.ClusterGetKeysInSlot(ctx, slot, clusterMigrateBatch).Result()
+		if err != nil {
+			return fmt.Errorf("get keys in slot %d fail: %w", slot, err)
+		}
+		if len(keys) <= 0 {
+			break
+		}
+		for _, key := range keys {
+			if err = m.source.Do(ctx, m.migrateArgs(key)...).Err(); err != nil {
+				return fmt.Errorf("migrate key \"%s\" in slot %d fail: %w", key, slot, err)
+			}
+			m.moved += 1
+			m.onKeyMoved(slot)
+		}
+	}
+	// assign slot to target, the target node should be the first one to know it
+	if err := m.target.Do(ctx, "cluster", "setslot", slot, "node", target.ID).Err(); err != nil {
+		return fmt.Errorf("assign slot %d to %s fail: %w", slot, target.Addr, err)
+	}
+	if err := m.source.Do(ctx, "cluster", "setslot", slot, "node", target.ID).Err(); err != nil {
+		return fmt.Errorf("assign slot %d to %s fail: %w", slot, target.Addr, err)
+	}
+	for _, node := range m.others {
+		node.Do(ctx, "cluster", "setslot", slot, "node", target.ID)
+	}
+	return nil
+}
+
+// stabilize clear importing/migrating state of unfinished slot if no key has been moved to target.
+// otherwise the state is kept, so that keys moved are still reachable by ASK redirection,
+// and the migration could be resumed or fixed by "redis-cli --cluster fix".
+// context of migration may be canceled already, so a separated one is used
+func (m *slotMigration) stabilize(ctx context.Context, slot int) bool {
+	if m.moved > 0 {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	// keys may be moved by previous interrupted migration
+	if n, err := m.target.Do(ctx, "cluster", "countkeysinslot", slot).Int64(); err != nil || n > 0 {
+		return false
+	}
+	m.target.Do(ctx, "cluster", "setslot", slot, "stable")
+	m.source.Do(ctx, "cluster", "setslot", slot, "stable")
+	return true
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeSlotNode records commands and holds keys of one slot
+type fakeSlotNode struct {
+	name     string
+	log      *[]string
+	keys     []string
+	fail     string // fail command with this prefix
+	countErr error
+	count    int64
+}
+
+func (n *fakeSlotNode) Do(ctx context.Context, args ...any) *redis.Cmd {
+	cmd := redis.NewCmd(ctx, args...)
+	line := strings.TrimSpace(fmt.Sprintln(args...))
+	*n.log = append(*n.log, n.name+": "+line)
+	switch {
+	case len(n.fail) > 0 && strings.HasPrefix(line, n.fail):
+		cmd.SetErr(errors.New("ERR " + n.fail))
+	case strings.HasPrefix(line, "cluster countkeysinslot"):
+		if n.countErr != nil {
+			cmd.SetErr(n.countErr)
+		} else {
+			cmd.SetVal(n.count)
+		}
+	case strings.HasPrefix(line, "migrate"):
+		n.keys = n.keys[1:]
+		cmd.SetVal("OK")
+	default:
+		cmd.SetVal("OK")
+	}
+	return cmd
+}
+
+func (n *fakeSlotNode) ClusterGetKeysInSlot(ctx context.Context, slot int, count int) *redis.StringSliceCmd {
+	cmd := redis.NewStringSliceCmd(ctx)
+	cmd.SetVal(n.keys[:min(count, len(n.keys))])
+	return cmd
+}
+
+func TestSlotMigration(t *testing.T) {
+	tests := []struct {
+		name         string
+		keys         []string
+		sourceFail   string
+		targetFail   string
+		targetCount  int64
+		countErr     error
+		wantErr      bool
+		wantMoved    int
+		wantStable   bool
+		wantCommands []string
+	}{
+		{
+			name:      "migrated",
+			keys:      []string{"a", "b"},
+			wantMoved: 2,
+			wantCommands: []string{
+				"target: cluster setslot 1 importing S",
+				"source: cluster setslot 1 migrating T",
+				"source: migrate a",
+				"source: migrate b",
+				"target: cluster setslot 1 node T",
+				"source: cluster setslot 1 node T",
+				"other: cluster setslot 1 node T",
+			},
+		},
+		{
+			name:       "set migrating failed",
+			keys:       []string{"a"},
+			sourceFail: "cluster setslot 1 migrating",
+			wantErr:    true,
+			wantStable: true,
+			wantCommands: []string{
+				"target: cluster setslot 1 importing S",
+				"source: cluster setslot 1 migrating T",
+				"target: cluster countkeysinslot 1",
+				"target: cluster setslot 1 stable",
+				"source: cluster setslot 1 stable",
+			},
+		},
+		{
+			name:       "first key failed",
+			keys:       []string{"a", "b"},
+			sourceFail: "migrate a",
+			wantErr:    true,
+			wantStable: true,
+		},
+		{
+			name:       "keys moved before failed",
+			keys:       []string{"a", "b"},
+			sourceFail: "migrate b",
+			wantErr:    true,
+			wantMoved:  1,
+			wantStable: false,
+			wantCommands: []string{
+				"target: cluster setslot 1 importing S",
+				"source: cluster setslot 1 migrating T",
+				"source: migrate a",
+				"source: migrate b",
+			},
+		},
+		{
+			name:       "assign slot failed",
+			keys:       []string{"a"},
+			targetFail: "cluster setslot 1 node",
+			wantErr:    true,
+			wantMoved:  1,
+			wantStable: false,
+		},
+		{
+			name:        "keys moved by previous migration",
+			keys:        []string{"b"},
+			sourceFail:  "migrate b",
+			targetCount: 1,
+			wantErr:     true,
+			wantStable:  false,
+		},
+		{
+			name:       "count keys of target failed",
+			keys:       []string{"b"},
+			sourceFail: "migrate b",
+			countErr:   errors.New("timeout"),
+			wantErr:    true,
+			wantStable: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log []string
+			source := &fakeSlotNode{name: "source", log: &log, keys: tt.keys, fail: tt.sourceFail}
+			target := &fakeSlotNode{name: "target", log: &log, fail: tt.targetFail, count: tt.targetCount, countErr: tt.countErr}
+			var moved int
+			m := &slotMigration{
+				source:     source,
+				target:     target,
+				sourceNode: types.ClusterNode{ID: "S"},
+				targetNode: types.ClusterNode{ID: "T"},
+				others:     []slotMigrateNode{&fakeSlotNode{name: "other", log: &log}},
+				migrateArgs: func(key string) []any {
+					return []any{"migrate", key}
+				},
+				onKeyMoved: func(slot int) {
+					moved += 1
+				},
+			}
+
+			err := m.migrate(context.Background(), 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("migrate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if moved != tt.wantMoved {
+				t.Errorf("keys moved = %d, want %d", moved, tt.wantMoved)
+			}
+			if err != nil {
+				if stable := m.stabilize(context.Background(), 1); stable != tt.wantStable {
+					t.Errorf("stabilize() = %v, want %v", stable, tt.wantStable)
+				}
+				for _, line := range log {
+					if strings.HasSuffix(line, "stable") && !tt.wantStable {
+						t.Errorf("slot with keys moved should not be stabilized: %s", line)
+					}
+				}
+			}
+			if tt.wantCommands != nil && !reflect.DeepEqual(log, tt.wantCommands) {
+				t.Errorf("commands =\n%s\nwant\n%s", strings.Join(log, "\n"), strings.Join(tt.wantCommands, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// listReplicationNodes list current node with its master or replicas by "INFO replication"
+func (b *browserService) listReplicationNodes(config types.ConnectionConfig) ([]types.ServerNode, error) {
+	config.LastDB = 0
+	client, err := Connection().createRedisClient(config)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	res, err := client.Info(b.ctx, "replication").Result()
+	if err != nil {
+		return nil, err
+	}
+	info := b.parseInfo(res)["Replication"]
+
+	var nodes []types.ServerNode
+	if cli, ok := client.(*redis.Client); ok {
+		role := info["role"]
+		if role == "slave" {
+			role = "replica"
+		}
+		nodes = append(nodes, types.ServerNode{
+			Addr:    cli.Options().Addr,
+			Role:    role,
+			Healthy: true,
+		})
+	}
+	if info["role"] == "slave" && len(info["master_host"]) > 0 {
+		nodes = append(nodes, types.ServerNode{
+			Addr:    net.JoinHostPort(info["master_host"], info["master_port"]),
+			Role:    "master",
+			Healthy: info["master_link_status"] == "up",
+		})
+	}
+
+	// replica is like "slave0:ip=127.0.0.1,port=6380,state=online,offset=1,lag=0"
+	count, _ := strconv.Atoi(info["connected_slaves"])
+	for i := 0; i < count; i++ {
+		fields := map[string]string{}
+		for _, field := range strings.Split(info["slave"+strconv.Itoa(i)], ",") {
+			if k, v, ok := strings.Cut(field, "="); ok {
+				fields[k] = v
+			}
+		}
+		if len(fields["ip"]) > 0 && len(fields["port"]) > 0 {
+			nodes = append(nodes, types.ServerNode{
+				Addr:    net.JoinHostPort(fields["ip"], fields["port"]),
+				Role:    "replica",
+				Healthy: fields["state"] == "online",
+			})
+		}
+	}
+	return nodes, nil
+}
+
+// listServerNodes list known nodes of connection, masters first
+func (b *browserService) listServerNodes(server string, conf *types.Connection) (nodes []types.ServerNode, err error) {
+	switch {
+	case conf.Cluster.Enable:
+		cluster, ctx, cerr := b.getClusterClient(server)
+		if cerr != nil {
+			return nil, cerr
+		}
+		var content string
+		if content, err = cluster.ClusterNodes(ctx).Result(); err == nil {
+			for _, node := range parseClusterNodes(content) {
+				if strings.HasPrefix(node.Addr, ":") {
+					// address unknown yet
+					continue
+				}
+				nodes = append(nodes, types.ServerNode{
+					Addr:    node.Addr,
+					Role:    node.Role,
+					Healthy: node.Connected && !node.Failing,
+				})
+			}
+		}
+	case conf.Sentinel.Enable:
+		nodes, err = Connection().listSentinelNodes(conf.ConnectionConfig)
+	default:
+		nodes, err = b.listReplicationNodes(conf.ConnectionConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Role != nodes[j].Role {
+			return nodes[i].Role == "master"
+		}
+		return nodes[i].Addr < nodes[j].Addr
+	})
+	return nodes, nil
+}
+
+// ListServerNodes list nodes which browser could be pinned to
+func (b *browserService) ListServerNodes(server string) (resp types.JSResp) {
+	conf, err := Connection().getConnection(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	nodes, err := b.listServerNodes(server, conf)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"nodes":  nodes,
+		"pinned": conf.PinnedNode,
+	}
+	return
+}
+
+// getNodeClient get client connect to specified node, or the client of whole server if node is empty.
+// the returned close function should be called after used
+func (b *browserService) getNodeClient(server, node string) (redis.UniversalClient, context.Context, func(), error) {
+	if len(node) <= 0 {
+		client, ctx, err := b.getAdminClient(server)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		return client, ctx, func() {}, nil
+	}
+
+	conf, err := Connection().getConnection(server)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	client, err := Connection().createNodeClient(conf.ConnectionConfig, node)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return client, b.ctx, func() { client.Close() }, nil
+}
+
+// getAdminClient get client of whole server for administration commands(ACL, CONFIG, CLIENT, etc.).
+// a separated client is built from connection config if browser is pinned to one node,
+// so that commands are still sent to every node in cluster mode, or to master in sentinel mode
+func (b *browserService) getAdminClient(server string) (redis.UniversalClient, context.Context, error) {
+	item, err := b.getRedisClient(server, -1)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !item.pinned {
+		return item.client, item.ctx, nil
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if item.unpinned == nil {
+		conf, err := Connection().getConnection(server)
+		if err != nil {
+			return nil, nil, err
+		}
+		conf.PinnedNode = ""
+		if item.unpinned, err = Connection().createRedisClient(conf.ConnectionConfig); err != nil {
+			return nil, nil, err
+		}
+	}
+	return item.unpinned, item.ctx, nil
+}
+
+// forEachNode execute on every node in cluster mode, or on the client directly
+func forEachNode(client redis.UniversalClient, ctx context.Context, fn func(ctx context.Context, cli redis.UniversalClient) error) error {
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		return cluster.ForEachShard(ctx, func(ctx context.Context, cli *redis.Client) error {
+			if err := fn(ctx, cli); err != nil {
+				return fmt.Errorf("%s: %w", cli.Options().Addr, err)
+			}
+			return nil
+		})
+	}
+	return fn(ctx, client)
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGetAdminClient(t *testing.T) {
+	pinnedCli := redis.NewClient(&redis.Options{Addr: "10.0.0.2:6379"})
+	clusterCli := redis.NewClusterClient(&redis.ClusterOptions{Addrs: []string{"10.0.0.1:6379"}})
+	defer pinnedCli.Close()
+	defer clusterCli.Close()
+
+	tests := []struct {
+		name string
+		item *connectionItem
+		want redis.UniversalClient
+	}{
+		{"not pinned", &connectionItem{client: clusterCli}, clusterCli},
+		{"pinned", &connectionItem{client: pinnedCli, pinned: true, unpinned: clusterCli}, clusterCli},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.item.ctx = context.Background()
+			b := &browserService{connMap: map[string]*connectionItem{"server": tt.item}}
+			got, _, err := b.getAdminClient("server")
+			if err != nil {
+				t.Fatalf("getAdminClient() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("getAdminClient() = %v, want %v", got, tt.want)
+			}
+			cluster, _, err := b.getClusterClient("server")
+			if err != nil || cluster != clusterCli {
+				t.Errorf("getClusterClient() = %v, %v, want whole cluster", cluster, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tinyrdm/backend/types"
+)
+
+const replicationMaxNodes = 64
+const replicationInfoTimeout = 5 * time.Second
+
+// loadReplicationNode get replication status of node by "INFO replication" and "INFO stats",
+// returns lag in seconds of its replicas, and address of its master and replicas to discover more nodes
+func (b *browserService) loadReplicationNode(ctx context.Context, config types.ConnectionConfig, addr string) (node types.ReplicationNode, lags map[string]int64, related []string) {
+	node.Addr = addr
+	node.LagSeconds = -1
+	client, err := Connection().createNodeClient(config, addr)
+	if err != nil {
+		node.Error = err.Error()
+		return
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, replicationInfoTimeout)
+	defer cancel()
+	res, err := client.Info(ctx, "replication").Result()
+	if err != nil {
+		node.Error = err.Error()
+		return
+	}
+	var stats map[string]string
+	if statsRes, serr := client.Info(ctx, "stats").Result(); serr == nil {
+		stats = b.parseInfo(statsRes)["Stats"]
+	}
+	lags, related = parseReplicationInfo(&node, b.parseInfo(res)["Replication"], stats)
+	return
+}
+
+// parseReplicationInfo fill replication status of node by fields of "INFO replication" and "INFO stats",
+// returns lag in seconds of its replicas, and address of its master and replicas
+func parseReplicationInfo(node *types.ReplicationNode, info, stats map[string]string) (lags map[string]int64, related []string) {
+	if stats != nil {
+		node.SyncFull, _ = strconv.ParseInt(stats["sync_full"], 10, 64)
+		node.SyncPartialOK, _ = strconv.ParseInt(stats["sync_partial_ok"], 10, 64)
+		node.SyncPartialErr, _ = strconv.ParseInt(stats["sync_partial_err"], 10, 64)
+	}
+
+	node.Role = info["role"]
+	node.ReplID = info["master_replid"]
+	node.ConnectedReplicas, _ = strconv.Atoi(info["connected_slaves"])
+	node.BacklogActive = info["repl_backlog_active"] == "1"
+	node.BacklogSize, _ = strconv.ParseInt(info["repl_backlog_size"], 10, 64)
+	node.BacklogHistLen, _ = strconv.ParseInt(info["repl_backlog_histlen"], 10, 64)
+	if node.Role == "slave" {
+		node.Role = "replica"
+		node.MasterAddr = net.JoinHostPort(info["master_host"], info["master_port"])
+		node.LinkStatus = info["master_link_status"]
+		node.LastIOSeconds, _ = strconv.ParseInt(info["master_last_io_seconds_ago"], 10, 64)
+		node.LinkDownSince, _ = strconv.ParseInt(info["master_link_down_since_seconds"], 10, 64)
+		node.SyncInProgress = info["master_sync_in_progress"] == "1"
+		node.ReadOnly = info["slave_read_only"] == "1" || info["replica_read_only"] == "1"
+		node.ReplOffset, _ = strconv.ParseInt(info["slave_repl_offset"], 10, 64)
+		related = append(related, node.MasterAddr)
+	} else {
+		node.ReplOffset, _ = strconv.ParseInt(info["master_repl_offset"], 10, 64)
+	}
+
+	// replica is like "slave0:ip=127.0.0.1,port=6380,state=online,offset=1,lag=0"
+	for i := 0; i < node.ConnectedReplicas; i++ {
+		fields := map[string]string{}
+		for _, field := range strings.Split(info["slave"+strconv.Itoa(i)], ",") {
+			if k, v, ok := strings.Cut(field, "="); ok {
+				fields[k] = v
+			}
+		}
+		if len(fields["ip"]) > 0 && len(fields["port"]) > 0 {
+			replica := net.JoinHostPort(fields["ip"], fields["port"])
+			if lag, perr := strconv.ParseInt(fields["lag"], 10, 64); perr == nil {
+				if lags == nil {
+					lags = map[string]int64{}
+				}
+				lags[replica] = lag
+			}
+			related = append(related, replica)
+		}
+	}
+	return
+}
+
+// GetReplicationStatus get replication status of all nodes discovered via connection
+func (b *browserService) GetReplicationStatus(server string) (resp types.JSResp) {
+	conf, err := Connection().getConnection(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	known, err := b.listServerNodes(server, conf)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	// discover masters and replicas which are not known by connection, e.g. replicas of replica
+	ctx := b.ctx
+	var queue []string
+	visited := map[string]bool{}
+	for _, node := range known {
+		queue = append(queue, node.Addr)
+		visited[node.Addr] = true
+	}
+	nodes := map[string]types.ReplicationNode{}
+	replicaLags := map[string]map[string]int64{} // master address to lags of replicas
+	for len(queue) > 0 && len(nodes) < replicationMaxNodes {
+		addr := queue[0]
+		queue = queue[1:]
+		node, lags, related := b.loadReplicationNode(ctx, conf.ConnectionConfig, addr)
+		nodes[addr] = node
+		replicaLags[addr] = lags
+		for _, r := range related {
+			if !visited[r] {
+				visited[r] = true
+				queue = append(queue, r)
+			}
+		}
+	}
+
+	resp.Success = true
+	resp.Data = map[string]any{
+		"nodes": replicationNodeList(nodes, replicaLags),
+	}
+	return
+}
+
+// replicationNodeList fill lag of replicas by offset of their masters and lags reported by masters
+func replicationNodeList(nodes map[string]types.ReplicationNode, replicaLags map[string]map[string]int64) []types.ReplicationNode {
+	list := make([]types.ReplicationNode, 0, len(nodes))
+	for _, node := range nodes {
+		if node.Role == "replica" {
+			if master, ok := nodes[node.MasterAddr]; ok && len(master.Error) <= 0 {
+				node.LagBytes = max(0, master.ReplOffset-node.ReplOffset)
+			}
+			if lag, ok := replicaLags[node.MasterAddr][node.Addr]; ok {
+				node.LagSeconds = lag
+			}
+		}
+		list = append(list, node)
+	}
+	// masters first, then replicas, unreachable nodes at last
+	roleOrder := func(role string) int {
+		switch role {
+		case "master":
+			return 0
+		case "replica":
+			return 1
+		default:
+			return 2
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if oi, oj := roleOrder(list[i].Role), roleOrder(list[j].Role); oi != oj {
+			return oi < oj
+		}
+		return list[i].Addr < list[j].Addr
+	})
+	return list
+}
+
+// ReplicaOf make node replicate from specified master, or promote it to master if host is empty
+func (b *browserService) ReplicaOf(server, addr, host string, port int) (resp types.JSResp) {
+	conf, err := Connection().getConnection(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if conf.Cluster.Enable {
+		resp.Msg = "REPLICAOF is not allowed in cluster mode"
+		return
+	}
+
+	client, err := Connection().createNodeClient(conf.ConnectionConfig, addr)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer client.Close()
+
+	args := []any{"NO", "ONE"}
+	if len(host) > 0 {
+		args = []any{host, strconv.Itoa(port)}
+	}
+	err = client.Do(b.ctx, append([]any{"REPLICAOF"}, args...)...).Err()
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unknown command") {
+		// REPLICAOF is available since redis 5.0
+		err = client.Do(b.ctx, append([]any{"SLAVEOF"}, args...)...).Err()
+	}
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
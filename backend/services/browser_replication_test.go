@@ -0,0 +1,110 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+func TestParseReplicationInfo(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        map[string]string
+		stats       map[string]string
+		want        types.ReplicationNode
+		wantLags    map[string]int64
+		wantRelated []string
+	}{
+		{
+			name: "master",
+			info: map[string]string{
+				"role": "master", "connected_slaves": "2", "master_replid": "abc", "master_repl_offset": "1000",
+				"repl_backlog_active": "1", "repl_backlog_size": "1048576", "repl_backlog_histlen": "1000",
+				"slave0": "ip=10.0.0.2,port=6379,state=online,offset=990,lag=0",
+				"slave1": "ip=::1,port=6380,state=online,offset=900,lag=3",
+			},
+			stats: map[string]string{"sync_full": "2", "sync_partial_ok": "1", "sync_partial_err": "0"},
+			want: types.ReplicationNode{
+				Role: "master", ReplID: "abc", ReplOffset: 1000, ConnectedReplicas: 2,
+				BacklogActive: true, BacklogSize: 1048576, BacklogHistLen: 1000, SyncFull: 2, SyncPartialOK: 1,
+			},
+			wantLags:    map[string]int64{"10.0.0.2:6379": 0, "[::1]:6380": 3},
+			wantRelated: []string{"10.0.0.2:6379", "[::1]:6380"},
+		},
+		{
+			name: "replica",
+			info: map[string]string{
+				"role": "slave", "master_host": "10.0.0.1", "master_port": "6379", "master_link_status": "down",
+				"master_last_io_seconds_ago": "-1", "master_link_down_since_seconds": "12", "master_sync_in_progress": "1",
+				"slave_read_only": "1", "slave_repl_offset": "900", "connected_slaves": "0",
+			},
+			want: types.ReplicationNode{
+				Role: "replica", MasterAddr: "10.0.0.1:6379", LinkStatus: "down", LastIOSeconds: -1,
+				LinkDownSince: 12, SyncInProgress: true, ReadOnly: true, ReplOffset: 900,
+			},
+			wantRelated: []string{"10.0.0.1:6379"},
+		},
+		{
+			name: "replica without lag reported",
+			info: map[string]string{
+				"role": "master", "connected_slaves": "1", "slave0": "ip=10.0.0.2,port=6379,state=wait_bgsave",
+			},
+			want:        types.ReplicationNode{Role: "master", ConnectedReplicas: 1},
+			wantRelated: []string{"10.0.0.2:6379"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var node types.ReplicationNode
+			lags, related := parseReplicationInfo(&node, tt.info, tt.stats)
+			if !reflect.DeepEqual(node, tt.want) {
+				t.Errorf("node = %+v, want %+v", node, tt.want)
+			}
+			if !reflect.DeepEqual(lags, tt.wantLags) {
+				t.Errorf("lags = %v, want %v", lags, tt.wantLags)
+			}
+			if !reflect.DeepEqual(related, tt.wantRelated) {
+				t.Errorf("related = %v, want %v", related, tt.wantRelated)
+			}
+		})
+	}
+}
+
+func TestReplicationNodeList(t *testing.T) {
+	nodes := map[string]types.ReplicationNode{
+		"m:1":  {Addr: "m:1", Role: "master", ReplOffset: 1000},
+		"r:2":  {Addr: "r:2", Role: "replica", MasterAddr: "m:1", ReplOffset: 900, LagSeconds: -1},
+		"r:1":  {Addr: "r:1", Role: "replica", MasterAddr: "m:1", ReplOffset: 1200, LagSeconds: -1},
+		"r:3":  {Addr: "r:3", Role: "replica", MasterAddr: "x:1", ReplOffset: 10, LagSeconds: -1},
+		"x:1":  {Addr: "x:1", Error: "connection refused", LagSeconds: -1},
+		"m2:1": {Addr: "m2:1", Role: "master"},
+	}
+	lags := map[string]map[string]int64{
+		"m:1": {"r:2": 2},
+	}
+	got := replicationNodeList(nodes, lags)
+	var order []string
+	for _, node := range got {
+		order = append(order, node.Addr)
+	}
+	wantOrder := []string{"m2:1", "m:1", "r:1", "r:2", "r:3", "x:1"}
+	if !reflect.DeepEqual(order, wantOrder) {
+		t.Fatalf("order = %v, want %v", order, wantOrder)
+	}
+	tests := []struct {
+		idx         int
+		wantBytes   int64
+		wantSeconds int64
+	}{
+		{2, 0, -1},  // offset ahead of master
+		{3, 100, 2}, // lag reported by master
+		{4, 0, -1},  // master unreachable
+	}
+	for _, tt := range tests {
+		node := got[tt.idx]
+		if node.LagBytes != tt.wantBytes || node.LagSeconds != tt.wantSeconds {
+			t.Errorf("lag of %s = %d bytes, %d seconds, want %d, %d",
+				node.Addr, node.LagBytes, node.LagSeconds, tt.wantBytes, tt.wantSeconds)
+		}
+	}
+}
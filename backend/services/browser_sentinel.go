@@ -0,0 +1,183 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// getSentinelClient connect to sentinel node of connection, should be closed after used
+func (b *browserService) getSentinelClient(server string) (*redis.SentinelClient, string, error) {
+	conf, err := Connection().getConnection(server)
+	if err != nil {
+		return nil, "", err
+	}
+	if !conf.Sentinel.Enable {
+		return nil, "", errors.New("not a sentinel connection")
+	}
+	return Connection().createSentinelClient(conf.ConnectionConfig)
+}
+
+// parseSentinelNode parse fields reply by "SENTINEL MASTERS/REPLICAS/SENTINELS"
+func parseSentinelNode(info map[string]string) types.SentinelNode {
+	node := types.SentinelNode{
+		Addr:             net.JoinHostPort(info["ip"], info["port"]),
+		RunID:            info["runid"],
+		Flags:            strings.Split(info["flags"], ","),
+		MasterLinkStatus: info["master-link-status"],
+		VotedLeader:      info["voted-leader"],
+	}
+	node.SDown = slices.Contains(node.Flags, "s_down")
+	node.ODown = slices.Contains(node.Flags, "o_down")
+	node.Disconnected = slices.Contains(node.Flags, "disconnected")
+	node.LastOkPing, _ = strconv.ParseInt(info["last-ok-ping-reply"], 10, 64)
+	node.ReplOffset, _ = strconv.ParseInt(info["slave-repl-offset"], 10, 64)
+	if node.VotedLeader == "?" {
+		node.VotedLeader = ""
+	}
+	return node
+}
+
+// parseSentinelMaster parse fields reply by "SENTINEL MASTERS" without replicas and sentinels.
+// time of last failover is not reported, config epoch is increased by each failover instead
+func parseSentinelMaster(info map[string]string) types.SentinelMaster {
+	master := types.SentinelMaster{
+		SentinelNode:  parseSentinelNode(info),
+		Name:          info["name"],
+		FailoverState: info["failover-state"],
+	}
+	master.Quorum, _ = strconv.Atoi(info["quorum"])
+	master.NumReplicas, _ = strconv.Atoi(info["num-slaves"])
+	master.NumOtherSentinels, _ = strconv.Atoi(info["num-other-sentinels"])
+	master.ConfigEpoch, _ = strconv.ParseInt(info["config-epoch"], 10, 64)
+	master.DownAfter, _ = strconv.ParseInt(info["down-after-milliseconds"], 10, 64)
+	master.FailoverTimeout, _ = strconv.ParseInt(info["failover-timeout"], 10, 64)
+	if master.FailoverState == "none" {
+		master.FailoverState = ""
+	}
+	return master
+}
+
+// GetSentinelOverview get all masters monitored by sentinel with their replicas and other sentinels
+func (b *browserService) GetSentinelOverview(server string) (resp types.JSResp) {
+	sentinel, addr, err := b.getSentinelClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer sentinel.Close()
+
+	ctx := b.ctx
+	// reply of "SENTINEL MASTERS" is parsed as maps like "SENTINEL REPLICAS"
+	cmd := redis.NewMapStringStringSliceCmd(ctx, "sentinel", "masters")
+	_ = sentinel.Process(ctx, cmd)
+	infos, err := cmd.Result()
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	masters := make([]types.SentinelMaster, 0, len(infos))
+	for _, info := range infos {
+		master := parseSentinelMaster(info)
+
+		master.Replicas = []types.SentinelNode{}
+		if replicas, rerr := sentinel.Replicas(ctx, master.Name).Result(); rerr == nil {
+			for _, replica := range replicas {
+				master.Replicas = append(master.Replicas, parseSentinelNode(replica))
+			}
+		}
+		master.Sentinels = []types.SentinelNode{}
+		if sentinels, serr := sentinel.Sentinels(ctx, master.Name).Result(); serr == nil {
+			for _, s := range sentinels {
+				master.Sentinels = append(master.Sentinels, parseSentinelNode(s))
+			}
+		}
+		masters = append(masters, master)
+	}
+	slices.SortFunc(masters, func(a, b types.SentinelMaster) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	resp.Success = true
+	resp.Data = types.SentinelOverview{
+		Addr:    addr,
+		Masters: masters,
+	}
+	return
+}
+
+// SentinelFailover force a failover of master without agreement of other sentinels
+func (b *browserService) SentinelFailover(server, master string) (resp types.JSResp) {
+	sentinel, _, err := b.getSentinelClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer sentinel.Close()
+
+	if err = sentinel.Failover(b.ctx, master).Err(); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// SentinelReset reset masters matching pattern, replicas and sentinels of them are rediscovered later.
+// only the connected sentinel is reset
+func (b *browserService) SentinelReset(server, pattern string) (resp types.JSResp) {
+	sentinel, _, err := b.getSentinelClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer sentinel.Close()
+
+	var count int64
+	if count, err = sentinel.Reset(b.ctx, pattern).Result(); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"count": count,
+	}
+	return
+}
+
+// SentinelCheckQuorum check if current sentinels are able to reach the quorum and authorize a failover
+func (b *browserService) SentinelCheckQuorum(server, master string) (resp types.JSResp) {
+	sentinel, _, err := b.getSentinelClient(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer sentinel.Close()
+
+	// reply error like "NOQUORUM ..." if not reachable
+	result, err := sentinel.CkQuorum(b.ctx, master).Result()
+	var redisErr redis.Error
+	if err != nil && !errors.As(err, &redisErr) {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	if err != nil {
+		resp.Data = map[string]any{
+			"ok":  false,
+			"msg": err.Error(),
+		}
+	} else {
+		resp.Data = map[string]any{
+			"ok":  true,
+			"msg": result,
+		}
+	}
+	return
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+func TestParseSentinelMaster(t *testing.T) {
+	tests := []struct {
+		name string
+		info map[string]string
+		want types.SentinelMaster
+	}{
+		{
+			name: "healthy",
+			info: map[string]string{
+				"name": "mymaster", "ip": "10.0.0.1", "port": "6379", "runid": "abc", "flags": "master",
+				"last-ok-ping-reply": "120", "role-reported-time": "3600000", "config-epoch": "3",
+				"num-slaves": "2", "num-other-sentinels": "2", "quorum": "2",
+				"down-after-milliseconds": "30000", "failover-timeout": "180000",
+			},
+			want: types.SentinelMaster{
+				SentinelNode: types.SentinelNode{
+					Addr: "10.0.0.1:6379", RunID: "abc", Flags: []string{"master"}, LastOkPing: 120,
+				},
+				Name: "mymaster", Quorum: 2, NumReplicas: 2, NumOtherSentinels: 2, ConfigEpoch: 3,
+				DownAfter: 30000, FailoverTimeout: 180000,
+			},
+		},
+		{
+			name: "failing over",
+			info: map[string]string{
+				"name": "mymaster", "ip": "::1", "port": "6379", "flags": "master,s_down,o_down",
+				"failover-state": "wait_promotion", "config-epoch": "4", "voted-leader": "?",
+			},
+			want: types.SentinelMaster{
+				SentinelNode: types.SentinelNode{
+					Addr: "[::1]:6379", Flags: []string{"master", "s_down", "o_down"}, SDown: true, ODown: true,
+				},
+				Name: "mymaster", ConfigEpoch: 4, FailoverState: "wait_promotion",
+			},
+		},
+		{
+			name: "no failover",
+			info: map[string]string{"name": "mymaster", "ip": "10.0.0.1", "port": "6379", "flags": "master", "failover-state": "none"},
+			want: types.SentinelMaster{
+				SentinelNode: types.SentinelNode{Addr: "10.0.0.1:6379", Flags: []string{"master"}},
+				Name:         "mymaster",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSentinelMaster(tt.info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSentinelMaster() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -11,13 +11,13 @@ import (
 	"net/url"
 	"os"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 	"tinyrdm/backend/consts"
+	"tinyrdm/backend/storage"
 	"tinyrdm/backend/types"
 	"tinyrdm/backend/utils/coll"
 	convutil "tinyrdm/backend/utils/convert"
@@ -29,14 +29,6 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
-type slowLogItem struct {
-	Timestamp int64  `json:"timestamp"`
-	Client    string `json:"client"`
-	Addr      string `json:"addr"`
-	Cmd       string `json:"cmd"`
-	Cost      int64  `json:"cost"`
-}
-
 type entryCursor struct {
 	DB      int
 	Type    string
@@ -53,7 +45,10 @@ type connectionItem struct {
 	cursor      map[int]uint64      // current cursor of databases
 	entryCursor map[int]entryCursor // current entry cursor of databases
 	stepSize    int64
-	db          int // current database index
+	db          int                   // current database index
+	rules       []types.DecoderRule   // decoder rules of connection
+	pinned      bool                  // browser is pinned to one node
+	unpinned    redis.UniversalClient // client of whole server built from connection config if pinned
 }
 
 type browserService struct {
@@ -89,14 +84,28 @@ func (b *browserService) Stop() {
 			}
 			item.client.Close()
 		}
+		if item.unpinned != nil {
+			item.unpinned.Close()
+		}
 	}
 	b.connMap = map[string]*connectionItem{}
 }
 
 // OpenConnection open redis server connection
 func (b *browserService) OpenConnection(name string) (resp types.JSResp) {
+	if Connection().secretsLocked() {
+		resp.Msg = storage.ErrSecretsLocked.Error()
+		resp.Data = map[string]any{
+			"locked": true,
+		}
+		return
+	}
 	// get connection config
-	selConn := Connection().getConnection(name)
+	selConn, err := Connection().getConnection(name)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
 	// correct last database index
 	lastDB := selConn.LastDB
 	if selConn.DBFilterType == "show" && !slices.Contains(selConn.DBFilterList, lastDB) {
@@ -108,6 +117,7 @@ func (b *browserService) OpenConnection(name string) (resp types.JSResp) {
 	item, db, err := b.getRedisClient2(name, lastDB)
 	if err != nil {
 		resp.Msg = err.Error()
+		resp.Data = hostKeyErrorData(err)
 		return
 	}
 	if lastDB != db {
@@ -243,11 +253,13 @@ func (b *browserService) OpenConnection(name string) (resp types.JSResp) {
 		"lastDB":  selConn.LastDB,
 		"version": version,
 	}
+	Metrics().Watch(name)
 	return
 }
 
 // CloseConnection close redis server connection
 func (b *browserService) CloseConnection(name string) (resp types.JSResp) {
+	Metrics().Unwatch(name)
 	if item, ok := b.connMap[name]; ok {
 		delete(b.connMap, name)
 		if item.cancelFunc != nil {
@@ -256,6 +268,9 @@ func (b *browserService) CloseConnection(name string) (resp types.JSResp) {
 		if item.client != nil {
 			item.client.Close()
 		}
+		if item.unpinned != nil {
+			item.unpinned.Close()
+		}
 	}
 	resp.Success = true
 	return
@@ -274,11 +289,16 @@ func (b *browserService) createRedisClient(ctx context.Context, selConn types.Co
 			Cmd:       cmd,
 			Cost:      cost,
 		})
+		Metrics().observeCommand(selConn.Name, cost)
 	})
 
-	client, err = Connection().createRedisClient(selConn)
+	if len(selConn.PinnedNode) > 0 {
+		client, err = Connection().createNodeClient(selConn, selConn.PinnedNode)
+	} else {
+		client, err = Connection().createRedisClient(selConn)
+	}
 	if err != nil {
-		err = fmt.Errorf("create conenction error: %s", err.Error())
+		err = fmt.Errorf("create conenction error: %w", err)
 		return
 	}
 
@@ -298,7 +318,11 @@ func (b *browserService) createRedisClient(ctx context.Context, selConn types.Co
 	}
 
 	if _, err = client.Ping(ctx).Result(); err != nil && !errors.Is(err, redis.Nil) {
-		err = errors.New("can not connect to redis server:" + err.Error())
+		if len(selConn.PinnedNode) > 0 {
+			err = fmt.Errorf("can not connect to pinned node %s:%s", selConn.PinnedNode, err.Error())
+		} else {
+			err = errors.New("can not connect to redis server:" + err.Error())
+		}
 		return
 	}
 	return
@@ -323,13 +347,15 @@ func (b *browserService) getRedisClient(server string, db int) (item *connection
 			item.cancelFunc()
 		}
 		item.client.Close()
+		if item.unpinned != nil {
+			item.unpinned.Close()
+		}
 		delete(b.connMap, server)
 	}
 
 	// recreate new connection after switch database
-	selConn := Connection().getConnection(server)
-	if selConn == nil {
-		err = fmt.Errorf("no match connection \"%s\"", server)
+	selConn, err := Connection().getConnection(server)
+	if err != nil {
 		delete(b.connMap, server)
 		return
 	}
@@ -354,6 +380,8 @@ func (b *browserService) getRedisClient(server string, db int) (item *connection
 		entryCursor: map[int]entryCursor{},
 		stepSize:    int64(selConn.LoadSize),
 		db:          db,
+		rules:       selConn.DecoderRules,
+		pinned:      len(selConn.PinnedNode) > 0,
 	}
 	if item.stepSize <= 0 {
 		item.stepSize = consts.DEFAULT_LOAD_SIZE
@@ -362,6 +390,29 @@ func (b *browserService) getRedisClient(server string, db int) (item *connection
 	return
 }
 
+// reconnectRedisClient recreate opened client of server with current database,
+// to apply options which take effect on connect
+func (b *browserService) reconnectRedisClient(server string) error {
+	b.mutex.Lock()
+	item, ok := b.connMap[server]
+	if ok {
+		if item.cancelFunc != nil {
+			item.cancelFunc()
+		}
+		item.client.Close()
+		if item.unpinned != nil {
+			item.unpinned.Close()
+		}
+		delete(b.connMap, server)
+	}
+	b.mutex.Unlock()
+	if !ok {
+		return nil
+	}
+	_, err := b.getRedisClient(server, item.db)
+	return err
+}
+
 // get redis client and try to reset selected database when not exists
 func (b *browserService) getRedisClient2(server string, db int) (item *connectionItem, selecetdDB int, err error) {
 	selecetdDB = db
@@ -395,6 +446,41 @@ func (b *browserService) setClientCursor(server string, db int, cursor uint64) {
 	}
 }
 
+// match decoder rule by key name and hash field, the first matched rule wins
+// field rules take precedence over key rules when the field is not empty
+func (b *browserService) matchDecoderRule(rules []types.DecoderRule, key, field string) (decode, format string, ok bool) {
+	if len(field) > 0 {
+		for _, rule := range rules {
+			if len(rule.Field) > 0 && strutil.MatchGlob(rule.Pattern, key) && strutil.MatchGlob(rule.Field, field) {
+				return rule.Decode, rule.Format, true
+			}
+		}
+	}
+	for _, rule := range rules {
+		if len(rule.Field) <= 0 && strutil.MatchGlob(rule.Pattern, key) {
+			return rule.Decode, rule.Format, true
+		}
+	}
+	return
+}
+
+// hasDecoderRule check if any decoder rule applies to key or its fields
+func (b *browserService) hasDecoderRule(rules []types.DecoderRule, key string) bool {
+	return slices.ContainsFunc(rules, func(rule types.DecoderRule) bool {
+		return strutil.MatchGlob(rule.Pattern, key)
+	})
+}
+
+// resolve decode type and format, use the matched decoder rule if both of them are not specified
+func (b *browserService) resolveConvert(rules []types.DecoderRule, key, field, decode, format string) (string, string) {
+	if len(decode) <= 0 && len(format) <= 0 {
+		if ruleDecode, ruleFormat, ok := b.matchDecoderRule(rules, key, field); ok {
+			return ruleDecode, ruleFormat
+		}
+	}
+	return decode, format
+}
+
 // parse command response content which use "redis info"
 // # Keyspace\r\ndb0:keys=2,expires=1,avg_ttl=1877111749\r\ndb1:keys=33,expires=0,avg_ttl=0\r\ndb3:keys=17,expires=0,avg_ttl=0\r\ndb5:keys=3,expires=0,avg_ttl=0\r\n
 func (b *browserService) parseInfo(info string) map[string]map[string]string {
@@ -804,6 +890,9 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 		resp.Msg = "key not exists"
 		return
 	}
+	// apply decoder rules of connection if neither decode nor format specified
+	reqDecode, reqFormat := param.Decode, param.Format
+	param.Decode, param.Format = b.resolveConvert(item.rules, key, "", reqDecode, reqFormat)
 	var doConvert bool
 	if (len(param.Decode) > 0 && param.Decode != types.DECODE_NONE) ||
 		(len(param.Format) > 0 && param.Format != types.FORMAT_RAW) {
@@ -862,6 +951,7 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 		var str string
 		str, err = client.Get(ctx, key).Result()
 		data.Value = strutil.EncodeRedisKey(str)
+		data.Decode, data.Format = param.Decode, param.Format
 		//data.Value, data.Decode, data.Format = convutil.ConvertTo(str, param.Decode, param.Format, decoder)
 
 	case "list":
@@ -890,20 +980,26 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 			}
 			setEntryCursor(cursor)
 
+			var dvs []string
+			if doConvert {
+				dvs = convutil.ConvertBatch(loadVal, param.Decode, param.Format, decoder)
+			}
 			items := make([]types.ListEntryItem, 0, len(loadVal))
-			for _, val := range loadVal {
-				if doFilter && !strings.Contains(val, param.MatchPattern) {
+			for i, val := range loadVal {
+				var dv string
+				if doConvert && dvs[i] != val {
+					dv = dvs[i]
+				}
+				// search in both raw and decoded value
+				if doFilter && !strings.Contains(val, param.MatchPattern) &&
+					(len(dv) <= 0 || !strings.Contains(dv, param.MatchPattern)) {
 					continue
 				}
 				items = append(items, types.ListEntryItem{
-					Index: len(items),
-					Value: strutil.EncodeRedisKey(val),
+					Index:        len(items),
+					Value:        strutil.EncodeRedisKey(val),
+					DisplayValue: dv,
 				})
-				if doConvert {
-					if dv, _, _ := convutil.ConvertTo(val, param.Decode, param.Format, decoder); dv != val {
-						items[len(items)-1].DisplayValue = dv
-					}
-				}
 			}
 			if subErr != nil {
 				return items, reset, false, subErr
@@ -925,19 +1021,54 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 		if !strings.HasSuffix(matchPattern, "*") {
 			matchPattern = matchPattern + "*"
 		}
+		// fill display values in batch, grouped by decoder rule of each field
+		fillDisplayValue := func(items []types.HashEntryItem, vals []string) {
+			type convertType struct {
+				decode string
+				format string
+			}
+			groups := map[convertType][]int{}
+			for i := range items {
+				decode, format := b.resolveConvert(item.rules, key, items[i].Key, reqDecode, reqFormat)
+				if (len(decode) > 0 && decode != types.DECODE_NONE) ||
+					(len(format) > 0 && format != types.FORMAT_RAW) {
+					ct := convertType{decode: decode, format: format}
+					groups[ct] = append(groups[ct], i)
+				}
+			}
+			for ct, indexes := range groups {
+				strs := sliceutil.Map(indexes, func(i int) string {
+					return vals[indexes[i]]
+				})
+				dvs := convutil.ConvertBatch(strs, ct.decode, ct.format, decoder)
+				for i, idx := range indexes {
+					if dvs[i] != strs[i] {
+						items[idx].DisplayValue = dvs[i]
+					}
+				}
+			}
+		}
 		loadHashHandle := func() ([]types.HashEntryItem, bool, bool, error) {
 			var items []types.HashEntryItem
-			var loadedVal []string
+			var loadedVal, vals []string
 			var cursor uint64
 			var reset bool
 			var subErr error
 			scanSize := int64(Preferences().GetScanSize())
-			if param.Full || matchPattern != "*" {
+			doFilter := matchPattern != "*"
+			// HSCAN MATCH only apply to raw field name, decoded values could be searched
+			// by loading all and matching locally, only if any decoder rule applies to this key
+			filterLocally := doFilter && b.hasDecoderRule(item.rules, key)
+			if param.Full || doFilter {
 				// load all
 				cursor, reset = 0, true
 				items = []types.HashEntryItem{}
+				scanPattern := matchPattern
+				if filterLocally {
+					scanPattern = "*"
+				}
 				for {
-					loadedVal, cursor, subErr = client.HScan(ctx, key, cursor, matchPattern, scanSize).Result()
+					loadedVal, cursor, subErr = client.HScan(ctx, key, cursor, scanPattern, scanSize).Result()
 					if subErr != nil {
 						return nil, reset, false, subErr
 					}
@@ -946,16 +1077,25 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 							Key:   loadedVal[i],
 							Value: strutil.EncodeRedisKey(loadedVal[i+1]),
 						})
-						if doConvert {
-							if dv, _, _ := convutil.ConvertTo(loadedVal[i+1], param.Decode, param.Format, decoder); dv != loadedVal[i+1] {
-								items[len(items)-1].DisplayValue = dv
-							}
-						}
+						vals = append(vals, loadedVal[i+1])
 					}
 					if cursor == 0 {
 						break
 					}
 				}
+				fillDisplayValue(items, vals)
+				if filterLocally {
+					// search in field name, raw value and decoded value
+					filtered := make([]types.HashEntryItem, 0, len(items))
+					for i := range items {
+						if strutil.MatchGlob(matchPattern, items[i].Key) ||
+							strings.Contains(vals[i], param.MatchPattern) ||
+							(len(items[i].DisplayValue) > 0 && strings.Contains(items[i].DisplayValue, param.MatchPattern)) {
+							filtered = append(filtered, items[i])
+						}
+					}
+					items = filtered
+				}
 			} else {
 				if param.Reset {
 					cursor, reset = 0, true
@@ -968,15 +1108,13 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 				}
 				loadedLen := len(loadedVal)
 				items = make([]types.HashEntryItem, loadedLen/2)
+				vals = make([]string, loadedLen/2)
 				for i := 0; i < loadedLen; i += 2 {
 					items[i/2].Key = loadedVal[i]
 					items[i/2].Value = strutil.EncodeRedisKey(loadedVal[i+1])
-					if doConvert {
-						if dv, _, _ := convutil.ConvertTo(loadedVal[i+1], param.Decode, param.Format, decoder); dv != loadedVal[i+1] {
-							items[i/2].DisplayValue = dv
-						}
-					}
+					vals[i/2] = loadedVal[i+1]
 				}
+				fillDisplayValue(items, vals)
 			}
 			setEntryCursor(cursor)
 			return items, reset, cursor == 0, nil
@@ -1001,7 +1139,7 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 			var cursor uint64
 			var reset bool
 			var subErr error
-			var loadedKey []string
+			var loadedKey, vals []string
 			scanSize := int64(Preferences().GetScanSize())
 			if param.Full || matchPattern != "*" {
 				// load all
@@ -1016,11 +1154,7 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 						items = append(items, types.SetEntryItem{
 							Value: strutil.EncodeRedisKey(val),
 						})
-						if doConvert {
-							if dv, _, _ := convutil.ConvertTo(val, param.Decode, param.Format, decoder); dv != val {
-								items[len(items)-1].DisplayValue = dv
-							}
-						}
+						vals = append(vals, val)
 					}
 					if cursor == 0 {
 						break
@@ -1036,10 +1170,14 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 				items = make([]types.SetEntryItem, len(loadedKey))
 				for i, val := range loadedKey {
 					items[i].Value = strutil.EncodeRedisKey(val)
-					if doConvert {
-						if dv, _, _ := convutil.ConvertTo(val, param.Decode, param.Format, decoder); dv != val {
-							items[i].DisplayValue = dv
-						}
+				}
+				vals = loadedKey
+			}
+			if doConvert {
+				dvs := convutil.ConvertBatch(vals, param.Decode, param.Format, decoder)
+				for i := range items {
+					if dvs[i] != vals[i] {
+						items[i].DisplayValue = dvs[i]
 					}
 				}
 			}
@@ -1063,6 +1201,7 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 		}
 		loadZSetHandle := func() ([]types.ZSetEntryItem, bool, bool, error) {
 			var items []types.ZSetEntryItem
+			var vals []string
 			var reset bool
 			var cursor uint64
 			scanSize := int64(Preferences().GetScanSize())
@@ -1084,11 +1223,7 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 								Value: strutil.EncodeRedisKey(loadedVal[i]),
 								Score: score,
 							})
-							if doConvert {
-								if dv, _, _ := convutil.ConvertTo(loadedVal[i], param.Decode, param.Format, decoder); dv != loadedVal[i] {
-									items[len(items)-1].DisplayValue = dv
-								}
-							}
+							vals = append(vals, loadedVal[i])
 						}
 					}
 					if cursor == 0 {
@@ -1125,10 +1260,14 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 						entry.Score = z.Score
 					}
 					items = append(items, entry)
-					if doConvert {
-						if dv, _, _ := convutil.ConvertTo(val, param.Decode, param.Format, decoder); dv != val {
-							items[len(items)-1].DisplayValue = dv
-						}
+					vals = append(vals, val)
+				}
+			}
+			if doConvert {
+				dvs := convutil.ConvertBatch(vals, param.Decode, param.Format, decoder)
+				for i := range items {
+					if dvs[i] != vals[i] {
+						items[i].DisplayValue = dvs[i]
 					}
 				}
 			}
@@ -1178,24 +1317,39 @@ func (b *browserService) GetKeyDetail(param types.KeyDetailParam) (resp types.JS
 				}
 			}
 			setEntryXLast(last)
+			// collect field values of all messages and convert them in batch
+			fields := make([][]string, len(msgs))
+			var vals []string
+			for i, msg := range msgs {
+				for k, v := range msg.Values {
+					if str, ok := v.(string); ok {
+						fields[i] = append(fields[i], k)
+						vals = append(vals, str)
+					}
+				}
+			}
+			dvs := vals
+			if doConvert {
+				dvs = convutil.ConvertBatch(vals, param.Decode, param.Format, decoder)
+			}
 			items := make([]types.StreamEntryItem, 0, len(msgs))
-			for _, msg := range msgs {
+			var idx int
+			for i, msg := range msgs {
 				it := types.StreamEntryItem{
 					ID:    msg.ID,
 					Value: msg.Values,
 				}
 				var displayValue strings.Builder
-				for k, v := range msg.Values {
+				for _, k := range fields[i] {
 					if displayValue.Len() > 0 {
 						displayValue.WriteString(", ")
 					}
-					if str, ok := v.(string); ok {
-						displayValue.WriteByte('"')
-						displayValue.WriteString(k)
-						displayValue.WriteByte('"')
-						displayValue.WriteByte(':')
-						displayValue.WriteString(str)
-					}
+					displayValue.WriteByte('"')
+					displayValue.WriteString(k)
+					displayValue.WriteByte('"')
+					displayValue.WriteByte(':')
+					displayValue.WriteString(dvs[idx])
+					idx++
 				}
 				it.DisplayValue = displayValue.String()
 				if doFilter && !strings.Contains(it.DisplayValue, param.MatchPattern) {
@@ -1410,6 +1564,7 @@ func (b *browserService) GetHashValue(param types.GetHashParam) (resp types.JSRe
 	}
 
 	var displayVal string
+	param.Decode, param.Format = b.resolveConvert(item.rules, key, param.Field, param.Decode, param.Format)
 	if (len(param.Decode) > 0 && param.Decode != types.DECODE_NONE) ||
 		(len(param.Format) > 0 && param.Format != types.FORMAT_RAW) {
 		decoder := Preferences().GetDecoder()
@@ -2684,65 +2839,6 @@ func (b *browserService) CleanCmdHistory() (resp types.JSResp) {
 	return
 }
 
-// GetSlowLogs get slow log list
-func (b *browserService) GetSlowLogs(server string, num int64) (resp types.JSResp) {
-	item, err := b.getRedisClient(server, -1)
-	if err != nil {
-		resp.Msg = err.Error()
-		return
-	}
-	num = max(1, num)
-
-	client, ctx := item.client, item.ctx
-	var logs []redis.SlowLog
-	if cluster, ok := client.(*redis.ClusterClient); ok {
-		// cluster mode
-		var mu sync.Mutex
-		err = cluster.ForEachShard(ctx, func(ctx context.Context, cli *redis.Client) error {
-			if subLogs, _ := client.SlowLogGet(ctx, num).Result(); len(subLogs) > 0 {
-				mu.Lock()
-				logs = append(logs, subLogs...)
-				mu.Unlock()
-			}
-			return nil
-		})
-	} else {
-		logs, err = client.SlowLogGet(ctx, num).Result()
-	}
-	if err != nil {
-		resp.Msg = err.Error()
-		return
-	}
-
-	sort.Slice(logs, func(i, j int) bool {
-		return logs[i].Time.UnixMilli() > logs[j].Time.UnixMilli()
-	})
-	if len(logs) > int(num) {
-		logs = logs[:num]
-	}
-
-	list := sliceutil.Map(logs, func(i int) slowLogItem {
-		var name string
-		var e error
-		if name, e = url.QueryUnescape(logs[i].ClientName); e != nil {
-			name = logs[i].ClientName
-		}
-		return slowLogItem{
-			Timestamp: logs[i].Time.UnixMilli(),
-			Client:    name,
-			Addr:      logs[i].ClientAddr,
-			Cmd:       sliceutil.JoinString(logs[i].Args, " "),
-			Cost:      logs[i].Duration.Milliseconds(),
-		}
-	})
-
-	resp.Success = true
-	resp.Data = map[string]any{
-		"list": list,
-	}
-	return
-}
-
 // GetClientList get all connected client info
 func (b *browserService) GetClientList(server string) (resp types.JSResp) {
 	item, err := b.getRedisClient(server, -1)
@@ -2751,26 +2847,6 @@ func (b *browserService) GetClientList(server string) (resp types.JSResp) {
 		return
 	}
 
-	parseContent := func(content string) []map[string]string {
-		lines := strings.Split(content, "\n")
-		list := make([]map[string]string, 0, len(lines))
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if len(line) > 0 {
-				items := strings.Split(line, " ")
-				itemKV := map[string]string{}
-				for _, it := range items {
-					kv := strings.SplitN(it, "=", 2)
-					if len(kv) > 1 {
-						itemKV[kv[0]] = kv[1]
-					}
-				}
-				list = append(list, itemKV)
-			}
-		}
-		return list
-	}
-
 	client, ctx := item.client, item.ctx
 	var fullList []map[string]string
 	var mutex sync.Mutex
@@ -2778,11 +2854,11 @@ func (b *browserService) GetClientList(server string) (resp types.JSResp) {
 		cluster.ForEachMaster(ctx, func(ctx context.Context, cli *redis.Client) error {
 			mutex.Lock()
 			defer mutex.Unlock()
-			fullList = append(fullList, parseContent(cli.ClientList(ctx).Val())...)
+			fullList = append(fullList, parseClientList(cli.ClientList(ctx).Val())...)
 			return nil
 		})
 	} else {
-		fullList = append(fullList, parseContent(client.ClientList(ctx).Val())...)
+		fullList = append(fullList, parseClientList(client.ClientList(ctx).Val())...)
 	}
 
 	resp.Success = true
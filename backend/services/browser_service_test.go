@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+var decoderTestRules = []types.DecoderRule{
+	{Pattern: "user:*", Field: "avatar", Decode: types.DECODE_BASE64},
+	{Pattern: "user:*", Format: types.FORMAT_JSON},
+	{Pattern: "cache:*", Decode: types.DECODE_GZIP, Format: types.FORMAT_JSON},
+}
+
+func TestResolveConvert(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		field      string
+		decode     string
+		format     string
+		wantDecode string
+		wantFormat string
+	}{
+		{"key rule", "user:1", "", "", "", "", types.FORMAT_JSON},
+		{"field rule first", "user:1", "avatar", "", "", types.DECODE_BASE64, ""},
+		{"field not matched", "user:1", "name", "", "", "", types.FORMAT_JSON},
+		{"specified", "user:1", "avatar", types.DECODE_NONE, types.FORMAT_RAW, types.DECODE_NONE, types.FORMAT_RAW},
+		{"no rule", "session:1", "", "", "", "", ""},
+		{"first rule wins", "cache:1", "", "", "", types.DECODE_GZIP, types.FORMAT_JSON},
+	}
+	b := &browserService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decode, format := b.resolveConvert(decoderTestRules, tt.key, tt.field, tt.decode, tt.format)
+			if decode != tt.wantDecode || format != tt.wantFormat {
+				t.Errorf("resolveConvert() = %q, %q, want %q, %q", decode, format, tt.wantDecode, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestHasDecoderRule(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"user:1", true},
+		{"cache:1", true},
+		{"session:1", false},
+		{"user", false},
+	}
+	b := &browserService{}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if got := b.hasDecoderRule(decoderTestRules, tt.key); got != tt.want {
+				t.Errorf("hasDecoderRule(%q) = %v, want %v", tt.key, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"tinyrdm/backend/types"
+	sliceutil "tinyrdm/backend/utils/slice"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type slowLogItem struct {
+	Timestamp int64  `json:"timestamp"`
+	Client    string `json:"client"`
+	Addr      string `json:"addr"`
+	Cmd       string `json:"cmd"`
+	Cost      int64  `json:"cost"`
+	Node      string `json:"node,omitempty"`
+}
+
+type slowLogGroup struct {
+	Cmd    string `json:"cmd"`    // normalized command
+	Sample string `json:"sample"` // the slowest one of group
+	Count  int    `json:"count"`
+	Total  int64  `json:"total"` // in microseconds
+	Avg    int64  `json:"avg"`   // in microseconds
+	Max    int64  `json:"max"`   // in microseconds
+	Last   int64  `json:"last"`  // timestamp of latest execution
+}
+
+type latencyEvent struct {
+	Node      string `json:"node,omitempty"`
+	Event     string `json:"event"`
+	Timestamp int64  `json:"timestamp"`
+	Latest    int64  `json:"latest"` // in milliseconds
+	Max       int64  `json:"max"`    // in milliseconds
+}
+
+type latencySample struct {
+	Timestamp int64 `json:"timestamp"`
+	Latency   int64 `json:"latency"` // in milliseconds
+}
+
+// nodeSlowLog slow log with address of node it comes from
+type nodeSlowLog struct {
+	redis.SlowLog
+	node string
+}
+
+// subcommand of these commands is kept after normalized
+var containerCommands = []string{
+	"ACL", "CLIENT", "CLUSTER", "COMMAND", "CONFIG", "FUNCTION", "LATENCY", "MEMORY",
+	"MODULE", "OBJECT", "PUBSUB", "SCRIPT", "SLOWLOG", "XGROUP", "XINFO",
+}
+
+// normalizeCommand replace arguments of command with placeholders,
+// e.g. "SET key val EX 10" -> "SET ? ? ? ...", "CONFIG GET maxmemory" -> "CONFIG GET ?"
+func normalizeCommand(args []string) string {
+	if len(args) <= 0 {
+		return ""
+	}
+	parts := []string{strings.ToUpper(args[0])}
+	rest := args[1:]
+	if len(rest) > 0 && slices.Contains(containerCommands, parts[0]) {
+		parts = append(parts, strings.ToUpper(rest[0]))
+		rest = rest[1:]
+	}
+	// variadic arguments are folded, so commands with different number of keys are grouped together
+	const maxPlaceholders = 3
+	for i := range rest {
+		if i >= maxPlaceholders {
+			parts = append(parts, "...")
+			break
+		}
+		parts = append(parts, "?")
+	}
+	return strings.Join(parts, " ")
+}
+
+// collectSlowLogs get latest slow logs of each node in cluster mode, or of connected node
+func (b *browserService) collectSlowLogs(server string, num int64) ([]nodeSlowLog, error) {
+	item, err := b.getRedisClient(server, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	client, ctx := item.client, item.ctx
+	_, isCluster := client.(*redis.ClusterClient)
+	var logs []nodeSlowLog
+	var mu sync.Mutex
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		subLogs, err := cli.SlowLogGet(ctx, num).Result()
+		if err != nil {
+			return err
+		}
+		var node string
+		if isCluster {
+			node = cli.(*redis.Client).Options().Addr
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, l := range subLogs {
+			logs = append(logs, nodeSlowLog{SlowLog: l, node: node})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(logs, func(i, j int) bool {
+		return logs[i].Time.UnixMilli() > logs[j].Time.UnixMilli()
+	})
+	return logs, nil
+}
+
+// GetSlowLogs get slow log list
+func (b *browserService) GetSlowLogs(server string, num int64) (resp types.JSResp) {
+	num = max(1, num)
+	logs, err := b.collectSlowLogs(server, num)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if len(logs) > int(num) {
+		logs = logs[:num]
+	}
+
+	list := sliceutil.Map(logs, func(i int) slowLogItem {
+		var name string
+		var e error
+		if name, e = url.QueryUnescape(logs[i].ClientName); e != nil {
+			name = logs[i].ClientName
+		}
+		return slowLogItem{
+			Timestamp: logs[i].Time.UnixMilli(),
+			Client:    name,
+			Addr:      logs[i].ClientAddr,
+			Cmd:       sliceutil.JoinString(logs[i].Args, " "),
+			Cost:      logs[i].Duration.Milliseconds(),
+			Node:      logs[i].node,
+		}
+	})
+
+	resp.Success = true
+	resp.Data = map[string]any{
+		"list": list,
+	}
+	return
+}
+
+// AnalyzeSlowLogs group slow logs by normalized command, sorted by total cost
+func (b *browserService) AnalyzeSlowLogs(server string, num int64) (resp types.JSResp) {
+	logs, err := b.collectSlowLogs(server, max(1, num))
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	groupMap := map[string]*slowLogGroup{}
+	for _, l := range logs {
+		cmd := normalizeCommand(l.Args)
+		cost := l.Duration.Microseconds()
+		group, ok := groupMap[cmd]
+		if !ok {
+			group = &slowLogGroup{Cmd: cmd}
+			groupMap[cmd] = group
+		}
+		group.Count += 1
+		group.Total += cost
+		group.Last = max(group.Last, l.Time.UnixMilli())
+		if !ok || cost > group.Max {
+			group.Max = cost
+			group.Sample = sliceutil.JoinString(l.Args, " ")
+		}
+	}
+
+	groups := make([]slowLogGroup, 0, len(groupMap))
+	for _, group := range groupMap {
+		group.Avg = group.Total / int64(group.Count)
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Total != groups[j].Total {
+			return groups[i].Total > groups[j].Total
+		}
+		return groups[i].Cmd < groups[j].Cmd
+	})
+
+	resp.Success = true
+	resp.Data = map[string]any{
+		"groups": groups,
+		"total":  len(logs),
+	}
+	return
+}
+
+// ResetSlowLogs clean slow logs by "SLOWLOG RESET", on all nodes in cluster mode
+func (b *browserService) ResetSlowLogs(server string) (resp types.JSResp) {
+	item, err := b.getRedisClient(server, -1)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	err = forEachNode(item.client, item.ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.Do(ctx, "SLOWLOG", "RESET").Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// GetLatestLatency get latest latency spike of each event by "LATENCY LATEST", of all nodes in cluster mode
+func (b *browserService) GetLatestLatency(server string) (resp types.JSResp) {
+	item, err := b.getRedisClient(server, -1)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	client, ctx := item.client, item.ctx
+	_, isCluster := client.(*redis.ClusterClient)
+	var events []latencyEvent
+	var mu sync.Mutex
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		// reply is like [[event, timestamp, latest, max], ...]
+		reply, err := cli.Do(ctx, "LATENCY", "LATEST").Slice()
+		if err != nil {
+			return err
+		}
+		var node string
+		if isCluster {
+			node = cli.(*redis.Client).Options().Addr
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range reply {
+			if fields, ok := r.([]any); ok && len(fields) >= 4 {
+				ts, _ := fields[1].(int64)
+				latest, _ := fields[2].(int64)
+				maxLatency, _ := fields[3].(int64)
+				events = append(events, latencyEvent{
+					Node:      node,
+					Event:     fmt.Sprint(fields[0]),
+					Timestamp: ts * 1000,
+					Latest:    latest,
+					Max:       maxLatency,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Event != events[j].Event {
+			return events[i].Event < events[j].Event
+		}
+		return events[i].Node < events[j].Node
+	})
+	resp.Success = true
+	resp.Data = map[string]any{
+		"events": events,
+	}
+	return
+}
+
+// GetLatencyHistory get latency samples of event by "LATENCY HISTORY",
+// node is required in cluster mode
+func (b *browserService) GetLatencyHistory(server, node, event string) (resp types.JSResp) {
+	client, ctx, closeFunc, err := b.getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	if _, ok := client.(*redis.ClusterClient); ok {
+		resp.Msg = "node of event is required in cluster mode"
+		return
+	}
+
+	// reply is like [[timestamp, latency], ...]
+	reply, err := client.Do(ctx, "LATENCY", "HISTORY", event).Slice()
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	samples := make([]latencySample, 0, len(reply))
+	for _, r := range reply {
+		if fields, ok := r.([]any); ok && len(fields) >= 2 {
+			ts, _ := fields[0].(int64)
+			latency, _ := fields[1].(int64)
+			samples = append(samples, latencySample{
+				Timestamp: ts * 1000,
+				Latency:   latency,
+			})
+		}
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"samples": samples,
+	}
+	return
+}
+
+// GetLatencyDoctor get latency analysis report by "LATENCY DOCTOR", reports are joined for all nodes in cluster mode
+func (b *browserService) GetLatencyDoctor(server string) (resp types.JSResp) {
+	item, err := b.getRedisClient(server, -1)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	client, ctx := item.client, item.ctx
+	cluster, isCluster := client.(*redis.ClusterClient)
+	if !isCluster {
+		var report string
+		if report, err = client.Do(ctx, "LATENCY", "DOCTOR").Text(); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+		resp.Success = true
+		resp.Data = map[string]any{
+			"report": report,
+		}
+		return
+	}
+
+	reports := map[string]string{}
+	var mu sync.Mutex
+	err = cluster.ForEachShard(ctx, func(ctx context.Context, cli *redis.Client) error {
+		report, err := cli.Do(ctx, "LATENCY", "DOCTOR").Text()
+		if err != nil {
+			return fmt.Errorf("%s: %w", cli.Options().Addr, err)
+		}
+		mu.Lock()
+		reports[cli.Options().Addr] = report
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	nodes := make([]string, 0, len(reports))
+	for node := range reports {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	var sb strings.Builder
+	for _, node := range nodes {
+		sb.WriteString(fmt.Sprintf("==================== %s ====================\n\n", node))
+		sb.WriteString(strings.TrimSpace(reports[node]))
+		sb.WriteString("\n\n")
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"report": sb.String(),
+	}
+	return
+}
+
+// ResetLatency clean latency data of all events by "LATENCY RESET", on all nodes in cluster mode
+func (b *browserService) ResetLatency(server string) (resp types.JSResp) {
+	item, err := b.getRedisClient(server, -1)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	err = forEachNode(item.client, item.ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.Do(ctx, "LATENCY", "RESET").Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -0,0 +1,27 @@
+package services
+
+import "testing"
+
+func TestNormalizeCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"empty", nil, ""},
+		{"no argument", []string{"ping"}, "PING"},
+		{"arguments", []string{"set", "key", "val"}, "SET ? ?"},
+		{"fold variadic", []string{"SET", "key", "val", "EX", "10"}, "SET ? ? ? ..."},
+		{"keep subcommand", []string{"config", "get", "maxmemory"}, "CONFIG GET ?"},
+		{"container without subcommand", []string{"CLIENT"}, "CLIENT"},
+		{"fold after subcommand", []string{"CLUSTER", "setslot", "1", "node", "abc", "x"}, "CLUSTER SETSLOT ? ? ? ..."},
+		{"same group for different keys", []string{"DEL", "a", "b", "c", "d", "e"}, "DEL ? ? ? ..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeCommand(tt.args); got != tt.want {
+				t.Errorf("normalizeCommand(%v) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
@@ -94,9 +94,9 @@ func (c *cliService) getRedisClient(server string) (redis.UniversalClient, error
 	client, ok := c.clients[server]
 	if !ok {
 		var err error
-		conf := Connection().getConnection(server)
-		if conf == nil {
-			return nil, fmt.Errorf("no connection profile named: %s", server)
+		var conf *types.Connection
+		if conf, err = Connection().getConnection(server); err != nil {
+			return nil, err
 		}
 		if client, err = Connection().createRedisClient(conf.ConnectionConfig); err != nil {
 			return nil, err
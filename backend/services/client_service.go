@@ -0,0 +1,346 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type clientService struct {
+	ctx          context.Context
+	noEvict      map[string]bool // connections protected from client eviction
+	noEvictMutex sync.Mutex
+}
+
+var clients *clientService
+var onceClients sync.Once
+
+func Client() *clientService {
+	if clients == nil {
+		onceClients.Do(func() {
+			clients = &clientService{
+				noEvict: map[string]bool{},
+			}
+		})
+	}
+	return clients
+}
+
+func (c *clientService) Start(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// flags in "CLIENT LIST"
+var clientFlags = map[rune]string{
+	'A': "close_asap",
+	'b': "blocked",
+	'B': "broadcast",
+	'c': "close_after_reply",
+	'd': "dirty_cas",
+	'e': "no_evict",
+	'i': "io_wait",
+	'M': "master",
+	'O': "monitor",
+	'P': "pubsub",
+	'r': "readonly",
+	'R': "tracking_invalid",
+	'S': "replica",
+	't': "tracking",
+	'T': "no_touch",
+	'u': "unblocked",
+	'U': "unix_socket",
+	'x': "multi",
+}
+
+// parseClientList parse content of "CLIENT LIST" or "CLIENT INFO" to field maps, one for each line
+func parseClientList(content string) []map[string]string {
+	lines := strings.Split(content, "\n")
+	list := make([]map[string]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if len(line) > 0 {
+			itemKV := map[string]string{}
+			for _, it := range strings.Split(line, " ") {
+				if k, v, ok := strings.Cut(it, "="); ok {
+					itemKV[k] = v
+				}
+			}
+			list = append(list, itemKV)
+		}
+	}
+	return list
+}
+
+// parseClientInfo convert fields of client to structured info
+func parseClientInfo(fields map[string]string, node, selfName string) types.ClientInfo {
+	info := types.ClientInfo{
+		Node:   node,
+		Addr:   fields["addr"],
+		LAddr:  fields["laddr"],
+		Name:   fields["name"],
+		User:   fields["user"],
+		Cmd:    fields["cmd"],
+		Fields: fields,
+	}
+	info.ID, _ = strconv.ParseInt(fields["id"], 10, 64)
+	info.DB, _ = strconv.Atoi(fields["db"])
+	info.Age, _ = strconv.ParseInt(fields["age"], 10, 64)
+	info.Idle, _ = strconv.ParseInt(fields["idle"], 10, 64)
+	info.Self = len(selfName) > 0 && info.Name == selfName
+
+	info.Flags = []string{}
+	for _, f := range fields["flags"] {
+		if f == 'N' {
+			continue
+		}
+		if name, ok := clientFlags[f]; ok {
+			info.Flags = append(info.Flags, name)
+		} else {
+			info.Flags = append(info.Flags, string(f))
+		}
+	}
+	flags := fields["flags"]
+	info.Tracking = types.ClientTracking{
+		Enabled:   strings.ContainsRune(flags, 't'),
+		Broadcast: strings.ContainsRune(flags, 'B'),
+		Invalid:   strings.ContainsRune(flags, 'R'),
+	}
+	// "redir" is available since redis 7.0, -1 if tracking is not enabled
+	if redir, err := strconv.ParseInt(fields["redir"], 10, 64); err == nil && redir > 0 {
+		info.Tracking.Redirect = redir
+	}
+	return info
+}
+
+// ListClients list clients of specified node, or clients of all nodes in cluster mode if node is empty
+func (c *clientService) ListClients(server, node string) (resp types.JSResp) {
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	_, isCluster := client.(*redis.ClusterClient)
+	selfName := url.QueryEscape(server)
+	var list []types.ClientInfo
+	var mutex sync.Mutex
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		content, err := cli.ClientList(ctx).Result()
+		if err != nil {
+			return err
+		}
+		addr := node
+		if isCluster {
+			addr = cli.(*redis.Client).Options().Addr
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		for _, fields := range parseClientList(content) {
+			list = append(list, parseClientInfo(fields, addr, selfName))
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Node != list[j].Node {
+			return list[i].Node < list[j].Node
+		}
+		return list[i].ID < list[j].ID
+	})
+	resp.Success = true
+	resp.Data = map[string]any{
+		"list": list,
+	}
+	return
+}
+
+// GetClientInfo get the latest info of client by id on specified node
+func (c *clientService) GetClientInfo(server, node string, id int64) (resp types.JSResp) {
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	if _, ok := client.(*redis.ClusterClient); ok {
+		resp.Msg = "node of client is required in cluster mode"
+		return
+	}
+
+	// "CLIENT LIST ID" is available since redis 6.2
+	content, err := client.Do(ctx, "CLIENT", "LIST", "ID", id).Text()
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	list := parseClientList(content)
+	if len(list) <= 0 {
+		resp.Msg = fmt.Sprintf("client %d not found", id)
+		return
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"info": parseClientInfo(list[0], node, url.QueryEscape(server)),
+	}
+	return
+}
+
+// KillClients close connections match filter by "CLIENT KILL", filter type should be one of id, addr, laddr and user.
+// clients are killed on all nodes in cluster mode if node is empty
+func (c *clientService) KillClients(server, node, filterType, value string) (resp types.JSResp) {
+	filterType = strings.ToLower(filterType)
+	switch filterType {
+	case "id", "addr", "laddr", "user":
+	default:
+		resp.Msg = fmt.Sprintf("unsupported filter \"%s\"", filterType)
+		return
+	}
+	if len(value) <= 0 {
+		resp.Msg = "filter value is required"
+		return
+	}
+
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	if _, ok := client.(*redis.ClusterClient); ok && filterType != "user" {
+		// id and address are only unique in one node
+		resp.Msg = "node of client is required in cluster mode"
+		return
+	}
+
+	var killed int64
+	var mutex sync.Mutex
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		n, err := cli.ClientKillByFilter(ctx, strings.ToUpper(filterType), value).Result()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		mutex.Lock()
+		killed += n
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"killed": killed,
+	}
+	return
+}
+
+// PauseClients suspend clients by "CLIENT PAUSE" for timeout in milliseconds, mode should be "write" or "all".
+// all nodes are paused in cluster mode if node is empty
+func (c *clientService) PauseClients(server, node string, timeout int64, mode string) (resp types.JSResp) {
+	if timeout <= 0 {
+		resp.Msg = "timeout should be greater than 0"
+		return
+	}
+	mode = strings.ToUpper(mode)
+	if mode != "WRITE" && mode != "ALL" {
+		resp.Msg = fmt.Sprintf("unsupported pause mode \"%s\"", mode)
+		return
+	}
+
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		// mode is available since redis 6.2
+		return cli.Do(ctx, "CLIENT", "PAUSE", timeout, mode).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// UnpauseClients resume clients paused by "CLIENT UNPAUSE"
+func (c *clientService) UnpauseClients(server, node string) (resp types.JSResp) {
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.ClientUnpause(ctx).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+func (c *clientService) noEvictEnabled(server string) bool {
+	c.noEvictMutex.Lock()
+	defer c.noEvictMutex.Unlock()
+	return c.noEvict[server]
+}
+
+// SetNoEvict protect connections of this app from client eviction by "CLIENT NO-EVICT".
+// the command only affects connection which sends it, so it's sent on connect of each pooled connection,
+// and the opened client is reconnected to apply it
+func (c *clientService) SetNoEvict(server string, enable bool) (resp types.JSResp) {
+	if enable {
+		// check if command is supported by server first
+		client, ctx, closeFunc, err := Browser().getNodeClient(server, "")
+		if err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+		err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+			return cli.Do(ctx, "CLIENT", "NO-EVICT", "on").Err()
+		})
+		closeFunc()
+		if err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
+
+	c.noEvictMutex.Lock()
+	if enable {
+		c.noEvict[server] = true
+	} else {
+		delete(c.noEvict, server)
+	}
+	c.noEvictMutex.Unlock()
+
+	if err := Browser().reconnectRedisClient(server); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
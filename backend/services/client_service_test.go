@@ -0,0 +1,102 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+func TestParseClientList(t *testing.T) {
+	content := "id=3 addr=127.0.0.1:50000 laddr=127.0.0.1:6379 name= age=10 cmd=client|list\n" +
+		"id=4 addr=10.0.0.2:50001 name=svc flags=N\r\n\n"
+	got := parseClientList(content)
+	want := []map[string]string{
+		{"id": "3", "addr": "127.0.0.1:50000", "laddr": "127.0.0.1:6379", "name": "", "age": "10", "cmd": "client|list"},
+		{"id": "4", "addr": "10.0.0.2:50001", "name": "svc", "flags": "N"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseClientList() = %v, want %v", got, want)
+	}
+}
+
+func TestParseClientInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		fields       map[string]string
+		selfName     string
+		wantFlags    []string
+		wantTracking types.ClientTracking
+		wantSelf     bool
+	}{
+		{
+			name:      "normal",
+			fields:    map[string]string{"id": "5", "flags": "N", "redir": "-1"},
+			wantFlags: []string{},
+		},
+		{
+			name:         "tracking with redirect",
+			fields:       map[string]string{"id": "5", "flags": "tBe", "redir": "7"},
+			wantFlags:    []string{"tracking", "broadcast", "no_evict"},
+			wantTracking: types.ClientTracking{Enabled: true, Broadcast: true, Redirect: 7},
+		},
+		{
+			name:         "redirect client closed",
+			fields:       map[string]string{"id": "5", "flags": "tR", "redir": "7"},
+			wantFlags:    []string{"tracking", "tracking_invalid"},
+			wantTracking: types.ClientTracking{Enabled: true, Invalid: true, Redirect: 7},
+		},
+		{
+			name:      "unknown flag",
+			fields:    map[string]string{"id": "5", "flags": "PZ"},
+			wantFlags: []string{"pubsub", "Z"},
+		},
+		{
+			name:      "self",
+			fields:    map[string]string{"id": "5", "name": "tinyrdm", "flags": "N"},
+			selfName:  "tinyrdm",
+			wantFlags: []string{},
+			wantSelf:  true,
+		},
+		{
+			name:      "unnamed is not self",
+			fields:    map[string]string{"id": "5", "name": "", "flags": "N"},
+			wantFlags: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := parseClientInfo(tt.fields, "node:1", tt.selfName)
+			if info.ID != 5 || info.Node != "node:1" {
+				t.Errorf("id = %d, node = %s", info.ID, info.Node)
+			}
+			if !reflect.DeepEqual(info.Flags, tt.wantFlags) {
+				t.Errorf("flags = %v, want %v", info.Flags, tt.wantFlags)
+			}
+			if info.Tracking != tt.wantTracking {
+				t.Errorf("tracking = %+v, want %+v", info.Tracking, tt.wantTracking)
+			}
+			if info.Self != tt.wantSelf {
+				t.Errorf("self = %v, want %v", info.Self, tt.wantSelf)
+			}
+		})
+	}
+}
+
+func TestClientActionsInvalidArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		call func() types.JSResp
+	}{
+		{"kill by unknown filter", func() types.JSResp { return Client().KillClients("s", "", "name", "svc") }},
+		{"kill without value", func() types.JSResp { return Client().KillClients("s", "", "addr", "") }},
+		{"pause without timeout", func() types.JSResp { return Client().PauseClients("s", "", 0, "write") }},
+		{"pause by unknown mode", func() types.JSResp { return Client().PauseClients("s", "", 1000, "read") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if resp := tt.call(); resp.Success || len(resp.Msg) <= 0 {
+				t.Errorf("resp = %+v, want failure with message", resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,266 @@
+package services
+
+// configParamMeta known parameter of redis.conf, default values are as reported by "CONFIG GET" of redis 7.x
+type configParamMeta struct {
+	section   string
+	def       string
+	kind      string
+	options   []string
+	readOnly  bool
+	noDefault bool // default value depends on environment
+}
+
+const (
+	configKindBool   = "bool"
+	configKindInt    = "int"
+	configKindMemory = "memory"
+	configKindEnum   = "enum"
+	configKindString = "string"
+)
+
+// configSections sections in the order of redis.conf
+var configSections = []string{
+	"network", "tls", "general", "snapshotting", "replication", "keys_tracking", "security", "clients",
+	"memory_management", "lazy_freeing", "threaded_io", "kernel", "append_only", "shutdown", "scripting",
+	"cluster", "slow_log", "latency_monitor", "event_notification", "advanced", "active_defrag", "other",
+}
+
+func boolParam(section, def string) configParamMeta {
+	return configParamMeta{section: section, def: def, kind: configKindBool}
+}
+
+func intParam(section, def string) configParamMeta {
+	return configParamMeta{section: section, def: def, kind: configKindInt}
+}
+
+func memoryParam(section, def string) configParamMeta {
+	return configParamMeta{section: section, def: def, kind: configKindMemory}
+}
+
+func enumParam(section, def string, options ...string) configParamMeta {
+	return configParamMeta{section: section, def: def, kind: configKindEnum, options: options}
+}
+
+func stringParam(section, def string) configParamMeta {
+	return configParamMeta{section: section, def: def, kind: configKindString}
+}
+
+func immutable(meta configParamMeta) configParamMeta {
+	meta.readOnly = true
+	return meta
+}
+
+var configParams = map[string]configParamMeta{
+	// network
+	"bind":           stringParam("network", "* -::*"),
+	"protected-mode": boolParam("network", "yes"),
+	"port":           intParam("network", "6379"),
+	"tcp-backlog":    immutable(intParam("network", "511")),
+	"unixsocket":     immutable(stringParam("network", "")),
+	"unixsocketperm": immutable(intParam("network", "0")),
+	"timeout":        intParam("network", "0"),
+	"tcp-keepalive":  intParam("network", "300"),
+	"socket-mark-id": intParam("network", "0"),
+
+	// tls
+	"tls-port":                  intParam("tls", "0"),
+	"tls-cert-file":             stringParam("tls", ""),
+	"tls-key-file":              stringParam("tls", ""),
+	"tls-ca-cert-file":          stringParam("tls", ""),
+	"tls-auth-clients":          enumParam("tls", "yes", "yes", "no", "optional"),
+	"tls-replication":           boolParam("tls", "no"),
+	"tls-cluster":               boolParam("tls", "no"),
+	"tls-protocols":             stringParam("tls", ""),
+	"tls-ciphers":               stringParam("tls", ""),
+	"tls-ciphersuites":          stringParam("tls", ""),
+	"tls-prefer-server-ciphers": boolParam("tls", "no"),
+	"tls-session-caching":       boolParam("tls", "yes"),
+	"tls-session-cache-size":    intParam("tls", "20480"),
+	"tls-session-cache-timeout": intParam("tls", "300"),
+
+	// general
+	"daemonize":              immutable(boolParam("general", "no")),
+	"supervised":             immutable(enumParam("general", "no", "no", "upstart", "systemd", "auto")),
+	"pidfile":                immutable(stringParam("general", "")),
+	"loglevel":               enumParam("general", "notice", "debug", "verbose", "notice", "warning", "nothing"),
+	"logfile":                immutable(stringParam("general", "")),
+	"syslog-enabled":         immutable(boolParam("general", "no")),
+	"syslog-ident":           immutable(stringParam("general", "redis")),
+	"syslog-facility":        immutable(stringParam("general", "local0")),
+	"crash-log-enabled":      boolParam("general", "yes"),
+	"crash-memcheck-enabled": boolParam("general", "yes"),
+	"databases":              immutable(intParam("general", "16")),
+	"always-show-logo":       immutable(boolParam("general", "no")),
+	"set-proc-title":         immutable(boolParam("general", "yes")),
+	"proc-title-template":    stringParam("general", "{title} {listen-addr} {server-mode}"),
+	"locale-collate":         stringParam("general", ""),
+
+	// snapshotting
+	"save":                        stringParam("snapshotting", "3600 1 300 100 60 10000"),
+	"stop-writes-on-bgsave-error": boolParam("snapshotting", "yes"),
+	"rdbcompression":              boolParam("snapshotting", "yes"),
+	"rdbchecksum":                 boolParam("snapshotting", "yes"),
+	"sanitize-dump-payload":       enumParam("snapshotting", "no", "no", "yes", "clients"),
+	"dbfilename":                  stringParam("snapshotting", "dump.rdb"),
+	"rdb-del-sync-files":          boolParam("snapshotting", "no"),
+	"dir":                         {section: "snapshotting", kind: configKindString, noDefault: true},
+
+	// replication
+	"replicaof":                        stringParam("replication", ""),
+	"masterauth":                       stringParam("replication", ""),
+	"masteruser":                       stringParam("replication", ""),
+	"replica-serve-stale-data":         boolParam("replication", "yes"),
+	"replica-read-only":                boolParam("replication", "yes"),
+	"repl-diskless-sync":               boolParam("replication", "yes"),
+	"repl-diskless-sync-delay":         intParam("replication", "5"),
+	"repl-diskless-sync-max-replicas":  intParam("replication", "0"),
+	"repl-diskless-load":               enumParam("replication", "disabled", "disabled", "on-empty-db", "swapdb"),
+	"repl-ping-replica-period":         intParam("replication", "10"),
+	"repl-timeout":                     intParam("replication", "60"),
+	"repl-disable-tcp-nodelay":         boolParam("replication", "no"),
+	"repl-backlog-size":                memoryParam("replication", "1048576"),
+	"repl-backlog-ttl":                 intParam("replication", "3600"),
+	"replica-priority":                 intParam("replication", "100"),
+	"propagation-error-behavior":       enumParam("replication", "ignore", "ignore", "panic", "panic-on-replicas"),
+	"replica-ignore-disk-write-errors": boolParam("replication", "no"),
+	"replica-announced":                boolParam("replication", "yes"),
+	"min-replicas-to-write":            intParam("replication", "0"),
+	"min-replicas-max-lag":             intParam("replication", "10"),
+	"replica-announce-ip":              stringParam("replication", ""),
+	"replica-announce-port":            intParam("replication", "0"),
+
+	// keys tracking
+	"tracking-table-max-keys": intParam("keys_tracking", "1000000"),
+
+	// security
+	"acllog-max-len":           intParam("security", "128"),
+	"aclfile":                  immutable(stringParam("security", "")),
+	"requirepass":              stringParam("security", ""),
+	"acl-pubsub-default":       enumParam("security", "resetchannels", "allchannels", "resetchannels"),
+	"enable-protected-configs": immutable(enumParam("security", "no", "no", "yes", "local")),
+	"enable-debug-command":     immutable(enumParam("security", "no", "no", "yes", "local")),
+	"enable-module-command":    immutable(enumParam("security", "no", "no", "yes", "local")),
+
+	// clients
+	"maxclients": intParam("clients", "10000"),
+
+	// memory management
+	"maxmemory": memoryParam("memory_management", "0"),
+	"maxmemory-policy": enumParam("memory_management", "noeviction",
+		"volatile-lru", "allkeys-lru", "volatile-lfu", "allkeys-lfu",
+		"volatile-random", "allkeys-random", "volatile-ttl", "noeviction"),
+	"maxmemory-samples":           intParam("memory_management", "5"),
+	"maxmemory-eviction-tenacity": intParam("memory_management", "10"),
+	"replica-ignore-maxmemory":    boolParam("memory_management", "yes"),
+	"active-expire-effort":        intParam("memory_management", "1"),
+
+	// lazy freeing
+	"lazyfree-lazy-eviction":   boolParam("lazy_freeing", "no"),
+	"lazyfree-lazy-expire":     boolParam("lazy_freeing", "no"),
+	"lazyfree-lazy-server-del": boolParam("lazy_freeing", "no"),
+	"replica-lazy-flush":       boolParam("lazy_freeing", "no"),
+	"lazyfree-lazy-user-del":   boolParam("lazy_freeing", "no"),
+	"lazyfree-lazy-user-flush": boolParam("lazy_freeing", "no"),
+
+	// threaded I/O
+	"io-threads":          immutable(intParam("threaded_io", "1")),
+	"io-threads-do-reads": immutable(boolParam("threaded_io", "no")),
+
+	// kernel OOM control and transparent huge pages
+	"oom-score-adj":        enumParam("kernel", "no", "no", "yes", "relative", "absolute"),
+	"oom-score-adj-values": stringParam("kernel", "0 200 800"),
+	"disable-thp":          immutable(boolParam("kernel", "yes")),
+
+	// append only mode
+	"appendonly":                  boolParam("append_only", "no"),
+	"appendfilename":              immutable(stringParam("append_only", "appendonly.aof")),
+	"appenddirname":               immutable(stringParam("append_only", "appendonlydir")),
+	"appendfsync":                 enumParam("append_only", "everysec", "always", "everysec", "no"),
+	"no-appendfsync-on-rewrite":   boolParam("append_only", "no"),
+	"auto-aof-rewrite-percentage": intParam("append_only", "100"),
+	"auto-aof-rewrite-min-size":   memoryParam("append_only", "67108864"),
+	"aof-load-truncated":          boolParam("append_only", "yes"),
+	"aof-use-rdb-preamble":        boolParam("append_only", "yes"),
+	"aof-timestamp-enabled":       boolParam("append_only", "no"),
+
+	// shutdown
+	"shutdown-timeout":    intParam("shutdown", "10"),
+	"shutdown-on-sigint":  stringParam("shutdown", "default"),
+	"shutdown-on-sigterm": stringParam("shutdown", "default"),
+
+	// lua scripting and functions
+	"lua-time-limit":       intParam("scripting", "5000"),
+	"busy-reply-threshold": intParam("scripting", "5000"),
+
+	// cluster
+	"cluster-enabled":                     immutable(boolParam("cluster", "no")),
+	"cluster-config-file":                 immutable(stringParam("cluster", "nodes.conf")),
+	"cluster-node-timeout":                intParam("cluster", "15000"),
+	"cluster-port":                        immutable(intParam("cluster", "0")),
+	"cluster-replica-validity-factor":     intParam("cluster", "10"),
+	"cluster-migration-barrier":           intParam("cluster", "1"),
+	"cluster-allow-replica-migration":     boolParam("cluster", "yes"),
+	"cluster-require-full-coverage":       boolParam("cluster", "yes"),
+	"cluster-replica-no-failover":         boolParam("cluster", "no"),
+	"cluster-allow-reads-when-down":       boolParam("cluster", "no"),
+	"cluster-allow-pubsubshard-when-down": boolParam("cluster", "yes"),
+	"cluster-link-sendbuf-limit":          memoryParam("cluster", "0"),
+	"cluster-announce-hostname":           stringParam("cluster", ""),
+	"cluster-announce-human-nodename":     stringParam("cluster", ""),
+	"cluster-preferred-endpoint-type":     enumParam("cluster", "ip", "ip", "hostname", "unknown-endpoint"),
+	"cluster-announce-ip":                 stringParam("cluster", ""),
+	"cluster-announce-port":               intParam("cluster", "0"),
+	"cluster-announce-tls-port":           intParam("cluster", "0"),
+	"cluster-announce-bus-port":           intParam("cluster", "0"),
+
+	// slow log
+	"slowlog-log-slower-than": intParam("slow_log", "10000"),
+	"slowlog-max-len":         intParam("slow_log", "128"),
+
+	// latency monitor
+	"latency-monitor-threshold":         intParam("latency_monitor", "0"),
+	"latency-tracking":                  boolParam("latency_monitor", "yes"),
+	"latency-tracking-info-percentiles": stringParam("latency_monitor", "50 99 99.9"),
+
+	// event notification
+	"notify-keyspace-events": stringParam("event_notification", ""),
+
+	// advanced config
+	"hash-max-listpack-entries":     intParam("advanced", "128"),
+	"hash-max-listpack-value":       intParam("advanced", "64"),
+	"hash-max-ziplist-entries":      intParam("advanced", "128"),
+	"hash-max-ziplist-value":        intParam("advanced", "64"),
+	"list-max-listpack-size":        intParam("advanced", "-2"),
+	"list-max-ziplist-size":         intParam("advanced", "-2"),
+	"list-compress-depth":           intParam("advanced", "0"),
+	"set-max-intset-entries":        intParam("advanced", "512"),
+	"set-max-listpack-entries":      intParam("advanced", "128"),
+	"set-max-listpack-value":        intParam("advanced", "64"),
+	"zset-max-listpack-entries":     intParam("advanced", "128"),
+	"zset-max-listpack-value":       intParam("advanced", "64"),
+	"zset-max-ziplist-entries":      intParam("advanced", "128"),
+	"zset-max-ziplist-value":        intParam("advanced", "64"),
+	"hll-sparse-max-bytes":          memoryParam("advanced", "3000"),
+	"stream-node-max-bytes":         memoryParam("advanced", "4096"),
+	"stream-node-max-entries":       intParam("advanced", "100"),
+	"activerehashing":               boolParam("advanced", "yes"),
+	"client-output-buffer-limit":    stringParam("advanced", "normal 0 0 0 slave 268435456 67108864 60 pubsub 33554432 8388608 60"),
+	"client-query-buffer-limit":     memoryParam("advanced", "1073741824"),
+	"proto-max-bulk-len":            memoryParam("advanced", "536870912"),
+	"hz":                            intParam("advanced", "10"),
+	"dynamic-hz":                    boolParam("advanced", "yes"),
+	"aof-rewrite-incremental-fsync": boolParam("advanced", "yes"),
+	"rdb-save-incremental-fsync":    boolParam("advanced", "yes"),
+	"lfu-log-factor":                intParam("advanced", "10"),
+	"lfu-decay-time":                intParam("advanced", "1"),
+	"jemalloc-bg-thread":            boolParam("advanced", "yes"),
+
+	// active defragmentation
+	"activedefrag":                  boolParam("active_defrag", "no"),
+	"active-defrag-ignore-bytes":    memoryParam("active_defrag", "104857600"),
+	"active-defrag-threshold-lower": intParam("active_defrag", "10"),
+	"active-defrag-threshold-upper": intParam("active_defrag", "100"),
+	"active-defrag-cycle-min":       intParam("active_defrag", "1"),
+	"active-defrag-cycle-max":       intParam("active_defrag", "25"),
+	"active-defrag-max-scan-fields": intParam("active_defrag", "1000"),
+}
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+type configService struct {
+	ctx context.Context
+}
+
+var configs *configService
+var onceConfigs sync.Once
+
+func Config() *configService {
+	if configs == nil {
+		onceConfigs.Do(func() {
+			configs = &configService{}
+		})
+	}
+	return configs
+}
+
+func (c *configService) Start(ctx context.Context) {
+	c.ctx = ctx
+}
+
+// parseMemory parse memory value with unit like "100mb", units without "b" are in power of 1000
+func parseMemory(val string) (int64, error) {
+	val = strings.ToLower(strings.TrimSpace(val))
+	units := []struct {
+		suffix string
+		factor int64
+	}{
+		{"gb", 1 << 30}, {"mb", 1 << 20}, {"kb", 1 << 10},
+		{"g", 1000 * 1000 * 1000}, {"m", 1000 * 1000}, {"k", 1000}, {"b", 1},
+	}
+	factor := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(val, unit.suffix) {
+			val, factor = strings.TrimSuffix(val, unit.suffix), unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(val, 10, 64)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid memory value")
+	}
+	return n * factor, nil
+}
+
+// configValueEqual compare values of parameter with the same semantic
+func configValueEqual(kind, val1, val2 string) bool {
+	switch kind {
+	case configKindMemory:
+		n1, err1 := parseMemory(val1)
+		n2, err2 := parseMemory(val2)
+		if err1 == nil && err2 == nil {
+			return n1 == n2
+		}
+	case configKindBool, configKindEnum:
+		return strings.EqualFold(val1, val2)
+	}
+	return strings.Join(strings.Fields(val1), " ") == strings.Join(strings.Fields(val2), " ")
+}
+
+// validateConfig validate value of parameter before "CONFIG SET", unknown parameter is left to server
+func validateConfig(name, value string) error {
+	meta, ok := configParams[name]
+	if !ok {
+		return nil
+	}
+	if meta.readOnly {
+		return fmt.Errorf("\"%s\" could not be modified at runtime", name)
+	}
+	switch meta.kind {
+	case configKindBool:
+		if v := strings.ToLower(value); v != "yes" && v != "no" {
+			return fmt.Errorf("\"%s\" should be yes or no", name)
+		}
+	case configKindInt:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("\"%s\" should be an integer", name)
+		}
+	case configKindMemory:
+		if _, err := parseMemory(value); err != nil {
+			return fmt.Errorf("\"%s\" should be a memory size like 100mb", name)
+		}
+	case configKindEnum:
+		if !slices.Contains(meta.options, strings.ToLower(value)) {
+			return fmt.Errorf("\"%s\" should be one of %s", name, strings.Join(meta.options, ", "))
+		}
+	}
+	return nil
+}
+
+// loadParams load all parameters by "CONFIG GET *" sorted by section
+func (c *configService) loadParams(server, node string) ([]types.ConfigParam, error) {
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	values, err := client.ConfigGet(ctx, "*").Result()
+	if err != nil {
+		return nil, err
+	}
+	params := make([]types.ConfigParam, 0, len(values))
+	for name, value := range values {
+		param := types.ConfigParam{
+			Name:    name,
+			Value:   value,
+			Section: "other",
+			Kind:    configKindString,
+		}
+		if meta, ok := configParams[name]; ok {
+			param.Section = meta.section
+			param.Kind = meta.kind
+			param.Options = meta.options
+			param.ReadOnly = meta.readOnly
+			if !meta.noDefault {
+				param.HasDefault = true
+				param.Default = meta.def
+				param.Modified = !configValueEqual(meta.kind, value, meta.def)
+			}
+		}
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].Section != params[j].Section {
+			return slices.Index(configSections, params[i].Section) < slices.Index(configSections, params[j].Section)
+		}
+		return params[i].Name < params[j].Name
+	})
+	return params, nil
+}
+
+// GetConfig get all parameters of server or specified node, compared with default values
+func (c *configService) GetConfig(server, node string) (resp types.JSResp) {
+	params, err := c.loadParams(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"params":   params,
+		"sections": configSections,
+	}
+	return
+}
+
+// SetConfig validate and apply parameters by "CONFIG SET",
+// all nodes are applied in cluster mode if node is not specified
+func (c *configService) SetConfig(server, node string, params map[string]string) (resp types.JSResp) {
+	if len(params) <= 0 {
+		resp.Msg = "no parameter to set"
+		return
+	}
+	names := make([]string, 0, len(params))
+	for name, value := range params {
+		if err := validateConfig(name, value); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	// previous values of applied parameters on each node, restored if failed on any node
+	type nodeState struct {
+		cli      redis.UniversalClient
+		result   *types.ConfigSetResult
+		previous map[string]string
+	}
+	var states []*nodeState
+	var mutex sync.Mutex
+	var failed bool
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		state := &nodeState{
+			cli:      cli,
+			result:   &types.ConfigSetResult{Applied: []string{}},
+			previous: map[string]string{},
+		}
+		if c, ok := cli.(*redis.Client); ok {
+			state.result.Node = c.Options().Addr
+		}
+		// set one by one, multiple parameters in one command is not supported before redis 7.0
+		for _, name := range names {
+			prev, err := cli.ConfigGet(ctx, name).Result()
+			if err == nil {
+				err = cli.ConfigSet(ctx, name, params[name]).Err()
+			}
+			if err != nil {
+				state.result.Failed = name
+				state.result.Error = err.Error()
+				break
+			}
+			state.previous[name] = prev[name]
+			state.result.Applied = append(state.result.Applied, name)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		states = append(states, state)
+		failed = failed || len(state.result.Failed) > 0
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	if failed {
+		// restore applied parameters of all nodes, so that nodes are not configured partially
+		for _, state := range states {
+			var rollbackErr error
+			for i := len(state.result.Applied) - 1; i >= 0; i-- {
+				name := state.result.Applied[i]
+				if err = state.cli.ConfigSet(ctx, name, state.previous[name]).Err(); err != nil {
+					rollbackErr = fmt.Errorf("restore %s: %w", name, err)
+				}
+			}
+			if rollbackErr != nil {
+				state.result.Error = strings.TrimPrefix(state.result.Error+"; "+rollbackErr.Error(), "; ")
+			} else {
+				state.result.Restored = len(state.result.Applied) > 0
+			}
+		}
+	}
+
+	results := make([]types.ConfigSetResult, len(states))
+	var errMsgs []string
+	for i, state := range states {
+		results[i] = *state.result
+		if len(state.result.Error) > 0 {
+			msg := state.result.Error
+			if len(state.result.Failed) > 0 {
+				msg = state.result.Failed + ": " + msg
+			}
+			if len(state.result.Node) > 0 {
+				msg = state.result.Node + " " + msg
+			}
+			errMsgs = append(errMsgs, msg)
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Node < results[j].Node
+	})
+	sort.Strings(errMsgs)
+	resp.Data = map[string]any{
+		"results": results,
+	}
+	if failed {
+		resp.Msg = strings.Join(errMsgs, "\n")
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// RewriteConfig rewrite config file with current parameters by "CONFIG REWRITE"
+func (c *configService) RewriteConfig(server, node string) (resp types.JSResp) {
+	client, ctx, closeFunc, err := Browser().getNodeClient(server, node)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	defer closeFunc()
+
+	err = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		return cli.ConfigRewrite(ctx).Err()
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// parameters which accept multiple arguments in config file
+var configMultiArgParams = []string{
+	"bind", "save", "client-output-buffer-limit", "oom-score-adj-values", "latency-tracking-info-percentiles",
+}
+
+// parameters which contain passwords or locate credentials, omitted from exported snapshot unless requested
+var configSecretParams = []string{
+	"requirepass", "masterauth", "aclfile",
+}
+
+// ExportConfigToBytes generate snapshot of parameters in redis.conf format,
+// each non-default value is preceded by a "# default:" comment,
+// secret parameters are commented out unless withSecrets is set
+func (c *configService) ExportConfigToBytes(server, node string, withSecrets bool) ([]byte, string, error) {
+	params, err := c.loadParams(server, node)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	target := server
+	if len(node) > 0 {
+		target = fmt.Sprintf("%s (%s)", server, node)
+	}
+	filename := fmt.Sprintf("redis_%s.conf", now.Format("20060102150405"))
+	return formatConfigSnapshot(target, params, now, withSecrets), filename, nil
+}
+
+func formatConfigSnapshot(target string, params []types.ConfigParam, now time.Time, withSecrets bool) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("# Configuration snapshot of %s\n", target))
+	buf.WriteString(fmt.Sprintf("# Exported at %s\n", now.Format(time.DateTime)))
+	var section string
+	for _, param := range params {
+		if param.Section != section {
+			section = param.Section
+			buf.WriteString(fmt.Sprintf("\n################ %s ################\n\n",
+				strings.ToUpper(strings.ReplaceAll(section, "_", " "))))
+		}
+		if !withSecrets && slices.Contains(configSecretParams, param.Name) {
+			buf.WriteString(fmt.Sprintf("# %s <omitted>\n", param.Name))
+			continue
+		}
+		if param.Modified {
+			buf.WriteString(fmt.Sprintf("# default: %s\n", param.Default))
+		}
+		value := param.Value
+		if !slices.Contains(configMultiArgParams, param.Name) || len(value) <= 0 {
+			if len(value) <= 0 || strings.ContainsAny(value, " \t\"'\\") {
+				value = strconv.Quote(value)
+			}
+		}
+		buf.WriteString(param.Name + " " + value + "\n")
+	}
+	return buf.Bytes()
+}
+
+// ExportConfig export snapshot of parameters to file
+func (c *configService) ExportConfig(server, node string, withSecrets bool) (resp types.JSResp) {
+	content, filename, err := c.ExportConfigToBytes(server, node, withSecrets)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	filepath, err := SaveFileDialog(c.ctx, SaveDialogOptions{
+		ShowHiddenFiles: false,
+		DefaultFilename: filename,
+		Filters: []FileFilter{
+			{Pattern: "*.conf"},
+		},
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if len(filepath) <= 0 {
+		return
+	}
+
+	if err = os.WriteFile(filepath, content, 0600); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"tinyrdm/backend/types"
+)
+
+func TestFormatConfigSnapshot(t *testing.T) {
+	params := []types.ConfigParam{
+		{Name: "port", Section: "network", Value: "6380", Default: "6379", Modified: true},
+		{Name: "bind", Section: "network", Value: "127.0.0.1 -::1", Default: "* -::*", Modified: true},
+		{Name: "masterauth", Section: "replication", Value: "secret", Modified: true},
+		{Name: "requirepass", Section: "security", Value: "secret", Modified: true},
+		{Name: "aclfile", Section: "security", Value: "/etc/redis/users.acl", Modified: true},
+		{Name: "loglevel", Section: "general", Value: "notice", Default: "notice"},
+		{Name: "logfile", Section: "general", Value: ""},
+	}
+	tests := []struct {
+		name        string
+		withSecrets bool
+		want        []string
+		notWant     []string
+	}{
+		{
+			name: "secrets omitted",
+			want: []string{
+				"# default: 6379\nport 6380\n",
+				"bind 127.0.0.1 -::1\n",
+				"# masterauth <omitted>\n",
+				"# requirepass <omitted>\n",
+				"# aclfile <omitted>\n",
+				"\nloglevel notice\n",
+				"logfile \"\"\n",
+				"################ REPLICATION ################",
+			},
+			notWant: []string{"secret", "users.acl", "# default: notice"},
+		},
+		{
+			name:        "secrets included",
+			withSecrets: true,
+			want: []string{
+				"masterauth secret\n",
+				"requirepass secret\n",
+				"aclfile /etc/redis/users.acl\n",
+			},
+			notWant: []string{"<omitted>"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(formatConfigSnapshot("local", params, time.Now(), tt.withSecrets))
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("snapshot should contain %q:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("snapshot should not contain %q:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,567 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"tinyrdm/backend/types"
+)
+
+const IMPORT_SOURCE_TINYRDM = "tinyrdm"
+const IMPORT_SOURCE_ARDM = "ardm"
+const IMPORT_SOURCE_RESP = "resp"
+const IMPORT_SOURCE_REDIS_INSIGHT = "redisinsight"
+const IMPORT_SOURCE_URL = "url"
+
+// importFields wraps a decoded json object and records which fields have been mapped
+type importFields struct {
+	prefix   string
+	values   map[string]any
+	used     map[string]bool
+	children []*importFields
+}
+
+func newImportFields(prefix string, values map[string]any) *importFields {
+	return &importFields{
+		prefix: prefix,
+		values: values,
+		used:   map[string]bool{},
+	}
+}
+
+func (f *importFields) has(key string) bool {
+	_, ok := f.values[key]
+	return ok
+}
+
+func (f *importFields) str(key string) string {
+	f.used[key] = true
+	switch v := f.values[key].(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+func (f *importFields) int(key string) int {
+	f.used[key] = true
+	switch v := f.values[key].(type) {
+	case float64:
+		return int(v)
+	case string:
+		n, _ := strconv.Atoi(strings.TrimSpace(v))
+		return n
+	}
+	return 0
+}
+
+func (f *importFields) bool(key string) bool {
+	f.used[key] = true
+	switch v := f.values[key].(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return false
+}
+
+// sub returns fields of nested object, nil if not present
+func (f *importFields) sub(key string) *importFields {
+	f.used[key] = true
+	values, ok := f.values[key].(map[string]any)
+	if !ok {
+		return nil
+	}
+	child := newImportFields(f.prefix+key+".", values)
+	f.children = append(f.children, child)
+	return child
+}
+
+// skip marks fields which are meaningless to import, such as internal id
+func (f *importFields) skip(keys ...string) {
+	for _, key := range keys {
+		f.used[key] = true
+	}
+}
+
+// unmapped returns non-empty fields which were not consumed
+func (f *importFields) unmapped() (ret []string) {
+	for key, val := range f.values {
+		if !f.used[key] && !isEmptyImportValue(val) {
+			ret = append(ret, f.prefix+key)
+		}
+	}
+	for _, child := range f.children {
+		ret = append(ret, child.unmapped()...)
+	}
+	sort.Strings(ret)
+	return
+}
+
+func isEmptyImportValue(val any) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return len(v) <= 0
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case []any:
+		return len(v) <= 0
+	case map[string]any:
+		for _, item := range v {
+			if !isEmptyImportValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// parseForeignConnections parse connections exported by other redis clients or a list of redis urls,
+// returns the detected source and unmapped fields of each connection
+func parseForeignConnections(data []byte, defaultConf types.ConnectionConfig) (conns types.Connections,
+	source string, unmapped map[string][]string, err error) {
+	content := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(content) <= 0 {
+		err = errors.New("empty file")
+		return
+	}
+
+	if content[0] != '[' && content[0] != '{' {
+		if isRedisURLList(content) {
+			source = IMPORT_SOURCE_URL
+			conns, unmapped, err = parseRedisURLs(content, defaultConf)
+			return
+		}
+		// connections file exported by ARDM is base64 encoded json
+		decoded, derr := base64.StdEncoding.DecodeString(string(content))
+		if derr != nil {
+			err = errors.New("unsupported file format")
+			return
+		}
+		content = bytes.TrimSpace(decoded)
+	}
+
+	var items []map[string]any
+	if err = json.Unmarshal(content, &items); err != nil {
+		var wrapper map[string]any
+		if json.Unmarshal(content, &wrapper) != nil {
+			err = errors.New("unsupported file format")
+			return
+		}
+		// single connection or wrapped in "connections"
+		if list, ok := wrapper["connections"].([]any); ok {
+			for _, item := range list {
+				if obj, ok := item.(map[string]any); ok {
+					items = append(items, obj)
+				}
+			}
+		} else {
+			items = append(items, wrapper)
+		}
+		err = nil
+	}
+
+	source = detectImportSource(items)
+	var parse func(*importFields, types.ConnectionConfig) types.ConnectionConfig
+	switch source {
+	case IMPORT_SOURCE_ARDM:
+		parse = parseARDMConnection
+	case IMPORT_SOURCE_RESP:
+		parse = parseRESPConnection
+	case IMPORT_SOURCE_REDIS_INSIGHT:
+		parse = parseRedisInsightConnection
+	default:
+		err = errors.New("unsupported file format")
+		return
+	}
+
+	names := map[string]bool{}
+	unmapped = map[string][]string{}
+	for _, item := range items {
+		fields := newImportFields("", item)
+		conf := parse(fields, defaultConf)
+		conf.Name = uniqueImportName(names, conf.Name)
+		conns = append(conns, types.Connection{ConnectionConfig: conf})
+		if missing := fields.unmapped(); len(missing) > 0 {
+			unmapped[conf.Name] = missing
+		}
+	}
+	if len(conns) <= 0 {
+		err = errors.New("no connection found")
+	}
+	return
+}
+
+func detectImportSource(items []map[string]any) string {
+	for _, item := range items {
+		for _, key := range []string{"connectionType", "nameFromProvider", "verifyServerCert", "tlsServername"} {
+			if _, ok := item[key]; ok {
+				return IMPORT_SOURCE_REDIS_INSIGHT
+			}
+		}
+		for _, key := range []string{"namespace_separator", "ssh_host", "timeout_connect", "keys_pattern"} {
+			if _, ok := item[key]; ok {
+				return IMPORT_SOURCE_RESP
+			}
+		}
+		for _, key := range []string{"auth", "separator", "sshOptions", "sslOptions", "sentinelOptions"} {
+			if _, ok := item[key]; ok {
+				return IMPORT_SOURCE_ARDM
+			}
+		}
+	}
+	return ""
+}
+
+// uniqueImportName avoid duplicate names inside imported file
+func uniqueImportName(names map[string]bool, name string) string {
+	newName := name
+	for i := 2; names[newName]; i++ {
+		newName = fmt.Sprintf("%s (%d)", name, i)
+	}
+	names[newName] = true
+	return newName
+}
+
+func importAddrName(addr string, port int) string {
+	return net.JoinHostPort(addr, strconv.Itoa(port))
+}
+
+// parseARDMConnection map connection of Another Redis Desktop Manager
+func parseARDMConnection(f *importFields, conf types.ConnectionConfig) types.ConnectionConfig {
+	f.skip("key", "order")
+	conf.Addr = f.str("host")
+	if port := f.int("port"); port > 0 {
+		conf.Port = port
+	}
+	conf.Name = f.str("name")
+	if len(conf.Name) <= 0 {
+		conf.Name = importAddrName(conf.Addr, conf.Port)
+	}
+	conf.Username = f.str("username")
+	password := f.str("auth")
+	if sep := f.str("separator"); len(sep) > 0 {
+		conf.KeySeparator = sep
+	}
+	conf.Cluster.Enable = f.bool("cluster")
+
+	if ssh := f.sub("sshOptions"); ssh != nil && len(ssh.str("host")) > 0 {
+		conf.SSH.Enable = true
+		conf.SSH.Addr = ssh.str("host")
+		conf.SSH.Port = ssh.int("port")
+		if conf.SSH.Port <= 0 {
+			conf.SSH.Port = 22
+		}
+		conf.SSH.Username = ssh.str("username")
+		conf.SSH.Password = ssh.str("password")
+		conf.SSH.PKFile = ssh.str("privatekey")
+		conf.SSH.Passphrase = ssh.str("passphrase")
+		ssh.skip("privatekeybookmark")
+		if len(conf.SSH.PKFile) > 0 {
+			conf.SSH.LoginType = "pkfile"
+		} else {
+			conf.SSH.LoginType = "pwd"
+		}
+	}
+
+	if ssl := f.sub("sslOptions"); ssl != nil {
+		conf.SSL.Enable = true
+		conf.SSL.KeyFile = ssl.str("key")
+		conf.SSL.CertFile = ssl.str("cert")
+		conf.SSL.CAFile = ssl.str("ca")
+		conf.SSL.SNI = ssl.str("servername")
+		ssl.skip("keybookmark", "certbookmark", "cabookmark")
+	}
+
+	// "auth" is password of sentinel, which is the top level password of connection,
+	// password of redis master is set separately
+	conf.Password = password
+	if sentinel := f.sub("sentinelOptions"); sentinel != nil && len(sentinel.str("masterName")) > 0 {
+		conf.Sentinel.Enable = true
+		conf.Sentinel.Master = sentinel.str("masterName")
+		conf.Sentinel.Password = sentinel.str("nodePassword")
+	}
+	return conf
+}
+
+// parseRESPConnection map connection of RESP.app (RedisDesktopManager)
+func parseRESPConnection(f *importFields, conf types.ConnectionConfig) types.ConnectionConfig {
+	conf.Addr = f.str("host")
+	if port := f.int("port"); port > 0 {
+		conf.Port = port
+	}
+	conf.Name = f.str("name")
+	if len(conf.Name) <= 0 {
+		conf.Name = importAddrName(conf.Addr, conf.Port)
+	}
+	conf.Username = f.str("username")
+	conf.Password = f.str("auth")
+	if sep := f.str("namespace_separator"); len(sep) > 0 {
+		conf.KeySeparator = sep
+	}
+	if pattern := f.str("keys_pattern"); len(pattern) > 0 {
+		conf.DefaultFilter = pattern
+	}
+	// timeouts are in milliseconds
+	if timeout := f.int("timeout_connect"); timeout > 0 {
+		conf.ConnTimeout = max(timeout/1000, 1)
+	}
+	if timeout := f.int("timeout_execute"); timeout > 0 {
+		conf.ExecTimeout = max(timeout/1000, 1)
+	}
+	f.skip("iconColor")
+
+	if f.bool("ssl") {
+		conf.SSL.Enable = true
+		conf.SSL.CAFile = f.str("ssl_ca_cert_path")
+		conf.SSL.CertFile = f.str("ssl_local_cert_path")
+		conf.SSL.KeyFile = f.str("ssl_private_key_path")
+	}
+
+	if sshHost := f.str("ssh_host"); len(sshHost) > 0 {
+		conf.SSH.Enable = true
+		conf.SSH.Addr = sshHost
+		conf.SSH.Port = f.int("ssh_port")
+		if conf.SSH.Port <= 0 {
+			conf.SSH.Port = 22
+		}
+		conf.SSH.Username = f.str("ssh_user")
+		password := f.str("ssh_password")
+		if f.bool("ssh_agent") {
+			conf.SSH.LoginType = "agent"
+			f.skip("ssh_agent_path")
+		} else if pkFile := f.str("ssh_private_key_path"); len(pkFile) > 0 {
+			// password is used as passphrase of private key
+			conf.SSH.LoginType = "pkfile"
+			conf.SSH.PKFile = pkFile
+			conf.SSH.Passphrase = password
+		} else {
+			conf.SSH.LoginType = "pwd"
+			conf.SSH.Password = password
+		}
+	}
+	return conf
+}
+
+// parseRedisInsightConnection map database of Redis Insight
+func parseRedisInsightConnection(f *importFields, conf types.ConnectionConfig) types.ConnectionConfig {
+	f.skip("id", "provider", "nameFromProvider", "lastConnection", "modules", "version", "new", "createdAt")
+	conf.Addr = f.str("host")
+	if port := f.int("port"); port > 0 {
+		conf.Port = port
+	}
+	conf.Name = f.str("name")
+	if len(conf.Name) <= 0 {
+		conf.Name = importAddrName(conf.Addr, conf.Port)
+	}
+	conf.LastDB = f.int("db")
+	if timeout := f.int("timeout"); timeout > 0 {
+		conf.ConnTimeout = max(timeout/1000, 1)
+	}
+	username, password := f.str("username"), f.str("password")
+
+	switch strings.ToUpper(f.str("connectionType")) {
+	case "CLUSTER":
+		conf.Cluster.Enable = true
+		// cluster nodes are discovered from the seed node
+		f.skip("nodes")
+	case "SENTINEL":
+		conf.Sentinel.Enable = true
+	}
+
+	// top level credentials belong to sentinel as well as connection, the master ones are in "sentinelMaster"
+	conf.Username, conf.Password = username, password
+	if master := f.sub("sentinelMaster"); conf.Sentinel.Enable && master != nil {
+		if name := master.str("name"); len(name) > 0 {
+			conf.Sentinel.Master = name
+		}
+		conf.Sentinel.Username, conf.Sentinel.Password = master.str("username"), master.str("password")
+	}
+
+	if f.bool("tls") {
+		conf.SSL.Enable = true
+		conf.SSL.SNI = f.str("tlsServername")
+		if f.has("verifyServerCert") {
+			conf.SSL.AllowInsecure = !f.bool("verifyServerCert")
+		}
+		// certificates are embedded as pem content, only names can be mapped
+		if ca := f.sub("caCert"); ca != nil {
+			ca.skip("id", "name")
+		}
+		if cert := f.sub("clientCert"); cert != nil {
+			cert.skip("id", "name")
+		}
+	}
+
+	if f.bool("ssh") {
+		if ssh := f.sub("sshOptions"); ssh != nil {
+			ssh.skip("id")
+			conf.SSH.Enable = true
+			conf.SSH.Addr = ssh.str("host")
+			conf.SSH.Port = ssh.int("port")
+			if conf.SSH.Port <= 0 {
+				conf.SSH.Port = 22
+			}
+			conf.SSH.Username = ssh.str("username")
+			conf.SSH.Password = ssh.str("password")
+			// private key is embedded and could not map to key file,
+			// it's left as unmapped with its passphrase and key file should be chosen manually
+			conf.SSH.LoginType = "pwd"
+		}
+	}
+	return conf
+}
+
+func isRedisURLList(content []byte) bool {
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) <= 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "redis://") || strings.HasPrefix(line, "rediss://") ||
+			strings.HasPrefix(line, "unix://")
+	}
+	return false
+}
+
+// parseRedisURLs parse redis urls line by line, empty lines and lines start with "#" are ignored
+func parseRedisURLs(content []byte, defaultConf types.ConnectionConfig) (conns types.Connections,
+	unmapped map[string][]string, err error) {
+	names := map[string]bool{}
+	unmapped = map[string][]string{}
+	for i, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) <= 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		conf, missing, perr := parseRedisURL(line, defaultConf)
+		if perr != nil {
+			err = fmt.Errorf("line %d: %w", i+1, perr)
+			return
+		}
+		conf.Name = uniqueImportName(names, conf.Name)
+		conns = append(conns, types.Connection{ConnectionConfig: conf})
+		if len(missing) > 0 {
+			unmapped[conf.Name] = missing
+		}
+	}
+	if len(conns) <= 0 {
+		err = errors.New("no connection found")
+	}
+	return
+}
+
+func parseRedisURL(rawURL string, conf types.ConnectionConfig) (types.ConnectionConfig, []string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return conf, nil, err
+	}
+
+	query := u.Query()
+	switch u.Scheme {
+	case "redis", "rediss":
+		conf.Network = "tcp"
+		if host := u.Hostname(); len(host) > 0 {
+			conf.Addr = host
+		}
+		if port := u.Port(); len(port) > 0 {
+			if conf.Port, err = strconv.Atoi(port); err != nil {
+				return conf, nil, fmt.Errorf("invalid port: %s", port)
+			}
+		}
+		conf.Name = importAddrName(conf.Addr, conf.Port)
+		if db := strings.Trim(u.Path, "/"); len(db) > 0 {
+			if conf.LastDB, err = strconv.Atoi(db); err != nil {
+				return conf, nil, fmt.Errorf("invalid database number: %s", db)
+			}
+		}
+		if u.Scheme == "rediss" {
+			conf.SSL.Enable = true
+			conf.SSL.SNI = query.Get("servername")
+			conf.SSL.AllowInsecure, _ = strconv.ParseBool(query.Get("skip_verify"))
+			query.Del("servername")
+			query.Del("skip_verify")
+		}
+		// extra node addresses of cluster, nodes are discovered from the main address,
+		// seeds are reported as unmapped since only one address could be kept
+		if len(query["addr"]) > 0 {
+			conf.Cluster.Enable = true
+		}
+	case "unix":
+		conf.Network = "unix"
+		conf.Sock = u.Path
+		conf.Name = u.Path
+	default:
+		return conf, nil, fmt.Errorf("invalid url scheme: %s", u.Scheme)
+	}
+
+	if u.User != nil {
+		conf.Username = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			conf.Password = password
+		} else if len(conf.Username) > 0 {
+			// "redis://password@host" is accepted as password only
+			conf.Username, conf.Password = "", conf.Username
+		}
+	}
+	if db := query.Get("db"); len(db) > 0 {
+		if conf.LastDB, err = strconv.Atoi(db); err != nil {
+			return conf, nil, fmt.Errorf("invalid database number: %s", db)
+		}
+		query.Del("db")
+	}
+	if timeout, ok := parseURLTimeout(query.Get("dial_timeout")); ok {
+		conf.ConnTimeout = timeout
+		query.Del("dial_timeout")
+	}
+	if timeout, ok := parseURLTimeout(query.Get("read_timeout")); ok {
+		conf.ExecTimeout = timeout
+		query.Del("read_timeout")
+	}
+
+	var missing []string
+	for key := range query {
+		missing = append(missing, "query."+key)
+	}
+	if len(u.Fragment) > 0 {
+		missing = append(missing, "fragment")
+	}
+	sort.Strings(missing)
+	return conf, missing, nil
+}
+
+// parseURLTimeout parse duration like "5s" or number of seconds, round up to seconds
+func parseURLTimeout(val string) (int, bool) {
+	if len(val) <= 0 {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(val); err == nil {
+		return seconds, true
+	}
+	duration, err := time.ParseDuration(val)
+	if err != nil || duration <= 0 {
+		return 0, false
+	}
+	return int((duration + time.Second - 1) / time.Second), true
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"net"
+	"slices"
+	"strings"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// sentinelReplicas list replicas of master monitored by sentinel
+func (c *connectionService) sentinelReplicas(sentinel *redis.SentinelClient, master string) (replicas []types.ServerNode) {
+	infos, err := sentinel.Replicas(c.ctx, master).Result()
+	if err != nil {
+		return
+	}
+	for _, info := range infos {
+		if len(info["ip"]) <= 0 || len(info["port"]) <= 0 {
+			continue
+		}
+		flags := strings.Split(info["flags"], ",")
+		healthy := info["master-link-status"] == "ok" &&
+			!slices.ContainsFunc(flags, func(flag string) bool {
+				return flag == "s_down" || flag == "o_down" || flag == "disconnected"
+			})
+		replicas = append(replicas, types.ServerNode{
+			Addr:    net.JoinHostPort(info["ip"], info["port"]),
+			Role:    "replica",
+			Healthy: healthy,
+		})
+	}
+	return
+}
+
+// healthyAddrs returns address of healthy nodes
+func healthyAddrs(nodes []types.ServerNode) (addrs []string) {
+	for _, node := range nodes {
+		if node.Healthy {
+			addrs = append(addrs, node.Addr)
+		}
+	}
+	return
+}
+
+// createSentinelClient create client connect to sentinel node of connection, returns address of sentinel
+func (c *connectionService) createSentinelClient(config types.ConnectionConfig) (*redis.SentinelClient, string, error) {
+	option, err := c.buildOption(config)
+	if err != nil {
+		return nil, "", err
+	}
+	return redis.NewSentinelClient(option), option.Addr, nil
+}
+
+// listSentinelNodes list master and replicas monitored by sentinel
+func (c *connectionService) listSentinelNodes(config types.ConnectionConfig) ([]types.ServerNode, error) {
+	sentinel, _, err := c.createSentinelClient(config)
+	if err != nil {
+		return nil, err
+	}
+	defer sentinel.Close()
+
+	addr, err := sentinel.GetMasterAddrByName(c.ctx, config.Sentinel.Master).Result()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []types.ServerNode
+	if len(addr) >= 2 {
+		nodes = append(nodes, types.ServerNode{
+			Addr:    net.JoinHostPort(addr[0], addr[1]),
+			Role:    "master",
+			Healthy: true,
+		})
+	}
+	nodes = append(nodes, c.sentinelReplicas(sentinel, config.Sentinel.Master)...)
+	return nodes, nil
+}
+
+// createNodeClient create client connect to specified node directly with options of connection,
+// readonly is enabled for replicas of cluster
+func (c *connectionService) createNodeClient(config types.ConnectionConfig, addr string) (*redis.Client, error) {
+	option, err := c.buildOption(config)
+	if err != nil {
+		return nil, err
+	}
+
+	option.Addr = addr
+	if config.Sentinel.Enable {
+		option.Username = config.Sentinel.Username
+		option.Password = config.Sentinel.Password
+	}
+	if config.Cluster.Enable {
+		option.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+			return cn.ReadOnly(ctx).Err()
+		}
+	} else if config.LastDB > 0 {
+		option.DB = config.LastDB
+	}
+	return redis.NewClient(option), nil
+}
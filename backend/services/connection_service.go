@@ -1,30 +1,29 @@
 package services
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
+	"math/rand"
 	"net"
 	"net/url"
 	"os"
-	"path"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
-	"tinyrdm/backend/consts"
 	. "tinyrdm/backend/storage"
 	"tinyrdm/backend/types"
 	_ "tinyrdm/backend/utils/proxy"
 
+	"github.com/google/uuid"
 	"github.com/klauspost/compress/zip"
 	"github.com/redis/go-redis/v9"
-	"github.com/vrischmann/userdir"
-	sshagent "github.com/xanzy/ssh-agent"
-	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/proxy"
+	"gopkg.in/yaml.v3"
 )
 
 type cmdHistoryItem struct {
@@ -35,8 +34,25 @@ type cmdHistoryItem struct {
 }
 
 type connectionService struct {
-	ctx   context.Context
-	conns *ConnectionsStorage
+	ctx        context.Context
+	conns      *ConnectionsStorage
+	knownHosts *KnownHostsStorage
+	tunnels    *sshTunnelManager
+
+	pendingImports map[string]*pendingImport // key by token
+	importMutex    sync.Mutex
+}
+
+// pendingImportTTL pending import is discarded if not applied in time
+const pendingImportTTL = 30 * time.Minute
+
+// pendingImport connections parsed from imported file, waiting for confirmation of collisions
+type pendingImport struct {
+	conns    types.Connections
+	secrets  []byte // encrypted secrets bundle
+	source   string
+	unmapped map[string][]string
+	created  time.Time
 }
 
 var connection *connectionService
@@ -46,8 +62,11 @@ func Connection() *connectionService {
 	if connection == nil {
 		onceConnection.Do(func() {
 			connection = &connectionService{
-				conns: NewConnections(),
+				conns:          NewConnections(),
+				knownHosts:     NewKnownHosts(),
+				pendingImports: map[string]*pendingImport{},
 			}
+			connection.tunnels = newSSHTunnelManager(connection.onTunnelStatus)
 		})
 	}
 	return connection
@@ -55,6 +74,15 @@ func Connection() *connectionService {
 
 func (c *connectionService) Start(ctx context.Context) {
 	c.ctx = ctx
+	// move plaintext secrets of existing profile to secret storage
+	c.conns.MigrateSecrets()
+}
+
+// onTunnelStatus notify frontend when status of ssh tunnel changed
+func (c *connectionService) onTunnelStatus(status types.SSHTunnelStatus) {
+	if c.ctx != nil {
+		EventsEmit(c.ctx, "ssh_tunnel:status", status)
+	}
 }
 
 func (c *connectionService) buildOption(config types.ConnectionConfig) (*redis.Options, error) {
@@ -82,75 +110,12 @@ func (c *connectionService) buildOption(config types.ConnectionConfig) (*redis.O
 		}
 	}
 
-	var sshConfig *ssh.ClientConfig
-	var sshAddr string
-	if config.SSH.Enable {
-		sshConfig = &ssh.ClientConfig{
-			User:            config.SSH.Username,
-			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-			Timeout:         time.Duration(config.ConnTimeout) * time.Second,
-		}
-		switch config.SSH.LoginType {
-		case "pwd":
-			sshConfig.Auth = []ssh.AuthMethod{ssh.Password(config.SSH.Password)}
-		case "pkfile":
-			key, err := os.ReadFile(config.SSH.PKFile)
-			if err != nil {
-				return nil, err
-			}
-			var signer ssh.Signer
-			if len(config.SSH.Passphrase) > 0 {
-				signer, err = ssh.ParsePrivateKeyWithPassphrase(key, []byte(config.SSH.Passphrase))
-			} else {
-				signer, err = ssh.ParsePrivateKey(key)
-			}
-			if err != nil {
-				return nil, err
-			}
-			sshConfig.Auth = []ssh.AuthMethod{ssh.PublicKeys(signer)}
-		case "agent":
-			agent, conn, err := sshagent.New()
-			if err != nil {
-				return nil, err
-			}
-			if conn != nil {
-				defer conn.Close()
-			}
-			sshConfig.Auth = []ssh.AuthMethod{ssh.PublicKeysCallback(agent.Signers)}
-		default:
-			return nil, errors.New("invalid login type")
-		}
-
-		sshAddr = net.JoinHostPort(config.SSH.Addr, strconv.Itoa(config.SSH.Port))
-	}
-
 	var tlsConfig *tls.Config
 	if config.SSL.Enable {
 		// setup tls config
-		var certs []tls.Certificate
-		if len(config.SSL.CertFile) > 0 && len(config.SSL.KeyFile) > 0 {
-			if cert, err := tls.LoadX509KeyPair(config.SSL.CertFile, config.SSL.KeyFile); err != nil {
-				return nil, err
-			} else {
-				certs = []tls.Certificate{cert}
-			}
-		}
-
-		var caCertPool *x509.CertPool
-		if len(config.SSL.CAFile) > 0 {
-			ca, err := os.ReadFile(config.SSL.CAFile)
-			if err != nil {
-				return nil, err
-			}
-			caCertPool = x509.NewCertPool()
-			caCertPool.AppendCertsFromPEM(ca)
-		}
-
-		tlsConfig = &tls.Config{
-			RootCAs:            caCertPool,
-			InsecureSkipVerify: config.SSL.AllowInsecure,
-			Certificates:       certs,
-			ServerName:         strings.TrimSpace(config.SSL.SNI),
+		var err error
+		if tlsConfig, err = c.buildTLSConfig(config.SSL); err != nil {
+			return nil, err
 		}
 	}
 
@@ -186,26 +151,12 @@ func (c *connectionService) buildOption(config types.ConnectionConfig) (*redis.O
 		}
 	}
 
-	if len(sshAddr) > 0 {
-		if dialer != nil {
-			// ssh with proxy
-			conn, err := dialer.Dial("tcp", sshAddr)
-			if err != nil {
-				return nil, err
-			}
-			sc, chans, reqs, err := ssh.NewClientConn(conn, sshAddr, sshConfig)
-			if err != nil {
-				return nil, err
-			}
-			dialer = ssh.NewClient(sc, chans, reqs)
-		} else {
-			// ssh without proxy
-			sshClient, err := ssh.Dial("tcp", sshAddr, sshConfig)
-			if err != nil {
-				return nil, err
-			}
-			dialer = sshClient
+	if config.SSH.Enable {
+		tunnel, err := c.tunnelDialer(config, dialer)
+		if err != nil {
+			return nil, err
 		}
+		dialer = tunnel
 	}
 	if dialer != nil {
 		dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -217,10 +168,9 @@ func (c *connectionService) buildOption(config types.ConnectionConfig) (*redis.O
 			option.Dialer = func(ctx context.Context, network, addr string) (net.Conn, error) {
 				rawConn, err := dial(ctx, network, addr)
 				if err != nil {
-					rawConn.Close()
 					return nil, err
 				}
-				tlsConn := tls.Client(rawConn, tlsConfig)
+				tlsConn := tls.Client(rawConn, tlsConfigForAddr(tlsConfig, addr))
 				if err = tlsConn.Handshake(); err != nil {
 					rawConn.Close()
 					return nil, err
@@ -258,6 +208,12 @@ func (c *connectionService) createRedisClient(config types.ConnectionConfig) (re
 			return nil, errors.New("cannot get master address")
 		}
 		option.Addr = net.JoinHostPort(addr[0], addr[1])
+		if config.Sentinel.ReplicaPreferred {
+			// fallback to master if no healthy replica
+			if replicas := healthyAddrs(c.sentinelReplicas(sentinel, config.Sentinel.Master)); len(replicas) > 0 {
+				option.Addr = replicas[rand.Intn(len(replicas))]
+			}
+		}
 		option.Username = config.Sentinel.Username
 		option.Password = config.Sentinel.Password
 		if option.Dialer != nil && config.SSH.Enable {
@@ -269,6 +225,19 @@ func (c *connectionService) createRedisClient(config types.ConnectionConfig) (re
 	if config.LastDB > 0 {
 		option.DB = config.LastDB
 	}
+	if Client().noEvictEnabled(config.Name) {
+		onConnect := option.OnConnect
+		option.OnConnect = func(ctx context.Context, cn *redis.Conn) error {
+			if onConnect != nil {
+				if err := onConnect(ctx, cn); err != nil {
+					return err
+				}
+			}
+			// not supported before redis 7.0, ignore error
+			cn.Do(ctx, "CLIENT", "NO-EVICT", "on")
+			return nil
+		}
+	}
 
 	rdb := redis.NewClient(option)
 	if config.Cluster.Enable {
@@ -280,9 +249,10 @@ func (c *connectionService) createRedisClient(config types.ConnectionConfig) (re
 			clusterOptions := &redis.ClusterOptions{
 				//NewClient:             nil,
 				//MaxRedirects:          0,
-				//RouteByLatency:        false,
-				//RouteRandomly:         false,
 				//ClusterSlots:          nil,
+				ReadOnly:              config.Cluster.ReadOnly,
+				RouteByLatency:        config.Cluster.RouteByLatency,
+				RouteRandomly:         config.Cluster.RouteRandomly,
 				Dialer:                option.Dialer,
 				OnConnect:             option.OnConnect,
 				Protocol:              option.Protocol,
@@ -363,6 +333,7 @@ func (c *connectionService) TestConnection(config types.ConnectionConfig) (resp
 	client, err := c.createRedisClient(config)
 	if err != nil {
 		resp.Msg = err.Error()
+		resp.Data = hostKeyErrorData(err)
 		return
 	}
 	defer client.Close()
@@ -375,6 +346,27 @@ func (c *connectionService) TestConnection(config types.ConnectionConfig) (resp
 	return
 }
 
+// hostKeyErrorData returns detail of unknown or changed ssh host key for confirmation
+func hostKeyErrorData(err error) any {
+	var hostKeyErr *HostKeyError
+	if errors.As(err, &hostKeyErr) {
+		return map[string]any{
+			"hostKey": hostKeyErr,
+		}
+	}
+	return nil
+}
+
+// TrustSSHHostKey trust unknown ssh host key on first use, which is appended to known_hosts managed by app
+func (c *connectionService) TrustSSHHostKey(host, fingerprint string) (resp types.JSResp) {
+	if err := c.knownHosts.Trust(host, fingerprint); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
 // ListConnection list all saved connection in local profile
 func (c *connectionService) ListConnection() (resp types.JSResp) {
 	resp.Success = true
@@ -382,14 +374,26 @@ func (c *connectionService) ListConnection() (resp types.JSResp) {
 	return
 }
 
-func (c *connectionService) getConnection(name string) *types.Connection {
-	return c.conns.GetConnection(name)
+// getConnection get connection with secrets filled, error is returned if not found or secrets could not be read
+func (c *connectionService) getConnection(name string) (*types.Connection, error) {
+	conn, err := c.conns.GetConnection(name)
+	if err != nil {
+		return nil, err
+	}
+	if conn == nil {
+		return nil, fmt.Errorf("no match connection \"%s\"", name)
+	}
+	return conn, nil
 }
 
 // GetConnection get connection profile by name
 func (c *connectionService) GetConnection(name string) (resp types.JSResp) {
-	conn := c.getConnection(name)
-	resp.Success = conn != nil
+	conn, err := c.getConnection(name)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
 	resp.Data = conn
 	return
 }
@@ -472,18 +476,35 @@ func (c *connectionService) DeleteGroup(name string, includeConn bool) (resp typ
 
 // SaveLastDB save last selected database index
 func (c *connectionService) SaveLastDB(name string, db int) (resp types.JSResp) {
-	param := c.conns.GetConnection(name)
-	if param == nil {
-		resp.Msg = "no connection named \"" + name + "\""
+	// secrets are not read or written, so that they are kept even if secret storage is unavailable
+	err := c.conns.UpdateConnectionProfile(name, func(conf *types.ConnectionConfig) bool {
+		if conf.LastDB == db {
+			return false
+		}
+		conf.LastDB = db
+		return true
+	})
+	if err != nil {
+		resp.Msg = "save connection fail:" + err.Error()
 		return
 	}
+	resp.Success = true
+	return
+}
 
-	if param.LastDB != db {
-		param.LastDB = db
-		if err := c.conns.UpdateConnection(name, param.ConnectionConfig); err != nil {
-			resp.Msg = "save connection fail:" + err.Error()
-			return
+// SavePinnedNode save node which browser connect to directly, empty addr to unpin
+func (c *connectionService) SavePinnedNode(name, addr string) (resp types.JSResp) {
+	// secrets are not read or written, so that they are kept even if secret storage is unavailable
+	err := c.conns.UpdateConnectionProfile(name, func(conf *types.ConnectionConfig) bool {
+		if conf.PinnedNode == addr {
+			return false
 		}
+		conf.PinnedNode = addr
+		return true
+	})
+	if err != nil {
+		resp.Msg = "save connection fail:" + err.Error()
+		return
 	}
 	resp.Success = true
 	return
@@ -491,24 +512,115 @@ func (c *connectionService) SaveLastDB(name string, db int) (resp types.JSResp)
 
 // SaveRefreshInterval save auto refresh interval
 func (c *connectionService) SaveRefreshInterval(name string, interval int) (resp types.JSResp) {
-	param := c.conns.GetConnection(name)
-	if param == nil {
-		resp.Msg = "no connection named \"" + name + "\""
+	// secrets are not read or written, so that they are kept even if secret storage is unavailable
+	err := c.conns.UpdateConnectionProfile(name, func(conf *types.ConnectionConfig) bool {
+		if conf.RefreshInterval == interval {
+			return false
+		}
+		conf.RefreshInterval = interval
+		return true
+	})
+	if err != nil {
+		resp.Msg = "save connection fail:" + err.Error()
 		return
 	}
-	if param.RefreshInterval != interval {
-		param.RefreshInterval = interval
-		if err := c.conns.UpdateConnection(name, param.ConnectionConfig); err != nil {
-			resp.Msg = "save connection fail:" + err.Error()
-			return
-		}
+	resp.Success = true
+	return
+}
+
+// GetSecretStatus get storage mode of connection secrets and whether it's locked
+func (c *connectionService) GetSecretStatus() (resp types.JSResp) {
+	resp.Success = true
+	resp.Data = c.conns.SecretStatus()
+	return
+}
+
+// UnlockSecrets unlock saved secrets with master password
+func (c *connectionService) UnlockSecrets(password string) (resp types.JSResp) {
+	if err := c.conns.UnlockSecrets(password); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// LockSecrets lock saved secrets, master password is required before opening connection again
+func (c *connectionService) LockSecrets() (resp types.JSResp) {
+	c.conns.LockSecrets()
+	resp.Success = true
+	return
+}
+
+// SwitchSecretMode move saved secrets to system keyring or encrypt them with a new master password
+func (c *connectionService) SwitchSecretMode(mode, password string) (resp types.JSResp) {
+	if err := c.conns.SwitchSecretMode(mode, password); err != nil {
+		resp.Msg = err.Error()
+		return
 	}
 	resp.Success = true
 	return
 }
 
-// ExportConnections export connections to zip file
-func (c *connectionService) ExportConnections() (resp types.JSResp) {
+func (c *connectionService) secretsLocked() bool {
+	return c.conns.SecretsLocked()
+}
+
+const exportConnectionsFilename = "connections.yaml"
+const exportSecretsFilename = "secrets.yaml"
+
+// build zip content with selected connections, secrets are kept, stripped or encrypted with passphrase
+func (c *connectionService) buildExportZip(param types.ExportConnectionParam) ([]byte, error) {
+	conns, secrets, err := c.conns.ExportConnections(param.Names, param.Secret)
+	if err != nil {
+		return nil, err
+	}
+	if len(conns) <= 0 {
+		return nil, errors.New("no connection selected")
+	}
+
+	files := map[string][]byte{}
+	if param.Secret == types.EXPORT_SECRET_ENCRYPT {
+		if files[exportSecretsFilename], err = EncryptSecretsBundle(secrets, param.Passphrase); err != nil {
+			return nil, err
+		}
+	}
+	if files[exportConnectionsFilename], err = yaml.Marshal(&conns); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	for _, filename := range []string{exportConnectionsFilename, exportSecretsFilename} {
+		content, ok := files[filename]
+		if !ok {
+			continue
+		}
+		headerWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
+			Name:   filename,
+			Method: zip.Deflate,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = headerWriter.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err = zipWriter.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ExportConnections export selected connections to zip file
+func (c *connectionService) ExportConnections(param types.ExportConnectionParam) (resp types.JSResp) {
+	content, err := c.buildExportZip(param)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
 	defaultFileName := "connections_" + time.Now().Format("20060102150405") + ".zip"
 	filepath, err := SaveFileDialog(c.ctx, SaveDialogOptions{
 		ShowHiddenFiles: true,
@@ -523,56 +635,130 @@ func (c *connectionService) ExportConnections() (resp types.JSResp) {
 		resp.Msg = err.Error()
 		return
 	}
-
-	// compress the connections profile with zip
-	const connectionFilename = "connections.yaml"
-	inputFile, err := os.Open(path.Join(userdir.GetConfigHome(), consts.APP_DATA_FOLDER, connectionFilename))
-	if err != nil {
-		resp.Msg = err.Error()
+	if len(filepath) <= 0 {
 		return
 	}
-	defer inputFile.Close()
 
-	outputFile, err := os.Create(filepath)
-	if err != nil {
+	if err = os.WriteFile(filepath, content, 0600); err != nil {
 		resp.Msg = err.Error()
 		return
 	}
-	defer outputFile.Close()
 
-	zipWriter := zip.NewWriter(outputFile)
-	defer zipWriter.Close()
+	resp.Success = true
+	resp.Data = struct {
+		Path string `json:"path"`
+	}{
+		Path: filepath,
+	}
+	return
+}
+
+// parse exported zip file or connections from other clients, and keep it as pending import
+func (c *connectionService) prepareImport(data []byte) (preview types.ImportConnectionPreview, err error) {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		var pending pendingImport
+		pending.conns, pending.source, pending.unmapped, err = parseForeignConnections(data, c.conns.DefaultConnectionItem())
+		if err != nil {
+			return
+		}
+		preview = c.previewImport(pending)
+		preview.Token = c.addPendingImport(&pending)
+		return
+	}
 
-	headerWriter, err := zipWriter.CreateHeader(&zip.FileHeader{
-		Name:   connectionFilename,
-		Method: zip.Deflate,
-	})
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		resp.Msg = err.Error()
+		err = errors.New("invalid zip file")
 		return
 	}
 
-	if _, err = io.Copy(headerWriter, inputFile); err != nil {
-		resp.Msg = err.Error()
+	pending := pendingImport{source: IMPORT_SOURCE_TINYRDM}
+	var found bool
+	for _, file := range reader.File {
+		if file.Name != exportConnectionsFilename && file.Name != exportSecretsFilename {
+			continue
+		}
+		var zippedFile io.ReadCloser
+		if zippedFile, err = file.Open(); err != nil {
+			return
+		}
+		content, rerr := io.ReadAll(zippedFile)
+		zippedFile.Close()
+		if rerr != nil {
+			err = rerr
+			return
+		}
+		if file.Name == exportConnectionsFilename {
+			if err = yaml.Unmarshal(content, &pending.conns); err != nil {
+				return
+			}
+			found = true
+		} else {
+			pending.secrets = content
+		}
+	}
+	if !found {
+		err = errors.New(exportConnectionsFilename + " not found in zip")
 		return
 	}
 
-	resp.Success = true
-	resp.Data = struct {
-		Path string `json:"path"`
-	}{
-		Path: filepath,
+	preview = c.previewImport(pending)
+	preview.Token = c.addPendingImport(&pending)
+	return
+}
+
+// addPendingImport keep pending import until applied, returns token to identify it.
+// imports of multiple sessions could be pending at the same time in web mode
+func (c *connectionService) addPendingImport(pending *pendingImport) string {
+	c.importMutex.Lock()
+	defer c.importMutex.Unlock()
+	now := time.Now()
+	for token, p := range c.pendingImports {
+		if now.Sub(p.created) > pendingImportTTL {
+			delete(c.pendingImports, token)
+		}
 	}
+	token := uuid.NewString()
+	pending.created = now
+	c.pendingImports[token] = pending
+	return token
+}
+
+func (c *connectionService) previewImport(pending pendingImport) (preview types.ImportConnectionPreview) {
+	existing := map[string]bool{}
+	for _, conn := range c.conns.GetConnectionsFlat() {
+		existing[conn.Name] = true
+	}
+	preview.Connections = []string{}
+	preview.Collisions = []string{}
+	var walk func(types.Connections)
+	walk = func(cs types.Connections) {
+		for _, conn := range cs {
+			if conn.Type == "group" {
+				walk(conn.Connections)
+			} else {
+				preview.Connections = append(preview.Connections, conn.Name)
+				if existing[conn.Name] {
+					preview.Collisions = append(preview.Collisions, conn.Name)
+				}
+			}
+		}
+	}
+	walk(pending.conns)
+	preview.Encrypted = len(pending.secrets) > 0
+	preview.Source = pending.source
+	preview.Unmapped = pending.unmapped
 	return
 }
 
-// ImportConnections import connections from local zip file
+// ImportConnections choose a file to import, which could be exported by this app, other redis clients or a list of redis urls.
+// the connections are merged after confirmed by ApplyImportConnections
 func (c *connectionService) ImportConnections() (resp types.JSResp) {
 	filepath, err := OpenFileDialog(c.ctx, OpenDialogOptions{
 		ShowHiddenFiles: true,
 		Filters: []FileFilter{
 			{
-				Pattern: "*.zip",
+				Pattern: "*.zip;*.json;*.ardm;*.txt",
 			},
 		},
 	})
@@ -580,42 +766,58 @@ func (c *connectionService) ImportConnections() (resp types.JSResp) {
 		resp.Msg = err.Error()
 		return
 	}
+	if len(filepath) <= 0 {
+		return
+	}
 
-	const connectionFilename = "connections.yaml"
-	zipFile, err := zip.OpenReader(filepath)
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	preview, err := c.prepareImport(data)
 	if err != nil {
 		resp.Msg = err.Error()
 		return
 	}
+	resp.Success = true
+	resp.Data = preview
+	return
+}
 
-	var file *zip.File
-	for _, file = range zipFile.File {
-		if file.Name == connectionFilename {
-			break
-		}
+// ApplyImportConnections merge pending imported connections into local profile
+func (c *connectionService) ApplyImportConnections(param types.ImportConnectionParam) (resp types.JSResp) {
+	c.importMutex.Lock()
+	pending, ok := c.pendingImports[param.Token]
+	c.importMutex.Unlock()
+	if !ok || time.Since(pending.created) > pendingImportTTL {
+		resp.Msg = "no pending import"
+		return
 	}
-	if file != nil {
-		zippedFile, err := file.Open()
-		if err != nil {
-			resp.Msg = err.Error()
-			return
-		}
-		defer zippedFile.Close()
 
-		outputFile, err := os.Create(path.Join(userdir.GetConfigHome(), consts.APP_DATA_FOLDER, connectionFilename))
-		if err != nil {
-			resp.Msg = err.Error()
+	var secrets map[string]types.ConnectionSecrets
+	if len(pending.secrets) > 0 {
+		if len(param.Passphrase) <= 0 {
+			resp.Msg = "passphrase is required to import secrets"
 			return
 		}
-		defer outputFile.Close()
-
-		if _, err = io.Copy(outputFile, zippedFile); err != nil {
+		var err error
+		if secrets, err = DecryptSecretsBundle(pending.secrets, param.Passphrase); err != nil {
 			resp.Msg = err.Error()
 			return
 		}
 	}
 
+	result, err := c.conns.MergeConnections(pending.conns, secrets, param.Resolutions)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	c.importMutex.Lock()
+	delete(c.pendingImports, param.Token)
+	c.importMutex.Unlock()
 	resp.Success = true
+	resp.Data = result
 	return
 }
 
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+// closedPort returns a local port which nothing listens on
+func closedPort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+func TestBuildOptionDialError(t *testing.T) {
+	tests := []struct {
+		name string
+		ssl  bool
+	}{
+		{"plain", false},
+		{"tls", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := types.ConnectionConfig{
+				Addr:  "127.0.0.1",
+				Port:  6379,
+				SSL:   types.ConnectionSSL{Enable: tt.ssl},
+				Proxy: types.ConnectionProxy{Type: 2, Schema: "socks5", Addr: "127.0.0.1", Port: closedPort(t)},
+			}
+			c := &connectionService{}
+			option, err := c.buildOption(conf)
+			if err != nil {
+				t.Fatalf("buildOption() error: %v", err)
+			}
+			// dial error should be returned without touching the nil connection
+			conn, err := option.Dialer(context.Background(), "tcp", net.JoinHostPort(conf.Addr, strconv.Itoa(conf.Port)))
+			if err == nil || conn != nil {
+				t.Errorf("Dialer() = %v, %v, want error", conn, err)
+			}
+		})
+	}
+}
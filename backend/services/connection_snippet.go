@@ -0,0 +1,463 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"tinyrdm/backend/storage"
+	"tinyrdm/backend/types"
+)
+
+const maskedPassword = "******"
+
+// snippetConfig connection config normalized for generating snippets
+type snippetConfig struct {
+	types.ConnectionConfig
+	addr   string // host:port, or socket path for unix. local endpoint of tunnel if behind ssh server
+	remote string // host:port of redis server
+}
+
+func newSnippetConfig(conf types.ConnectionConfig, maskPassword bool) snippetConfig {
+	if maskPassword {
+		for _, pwd := range []*string{&conf.Password, &conf.Sentinel.Password} {
+			if len(*pwd) > 0 {
+				*pwd = maskedPassword
+			}
+		}
+	}
+	ret := snippetConfig{ConnectionConfig: conf}
+	if conf.Network == "unix" {
+		ret.addr = conf.Sock
+	} else {
+		if len(ret.Addr) <= 0 {
+			ret.Addr = "127.0.0.1"
+		}
+		if ret.Port <= 0 {
+			ret.Port = 6379
+		}
+		ret.remote = net.JoinHostPort(ret.Addr, strconv.Itoa(ret.Port))
+		ret.addr = ret.remote
+		if conf.SSH.Enable {
+			// connect to local port of ssh tunnel
+			ret.Addr = "127.0.0.1"
+			ret.addr = net.JoinHostPort(ret.Addr, strconv.Itoa(ret.Port))
+		}
+	}
+	return ret
+}
+
+// nodeAuth returns credentials of redis node. top level credentials belong to sentinel
+// in sentinel mode, and the master ones are set separately
+func (s snippetConfig) nodeAuth() (username, password string) {
+	if s.Sentinel.Enable {
+		return s.Sentinel.Username, s.Sentinel.Password
+	}
+	return s.Username, s.Password
+}
+
+func (s snippetConfig) unix() bool {
+	return s.Network == "unix"
+}
+
+// sshHint returns hint of ssh tunnel lines, which maps remote redis address to the same local port
+func (s snippetConfig) sshHint(comment string) string {
+	if !s.SSH.Enable || s.unix() {
+		return ""
+	}
+	args := []string{"ssh", "-N", "-L", fmt.Sprintf("%d:%s", s.Port, s.remote)}
+	if s.SSH.Port > 0 && s.SSH.Port != 22 {
+		args = append(args, "-p", strconv.Itoa(s.SSH.Port))
+	}
+	if s.SSH.LoginType == "pkfile" && len(s.SSH.PKFile) > 0 {
+		args = append(args, "-i", shellQuote(s.SSH.PKFile))
+	}
+	var jumps []string
+	for _, jump := range s.SSH.JumpHosts {
+		host := jump.Addr
+		if len(host) <= 0 {
+			host = jump.Alias
+		}
+		if len(jump.Username) > 0 {
+			host = jump.Username + "@" + host
+		}
+		if jump.Port > 0 && jump.Port != 22 {
+			host += ":" + strconv.Itoa(jump.Port)
+		}
+		jumps = append(jumps, host)
+	}
+	if len(jumps) > 0 {
+		args = append(args, "-J", shellQuote(strings.Join(jumps, ",")))
+	}
+	target := s.SSH.Addr
+	if len(target) <= 0 {
+		// host alias in ssh config
+		target = s.SSH.Alias
+	}
+	if len(s.SSH.Username) > 0 {
+		target = s.SSH.Username + "@" + target
+	}
+	args = append(args, shellQuote(target))
+	return fmt.Sprintf("%s redis is behind ssh server, open a tunnel first and connect to 127.0.0.1:%d\n%s %s\n",
+		comment, s.Port, comment, strings.Join(args, " "))
+}
+
+// buildConnectionURL generate redis url which could be parsed by ParseConnectURL
+func buildConnectionURL(s snippetConfig) string {
+	u := url.URL{Scheme: "redis"}
+	if s.SSL.Enable {
+		u.Scheme = "rediss"
+	}
+	// host is sentinel in sentinel mode, so are the credentials
+	if len(s.Username) > 0 || len(s.Password) > 0 {
+		u.User = url.UserPassword(s.Username, s.Password)
+	}
+	query := url.Values{}
+	if s.unix() {
+		u.Scheme = "unix"
+		u.Path = s.Sock
+		if s.LastDB > 0 {
+			query.Set("db", strconv.Itoa(s.LastDB))
+		}
+	} else {
+		u.Host = s.addr
+		if s.LastDB > 0 {
+			u.Path = "/" + strconv.Itoa(s.LastDB)
+		}
+	}
+	u.RawQuery = query.Encode()
+	// keep masked password readable
+	return strings.Replace(u.String(), url.PathEscape(maskedPassword), maskedPassword, 1)
+}
+
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9@%+=:,./_-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafePattern.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// buildCliCommand generate equivalent redis-cli invocation
+func buildCliCommand(s snippetConfig) string {
+	var sb strings.Builder
+	sb.WriteString(s.sshHint("#"))
+	if s.Sentinel.Enable {
+		sentinelArgs := []string{"redis-cli", "-h", shellQuote(s.Addr), "-p", strconv.Itoa(s.Port)}
+		if len(s.Username) > 0 {
+			sentinelArgs = append(sentinelArgs, "--user", shellQuote(s.Username))
+		}
+		if len(s.Password) > 0 {
+			sentinelArgs = append(sentinelArgs, "-a", shellQuote(s.Password))
+		}
+		sentinelArgs = append(sentinelArgs, "SENTINEL", "get-master-addr-by-name", shellQuote(s.Sentinel.Master))
+		sb.WriteString("# query address of master from sentinel, then replace <master-host> and <master-port>\n")
+		sb.WriteString(strings.Join(sentinelArgs, " "))
+		sb.WriteString("\n")
+	}
+
+	args := []string{"redis-cli"}
+	switch {
+	case s.unix():
+		args = append(args, "-s", shellQuote(s.Sock))
+	case s.Sentinel.Enable:
+		args = append(args, "-h", "<master-host>", "-p", "<master-port>")
+	default:
+		args = append(args, "-h", shellQuote(s.Addr), "-p", strconv.Itoa(s.Port))
+	}
+	username, password := s.nodeAuth()
+	if len(username) > 0 {
+		args = append(args, "--user", shellQuote(username))
+	}
+	if len(password) > 0 {
+		args = append(args, "-a", shellQuote(password))
+	}
+	if s.Cluster.Enable {
+		args = append(args, "-c")
+	} else if s.LastDB > 0 {
+		args = append(args, "-n", strconv.Itoa(s.LastDB))
+	}
+	if s.SSL.Enable {
+		args = append(args, "--tls")
+		if len(s.SSL.SNI) > 0 {
+			args = append(args, "--sni", shellQuote(s.SSL.SNI))
+		}
+		if len(s.SSL.CAFile) > 0 {
+			args = append(args, "--cacert", shellQuote(s.SSL.CAFile))
+		}
+		if len(s.SSL.CertFile) > 0 {
+			args = append(args, "--cert", shellQuote(s.SSL.CertFile))
+		}
+		if len(s.SSL.KeyFile) > 0 {
+			args = append(args, "--key", shellQuote(s.SSL.KeyFile))
+		}
+		if s.SSL.AllowInsecure {
+			args = append(args, "--insecure")
+		}
+	}
+	sb.WriteString(strings.Join(args, " "))
+	return sb.String()
+}
+
+// snippetFields write "key: value" lines of non-empty values with indent
+type snippetFields struct {
+	keys   []string
+	values []string
+	indent string
+	format string // format of each line with key and value
+	align  bool   // align values like gofmt
+}
+
+func (f *snippetFields) add(key, value string) {
+	f.keys = append(f.keys, key)
+	f.values = append(f.values, value)
+}
+
+func (f *snippetFields) addStr(key, value string, quote func(string) string) {
+	if len(value) > 0 {
+		f.add(key, quote(value))
+	}
+}
+
+func (f *snippetFields) empty() bool {
+	return len(f.keys) <= 0
+}
+
+func (f *snippetFields) lines() []string {
+	var width int
+	if f.align {
+		for _, key := range f.keys {
+			width = max(width, len(key))
+		}
+	}
+	lines := make([]string, len(f.keys))
+	for i, key := range f.keys {
+		format := f.format
+		if f.align {
+			format = strings.Replace(format, " ", strings.Repeat(" ", width-len(key)+1), 1)
+		}
+		lines[i] = f.indent + fmt.Sprintf(format, key, f.values[i])
+	}
+	return lines
+}
+
+func (f *snippetFields) String() string {
+	return strings.Join(f.lines(), "\n")
+}
+
+// buildGoRedisSnippet generate connection snippet for go-redis
+func buildGoRedisSnippet(s snippetConfig) string {
+	var sb strings.Builder
+	sb.WriteString(s.sshHint("//"))
+	var tlsCode string
+	if s.SSL.Enable {
+		tlsFields := snippetFields{indent: "\t\t", format: "%s: %s,", align: true}
+		tlsFields.addStr("ServerName", s.SSL.SNI, strconv.Quote)
+		if s.SSL.AllowInsecure {
+			tlsFields.add("InsecureSkipVerify", "true")
+		}
+		var files []string
+		for _, file := range []string{s.SSL.CAFile, s.SSL.CertFile, s.SSL.KeyFile} {
+			if len(file) > 0 {
+				files = append(files, strconv.Quote(file))
+			}
+		}
+		if len(files) > 0 {
+			sb.WriteString("// load RootCAs and Certificates of tls.Config from " + strings.Join(files, ", ") + "\n")
+		}
+		tlsCode = "&tls.Config{"
+		if !tlsFields.empty() {
+			tlsCode += "\n" + tlsFields.String() + "\n\t"
+		}
+		tlsCode += "}"
+	}
+
+	fields := snippetFields{indent: "\t", format: "%s: %s,", align: true}
+	var constructor string
+	switch {
+	case s.Sentinel.Enable:
+		constructor = "redis.NewFailoverClient(&redis.FailoverOptions{"
+		fields.addStr("MasterName", s.Sentinel.Master, strconv.Quote)
+		fields.add("SentinelAddrs", fmt.Sprintf("[]string{%q}", s.addr))
+		fields.addStr("SentinelUsername", s.Username, strconv.Quote)
+		fields.addStr("SentinelPassword", s.Password, strconv.Quote)
+	case s.Cluster.Enable:
+		constructor = "redis.NewClusterClient(&redis.ClusterOptions{"
+		fields.add("Addrs", fmt.Sprintf("[]string{%q}", s.addr))
+	default:
+		constructor = "redis.NewClient(&redis.Options{"
+		if s.unix() {
+			fields.add("Network", strconv.Quote("unix"))
+		}
+		fields.add("Addr", strconv.Quote(s.addr))
+	}
+	username, password := s.nodeAuth()
+	fields.addStr("Username", username, strconv.Quote)
+	fields.addStr("Password", password, strconv.Quote)
+	if !s.Cluster.Enable && s.LastDB > 0 {
+		fields.add("DB", strconv.Itoa(s.LastDB))
+	}
+	if len(tlsCode) > 0 {
+		fields.add("TLSConfig", tlsCode)
+	}
+
+	sb.WriteString("rdb := ")
+	sb.WriteString(constructor)
+	sb.WriteString("\n")
+	sb.WriteString(fields.String())
+	sb.WriteString("\n})")
+	return sb.String()
+}
+
+// buildRedisPySnippet generate connection snippet for redis-py
+func buildRedisPySnippet(s snippetConfig) string {
+	var sb strings.Builder
+	sb.WriteString(s.sshHint("#"))
+	fields := snippetFields{indent: "    ", format: "%s=%s,"}
+	username, password := s.nodeAuth()
+	fields.addStr("username", username, strconv.Quote)
+	fields.addStr("password", password, strconv.Quote)
+	if !s.Cluster.Enable && s.LastDB > 0 {
+		fields.add("db", strconv.Itoa(s.LastDB))
+	}
+	if s.SSL.Enable {
+		fields.add("ssl", "True")
+		fields.addStr("ssl_ca_certs", s.SSL.CAFile, strconv.Quote)
+		fields.addStr("ssl_certfile", s.SSL.CertFile, strconv.Quote)
+		fields.addStr("ssl_keyfile", s.SSL.KeyFile, strconv.Quote)
+		if s.SSL.AllowInsecure {
+			fields.add("ssl_cert_reqs", strconv.Quote("none"))
+		}
+	}
+
+	switch {
+	case s.Sentinel.Enable:
+		sb.WriteString("from redis.sentinel import Sentinel\n\n")
+		sentinelFields := snippetFields{indent: "    ", format: "%s=%s,"}
+		sentinelKwargs := snippetFields{format: "%s: %s"}
+		sentinelKwargs.addStr(strconv.Quote("username"), s.Username, strconv.Quote)
+		sentinelKwargs.addStr(strconv.Quote("password"), s.Password, strconv.Quote)
+		if !sentinelKwargs.empty() {
+			sentinelFields.add("sentinel_kwargs", "{"+strings.Join(sentinelKwargs.lines(), ", ")+"}")
+		}
+		sb.WriteString(fmt.Sprintf("sentinel = Sentinel(\n    [(%s, %d)],\n", strconv.Quote(s.Addr), s.Port))
+		if !sentinelFields.empty() {
+			sb.WriteString(sentinelFields.String() + "\n")
+		}
+		sb.WriteString(fmt.Sprintf(")\nr = sentinel.master_for(\n    %s,\n", strconv.Quote(s.Sentinel.Master)))
+	case s.Cluster.Enable:
+		sb.WriteString("from redis.cluster import RedisCluster\n\n")
+		sb.WriteString(fmt.Sprintf("r = RedisCluster(\n    host=%s,\n    port=%d,\n", strconv.Quote(s.Addr), s.Port))
+	default:
+		sb.WriteString("import redis\n\n")
+		if s.unix() {
+			sb.WriteString(fmt.Sprintf("r = redis.Redis(\n    unix_socket_path=%s,\n", strconv.Quote(s.Sock)))
+		} else {
+			sb.WriteString(fmt.Sprintf("r = redis.Redis(\n    host=%s,\n    port=%d,\n", strconv.Quote(s.Addr), s.Port))
+		}
+	}
+	if !fields.empty() {
+		sb.WriteString(fields.String() + "\n")
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+func jsQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// buildIORedisSnippet generate connection snippet for ioredis
+func buildIORedisSnippet(s snippetConfig) string {
+	var sb strings.Builder
+	sb.WriteString(s.sshHint("//"))
+	sb.WriteString("const Redis = require(\"ioredis\");\n")
+
+	indent := "    "
+	if s.Cluster.Enable {
+		indent = "        "
+	}
+	fields := snippetFields{indent: indent, format: "%s: %s,"}
+	switch {
+	case s.Sentinel.Enable:
+		fields.add("sentinels", fmt.Sprintf("[{ host: %s, port: %d }]", jsQuote(s.Addr), s.Port))
+		fields.addStr("name", s.Sentinel.Master, jsQuote)
+		fields.addStr("sentinelUsername", s.Username, jsQuote)
+		fields.addStr("sentinelPassword", s.Password, jsQuote)
+	case s.Cluster.Enable:
+	case s.unix():
+		fields.add("path", jsQuote(s.Sock))
+	default:
+		fields.add("host", jsQuote(s.Addr))
+		fields.add("port", strconv.Itoa(s.Port))
+	}
+	username, password := s.nodeAuth()
+	fields.addStr("username", username, jsQuote)
+	fields.addStr("password", password, jsQuote)
+	if !s.Cluster.Enable && s.LastDB > 0 {
+		fields.add("db", strconv.Itoa(s.LastDB))
+	}
+	if s.SSL.Enable {
+		tlsFields := snippetFields{format: "%s: %s"}
+		tlsFields.addStr("servername", s.SSL.SNI, jsQuote)
+		if s.SSL.AllowInsecure {
+			tlsFields.add("rejectUnauthorized", "false")
+		}
+		for _, file := range [][2]string{{"ca", s.SSL.CAFile}, {"cert", s.SSL.CertFile}, {"key", s.SSL.KeyFile}} {
+			if len(file[1]) > 0 {
+				tlsFields.add(file[0], fmt.Sprintf("fs.readFileSync(%s)", jsQuote(file[1])))
+			}
+		}
+		if len(s.SSL.CAFile) > 0 || len(s.SSL.CertFile) > 0 || len(s.SSL.KeyFile) > 0 {
+			sb.WriteString("const fs = require(\"fs\");\n")
+		}
+		if tlsFields.empty() {
+			fields.add("tls", "{}")
+		} else {
+			fields.add("tls", "{ "+strings.Join(tlsFields.lines(), ", ")+" }")
+		}
+	}
+	sb.WriteString("\n")
+
+	if s.Cluster.Enable {
+		sb.WriteString(fmt.Sprintf("const redis = new Redis.Cluster([{ host: %s, port: %d }], {\n", jsQuote(s.Addr), s.Port))
+		if !fields.empty() {
+			sb.WriteString("    redisOptions: {\n" + fields.String() + "\n    },\n")
+		}
+		sb.WriteString("});")
+	} else {
+		sb.WriteString("const redis = new Redis({\n")
+		sb.WriteString(fields.String())
+		sb.WriteString("\n});")
+	}
+	return sb.String()
+}
+
+// GetConnectionSnippets generate url, redis-cli command and client snippets of saved connection
+func (c *connectionService) GetConnectionSnippets(name string, maskPassword bool) (resp types.JSResp) {
+	if c.secretsLocked() {
+		resp.Msg = storage.ErrSecretsLocked.Error()
+		return
+	}
+	conn, err := c.getConnection(name)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	s := newSnippetConfig(conn.ConnectionConfig, maskPassword)
+	resp.Success = true
+	resp.Data = types.ConnectionSnippets{
+		URL:     buildConnectionURL(s),
+		Cli:     buildCliCommand(s),
+		GoRedis: buildGoRedisSnippet(s),
+		RedisPy: buildRedisPySnippet(s),
+		IORedis: buildIORedisSnippet(s),
+	}
+	return
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+	"tinyrdm/backend/types"
+	sshutil "tinyrdm/backend/utils/ssh"
+
+	sshagent "github.com/xanzy/ssh-agent"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/net/proxy"
+)
+
+// sshEndpoint ssh server or jump host with options inherited from ssh config
+type sshEndpoint struct {
+	types.SSHJumpHost
+	identityFiles []string
+	proxyJump     string
+}
+
+// sshHop one ssh server to connect in chain
+type sshHop struct {
+	addr   string
+	config *ssh.ClientConfig
+}
+
+// resolve options of host alias in ssh config, options set explicitly take precedence.
+// options of wildcard hosts are applied if not required to be found
+func (e *sshEndpoint) resolveAlias(required bool) error {
+	if len(e.Alias) <= 0 {
+		return nil
+	}
+	hostConfig, found := sshutil.LookupHost(e.Alias)
+	if !found && required {
+		return fmt.Errorf("host \"%s\" not found in %s", e.Alias, sshutil.ConfigPath())
+	}
+	if len(e.Addr) <= 0 {
+		e.Addr = hostConfig.HostName
+	}
+	if e.Port <= 0 {
+		e.Port = hostConfig.Port
+	}
+	if len(e.Username) <= 0 {
+		e.Username = hostConfig.User
+	}
+	e.identityFiles = hostConfig.IdentityFiles
+	e.proxyJump = hostConfig.ProxyJump
+	return nil
+}
+
+func (e *sshEndpoint) addr() string {
+	port := e.Port
+	if port <= 0 {
+		port = 22
+	}
+	return net.JoinHostPort(e.Addr, strconv.Itoa(port))
+}
+
+func readSigner(pkFile, passphrase string) (ssh.Signer, error) {
+	key, err := os.ReadFile(pkFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(passphrase) > 0 {
+		return ssh.ParsePrivateKeyWithPassphrase(key, []byte(passphrase))
+	}
+	return ssh.ParsePrivateKey(key)
+}
+
+// authMethods build auth methods by login type, the closer should be closed after handshake.
+// identity files from ssh config and agent are tried if login type is not specified
+func (e *sshEndpoint) authMethods() (methods []ssh.AuthMethod, closer io.Closer, err error) {
+	switch e.LoginType {
+	case "pwd":
+		methods = []ssh.AuthMethod{ssh.Password(e.Password)}
+	case "pkfile":
+		pkFile := e.PKFile
+		if len(pkFile) <= 0 && len(e.identityFiles) > 0 {
+			pkFile = e.identityFiles[0]
+		}
+		var signer ssh.Signer
+		if signer, err = readSigner(pkFile, e.Passphrase); err != nil {
+			return
+		}
+		methods = []ssh.AuthMethod{ssh.PublicKeys(signer)}
+	case "agent":
+		agent, conn, aerr := sshagent.New()
+		if aerr != nil {
+			err = aerr
+			return
+		}
+		methods, closer = []ssh.AuthMethod{ssh.PublicKeysCallback(agent.Signers)}, conn
+	case "":
+		var signers []ssh.Signer
+		for _, file := range e.identityFiles {
+			if signer, serr := readSigner(file, e.Passphrase); serr == nil {
+				signers = append(signers, signer)
+			}
+		}
+		if len(signers) > 0 {
+			methods = append(methods, ssh.PublicKeys(signers...))
+		}
+		if agent, conn, aerr := sshagent.New(); aerr == nil {
+			methods, closer = append(methods, ssh.PublicKeysCallback(agent.Signers)), conn
+		}
+		if len(methods) <= 0 {
+			err = fmt.Errorf("no available auth method for ssh server %s", e.addr())
+		}
+	default:
+		err = errors.New("invalid login type")
+	}
+	return
+}
+
+// buildSSHHops resolve jump hosts and ssh server in connecting order.
+// jump hosts set in connection take precedence over ProxyJump in ssh config
+func (c *connectionService) buildSSHHops(config types.ConnectionSSH, timeout time.Duration) (hops []sshHop, closers []io.Closer, err error) {
+	target := sshEndpoint{
+		SSHJumpHost: types.SSHJumpHost{
+			Alias:      config.Alias,
+			Addr:       config.Addr,
+			Port:       config.Port,
+			LoginType:  config.LoginType,
+			Username:   config.Username,
+			Password:   config.Password,
+			PKFile:     config.PKFile,
+			Passphrase: config.Passphrase,
+		},
+	}
+	if err = target.resolveAlias(true); err != nil {
+		return
+	}
+
+	var endpoints []sshEndpoint
+	if len(config.JumpHosts) > 0 {
+		for _, jump := range config.JumpHosts {
+			endpoint := sshEndpoint{SSHJumpHost: jump}
+			if err = endpoint.resolveAlias(true); err != nil {
+				return
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	} else if len(target.proxyJump) > 0 {
+		var jumps []sshutil.JumpHost
+		if jumps, err = sshutil.ResolveProxyJump(config.Alias, target.proxyJump); err != nil {
+			return
+		}
+		for _, jump := range jumps {
+			// jump host could be an alias too
+			endpoint := sshEndpoint{SSHJumpHost: types.SSHJumpHost{Alias: jump.Host}}
+			endpoint.resolveAlias(false)
+			if len(jump.User) > 0 {
+				endpoint.Username = jump.User
+			}
+			if jump.Port > 0 {
+				endpoint.Port = jump.Port
+			}
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	endpoints = append(endpoints, target)
+
+	var localUser string
+	if u, uerr := user.Current(); uerr == nil {
+		localUser = u.Username
+	}
+	for _, endpoint := range endpoints {
+		if len(endpoint.Addr) <= 0 {
+			err = errors.New("ssh server address is required")
+			break
+		}
+		methods, closer, aerr := endpoint.authMethods()
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+		if aerr != nil {
+			err = aerr
+			break
+		}
+		username := endpoint.Username
+		if len(username) <= 0 {
+			username = localUser
+		}
+		addr := endpoint.addr()
+		hops = append(hops, sshHop{
+			addr: addr,
+			config: &ssh.ClientConfig{
+				User:              username,
+				Auth:              methods,
+				HostKeyCallback:   c.knownHosts.HostKeyCallback(),
+				HostKeyAlgorithms: c.knownHosts.HostKeyAlgorithms(addr),
+				Timeout:           timeout,
+			},
+		})
+	}
+	if err != nil {
+		closeAll(closers)
+		closers = nil
+	}
+	return
+}
+
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		closer.Close()
+	}
+}
+
+// dialSSHHops connect ssh servers one by one, each through the previous one
+func dialSSHHops(dialer proxy.Dialer, hops []sshHop) (client *ssh.Client, err error) {
+	var clients []*ssh.Client
+	defer func() {
+		if err != nil {
+			for i := len(clients) - 1; i >= 0; i-- {
+				clients[i].Close()
+			}
+			client = nil
+		}
+	}()
+
+	for _, hop := range hops {
+		var conn net.Conn
+		switch {
+		case client != nil:
+			conn, err = client.Dial("tcp", hop.addr)
+		case dialer != nil:
+			conn, err = dialer.Dial("tcp", hop.addr)
+		default:
+			conn, err = net.DialTimeout("tcp", hop.addr, hop.config.Timeout)
+		}
+		if err != nil {
+			err = fmt.Errorf("connect to ssh server %s failed: %w", hop.addr, err)
+			return
+		}
+		sc, chans, reqs, cerr := ssh.NewClientConn(conn, hop.addr, hop.config)
+		if cerr != nil {
+			conn.Close()
+			err = fmt.Errorf("ssh server %s: %w", hop.addr, cerr)
+			return
+		}
+		client = ssh.NewClient(sc, chans, reqs)
+		clients = append(clients, client)
+	}
+	return
+}
+
+// tunnelDialer dial through tunnel of connection which is shared by browser, cli, monitor and pub/sub clients,
+// the tunnel is connected immediately to report errors like unknown host key
+func (c *connectionService) tunnelDialer(config types.ConnectionConfig, dialer proxy.Dialer) (proxy.Dialer, error) {
+	timeout := time.Duration(config.ConnTimeout) * time.Second
+	connect := func() (*ssh.Client, error) {
+		hops, closers, err := c.buildSSHHops(config.SSH, timeout)
+		// connections of ssh agent are only required during handshake
+		defer closeAll(closers)
+		if err != nil {
+			return nil, err
+		}
+		return dialSSHHops(dialer, hops)
+	}
+
+	// options which affect ssh connection
+	opts, _ := json.Marshal([]any{config.SSH, config.Proxy, config.ConnTimeout})
+	sum := sha256.Sum256(opts)
+	hash := hex.EncodeToString(sum[:8])
+	tunnel := c.tunnels.get(config.Name, hash, connect)
+	if _, err := tunnel.ensure(); err != nil {
+		return nil, err
+	}
+	return &tunnelDialer{
+		get: func() *sshTunnel {
+			// tunnel could be closed and removed after idle, get it again before each dial
+			return c.tunnels.get(config.Name, hash, connect)
+		},
+	}, nil
+}
+
+type tunnelDialer struct {
+	get func() *sshTunnel
+}
+
+func (d *tunnelDialer) Dial(network, addr string) (net.Conn, error) {
+	return d.get().Dial(network, addr)
+}
+
+// ListSSHTunnels list status of ssh tunnels in use
+func (c *connectionService) ListSSHTunnels() (resp types.JSResp) {
+	resp.Success = true
+	resp.Data = c.tunnels.List()
+	return
+}
+
+// ListSSHConfigHosts list host aliases in ~/.ssh/config
+func (c *connectionService) ListSSHConfigHosts() (resp types.JSResp) {
+	resp.Success = true
+	resp.Data = sshutil.ListHosts()
+	return
+}
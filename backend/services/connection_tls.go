@@ -0,0 +1,182 @@
+package services
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"tinyrdm/backend/types"
+	tlsutil "tinyrdm/backend/utils/tls"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// loadPEM load PEM content, content set inline take precedence over file
+func loadPEM(content, file string) ([]byte, error) {
+	if len(strings.TrimSpace(content)) > 0 {
+		return []byte(content), nil
+	}
+	if len(file) > 0 {
+		return os.ReadFile(file)
+	}
+	return nil, nil
+}
+
+// findCipherSuite find cipher suite by name, insecure ones are included
+func findCipherSuite(name string) (uint16, bool) {
+	name = strings.TrimSpace(name)
+	for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// spkiHash base64 encoded SHA-256 of subject public key info of certificate
+func spkiHash(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// tlsConfigForAddr set server name by host of addr if SNI is not specified,
+// it's required to verify certificate when connection is dialed through ssh tunnel or proxy
+func tlsConfigForAddr(tlsConfig *tls.Config, addr string) *tls.Config {
+	if len(tlsConfig.ServerName) > 0 {
+		return tlsConfig
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.ServerName = host
+	return tlsConfig
+}
+
+// buildTLSConfig build tls config with client certificate, CA, version and cipher suites policy and SPKI pins
+func (c *connectionService) buildTLSConfig(ssl types.ConnectionSSL) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: ssl.AllowInsecure,
+		ServerName:         strings.TrimSpace(ssl.SNI),
+	}
+
+	// client certificate
+	certPEM, err := loadPEM(ssl.Cert, ssl.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := loadPEM(ssl.Key, ssl.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(certPEM) > 0 && len(keyPEM) > 0 {
+		if keyPEM, err = tlsutil.DecryptPEMKey(keyPEM, ssl.KeyPassphrase); err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	// CA certificates
+	caPEM, err := loadPEM(ssl.CA, ssl.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	if len(caPEM) > 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid CA certificate found")
+		}
+	}
+
+	// protocol versions
+	if len(ssl.MinVersion) > 0 {
+		var ok bool
+		if tlsConfig.MinVersion, ok = tlsVersions[ssl.MinVersion]; !ok {
+			return nil, fmt.Errorf("unsupported TLS version \"%s\"", ssl.MinVersion)
+		}
+	}
+	if len(ssl.MaxVersion) > 0 {
+		var ok bool
+		if tlsConfig.MaxVersion, ok = tlsVersions[ssl.MaxVersion]; !ok {
+			return nil, fmt.Errorf("unsupported TLS version \"%s\"", ssl.MaxVersion)
+		}
+	}
+	if tlsConfig.MinVersion > 0 && tlsConfig.MaxVersion > 0 && tlsConfig.MinVersion > tlsConfig.MaxVersion {
+		return nil, errors.New("minimum TLS version is higher than maximum version")
+	}
+
+	// cipher suites, not configurable for TLS 1.3
+	for _, name := range ssl.CipherSuites {
+		id, ok := findCipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite \"%s\"", name)
+		}
+		tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+	}
+
+	// certificate pinning, pass if any certificate in verified chains matches.
+	// chain is not verified if insecure is allowed, only leaf certificate could be trusted
+	if len(ssl.PinnedSPKI) > 0 {
+		pins := make([]string, 0, len(ssl.PinnedSPKI))
+		for _, pin := range ssl.PinnedSPKI {
+			if pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/"); len(pin) > 0 {
+				pins = append(pins, pin)
+			}
+		}
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.VerifiedChains) > 0 {
+				for _, chain := range state.VerifiedChains {
+					for _, cert := range chain {
+						if slices.Contains(pins, spkiHash(cert)) {
+							return nil
+						}
+					}
+				}
+			} else if len(state.PeerCertificates) > 0 {
+				if slices.Contains(pins, spkiHash(state.PeerCertificates[0])) {
+					return nil
+				}
+			}
+			return errors.New("certificate of server does not match any pinned public key")
+		}
+	}
+	return tlsConfig, nil
+}
+
+// ListCipherSuites list names of cipher suites could be selected, insecure ones are marked
+func (c *connectionService) ListCipherSuites() (resp types.JSResp) {
+	type cipherSuite struct {
+		Name     string `json:"name"`
+		Insecure bool   `json:"insecure"`
+	}
+	var suites []cipherSuite
+	for _, suite := range tls.CipherSuites() {
+		if !slices.Contains(suite.SupportedVersions, tls.VersionTLS13) {
+			suites = append(suites, cipherSuite{Name: suite.Name})
+		}
+	}
+	for _, suite := range tls.InsecureCipherSuites() {
+		suites = append(suites, cipherSuite{Name: suite.Name, Insecure: true})
+	}
+	resp.Success = true
+	resp.Data = map[string]any{
+		"suites": suites,
+	}
+	return
+}
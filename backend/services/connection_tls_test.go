@@ -0,0 +1,147 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+	"tinyrdm/backend/types"
+)
+
+func TestTLSConfigForAddr(t *testing.T) {
+	tests := []struct {
+		sni  string
+		addr string
+		want string
+	}{
+		{"", "redis.local:6379", "redis.local"},
+		{"", "10.0.0.1:6380", "10.0.0.1"},
+		{"", "[::1]:6379", "::1"},
+		{"", "redis.local", "redis.local"},
+		{"sni.local", "10.0.0.1:6379", "sni.local"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			conf := &tls.Config{ServerName: tt.sni}
+			got := tlsConfigForAddr(conf, tt.addr)
+			if got.ServerName != tt.want {
+				t.Errorf("ServerName = %q, want %q", got.ServerName, tt.want)
+			}
+			if conf.ServerName != tt.sni {
+				t.Errorf("original config is modified: %q", conf.ServerName)
+			}
+		})
+	}
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		ssl     types.ConnectionSSL
+		wantErr bool
+	}{
+		{"default", types.ConnectionSSL{}, false},
+		{"versions", types.ConnectionSSL{MinVersion: "1.2", MaxVersion: "1.3"}, false},
+		{"unknown version", types.ConnectionSSL{MinVersion: "2.0"}, true},
+		{"min higher than max", types.ConnectionSSL{MinVersion: "1.3", MaxVersion: "1.2"}, true},
+		{"cipher suite", types.ConnectionSSL{CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}}, false},
+		{"unknown cipher suite", types.ConnectionSSL{CipherSuites: []string{"TLS_FOO"}}, true},
+		{"invalid CA", types.ConnectionSSL{CA: "not a certificate"}, true},
+	}
+	c := &connectionService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := c.buildTLSConfig(tt.ssl); (err != nil) != tt.wantErr {
+				t.Errorf("buildTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// newTestTLSServer start tls server with self-signed certificate of "localhost", returns port and certificate
+func newTestTLSServer(t *testing.T) (int, *x509.Certificate, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return listener.Addr().(*net.TCPAddr).Port, cert, string(certPEM)
+}
+
+// connection dialed by custom dialer(ssh tunnel or proxy) should be verified and pinned like direct one
+func TestTLSHandshakeByDialer(t *testing.T) {
+	port, cert, certPEM := newTestTLSServer(t)
+	tests := []struct {
+		name    string
+		ssl     types.ConnectionSSL
+		wantErr bool
+	}{
+		{"verified", types.ConnectionSSL{CA: certPEM}, false},
+		{"pinned", types.ConnectionSSL{CA: certPEM, PinnedSPKI: []string{"sha256/" + spkiHash(cert)}}, false},
+		{"pin mismatched", types.ConnectionSSL{CA: certPEM, PinnedSPKI: []string{"AAAA"}}, true},
+		{"sni mismatched", types.ConnectionSSL{CA: certPEM, SNI: "redis.local"}, true},
+		{"unknown CA", types.ConnectionSSL{}, true},
+	}
+	c := &connectionService{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conf, err := c.buildTLSConfig(tt.ssl)
+			if err != nil {
+				t.Fatalf("buildTLSConfig() error: %v", err)
+			}
+			// tunnel forwards to "localhost", which is dialed locally here
+			addr := net.JoinHostPort("localhost", strconv.Itoa(port))
+			rawConn, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rawConn.Close()
+			err = tls.Client(rawConn, tlsConfigForAddr(conf, addr)).Handshake()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Handshake() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
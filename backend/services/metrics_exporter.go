@@ -0,0 +1,324 @@
+//go:build web
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// exporterScrapeTimeout max duration to collect INFO of one exported connection,
+// an unreachable connection would not delay others
+const exporterScrapeTimeout = 10 * time.Second
+
+// exporterClient client to scrape connection, recreated after connection config changed.
+// scrapes may run concurrently, a dropped client is closed after released by all scrapes using it
+type exporterClient struct {
+	conf    types.ConnectionConfig
+	client  redis.UniversalClient
+	refs    int
+	dropped bool
+}
+
+// cached clients by connection name, the client of a connection no longer exported
+// is dropped by closeExporterClients after each scrape
+var exporterClients = map[string]*exporterClient{}
+var exporterMutex sync.Mutex
+
+// drop remove client from cache and close it if not in use, should be called with exporterMutex held
+func (c *exporterClient) drop() {
+	c.dropped = true
+	if c.refs <= 0 {
+		c.client.Close()
+	}
+}
+
+// release client after scraped
+func (c *exporterClient) release() {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	c.refs -= 1
+	if c.dropped && c.refs <= 0 {
+		c.client.Close()
+	}
+}
+
+// promInfoMetric INFO field exposed as prometheus metric
+type promInfoMetric struct {
+	section string
+	field   string
+	name    string
+	typ     string
+	help    string
+}
+
+var promInfoMetrics = []promInfoMetric{
+	{"Server", "uptime_in_seconds", "redis_uptime_seconds", "gauge", "Seconds since server started"},
+	{"Clients", "connected_clients", "redis_connected_clients", "gauge", "Number of client connections"},
+	{"Clients", "blocked_clients", "redis_blocked_clients", "gauge", "Number of clients pending on a blocking call"},
+	{"Memory", "used_memory", "redis_memory_used_bytes", "gauge", "Bytes allocated by allocator"},
+	{"Memory", "used_memory_rss", "redis_memory_used_rss_bytes", "gauge", "Bytes allocated as seen by operating system"},
+	{"Memory", "maxmemory", "redis_memory_max_bytes", "gauge", "Value of maxmemory configuration"},
+	{"Memory", "mem_fragmentation_ratio", "redis_mem_fragmentation_ratio", "gauge", "Ratio of used_memory_rss and used_memory"},
+	{"Stats", "instantaneous_ops_per_sec", "redis_instantaneous_ops_per_sec", "gauge", "Commands processed per second"},
+	{"Stats", "total_commands_processed", "redis_commands_processed_total", "counter", "Total commands processed"},
+	{"Stats", "total_connections_received", "redis_connections_received_total", "counter", "Total connections accepted"},
+	{"Stats", "rejected_connections", "redis_rejected_connections_total", "counter", "Connections rejected because of maxclients"},
+	{"Stats", "total_net_input_bytes", "redis_net_input_bytes_total", "counter", "Total bytes read from network"},
+	{"Stats", "total_net_output_bytes", "redis_net_output_bytes_total", "counter", "Total bytes written to network"},
+	{"Stats", "keyspace_hits", "redis_keyspace_hits_total", "counter", "Successful lookups of keys"},
+	{"Stats", "keyspace_misses", "redis_keyspace_misses_total", "counter", "Failed lookups of keys"},
+	{"Stats", "expired_keys", "redis_expired_keys_total", "counter", "Total keys expired"},
+	{"Stats", "evicted_keys", "redis_evicted_keys_total", "counter", "Total keys evicted because of maxmemory"},
+	{"Replication", "connected_slaves", "redis_connected_replicas", "gauge", "Number of connected replicas"},
+	{"Persistence", "rdb_changes_since_last_save", "redis_rdb_changes_since_last_save", "gauge", "Changes since last dump"},
+}
+
+type promSample struct {
+	name   string
+	labels []string // pairs of label name and value
+	value  float64
+}
+
+type promFamily struct {
+	name    string
+	typ     string
+	help    string
+	samples []promSample
+}
+
+// promWriter collect samples grouped by metric family, and write in prometheus text format
+type promWriter struct {
+	families []*promFamily
+	index    map[string]*promFamily
+	mutex    sync.Mutex
+}
+
+func newPromWriter() *promWriter {
+	return &promWriter{
+		index: map[string]*promFamily{},
+	}
+}
+
+// add append sample to family, sample name is the same as family except for histogram
+func (p *promWriter) add(family, typ, help, name string, value float64, labels ...string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	f, ok := p.index[family]
+	if !ok {
+		f = &promFamily{name: family, typ: typ, help: help}
+		p.families = append(p.families, f)
+		p.index[family] = f
+	}
+	f.samples = append(f.samples, promSample{name: name, labels: labels, value: value})
+}
+
+func (p *promWriter) gauge(name, help string, value float64, labels ...string) {
+	p.add(name, "gauge", help, name, value, labels...)
+}
+
+func (p *promWriter) writeTo(w io.Writer) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var sb strings.Builder
+	for _, f := range p.families {
+		sb.WriteString(fmt.Sprintf("# HELP %s %s\n", f.name, f.help))
+		sb.WriteString(fmt.Sprintf("# TYPE %s %s\n", f.name, f.typ))
+		for _, s := range f.samples {
+			sb.WriteString(s.name)
+			if len(s.labels) > 0 {
+				sb.WriteByte('{')
+				for i := 0; i+1 < len(s.labels); i += 2 {
+					if i > 0 {
+						sb.WriteByte(',')
+					}
+					sb.WriteString(fmt.Sprintf(`%s="%s"`, s.labels[i], escaper.Replace(s.labels[i+1])))
+				}
+				sb.WriteByte('}')
+			}
+			sb.WriteByte(' ')
+			sb.WriteString(strconv.FormatFloat(s.value, 'g', -1, 64))
+			sb.WriteByte('\n')
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// getExporterClient get client to scrape connection, the cached one is reused if config not changed.
+// client should be released after used
+func getExporterClient(conf types.ConnectionConfig) (*exporterClient, error) {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	if item, ok := exporterClients[conf.Name]; ok {
+		if reflect.DeepEqual(item.conf, conf) {
+			item.refs += 1
+			return item, nil
+		}
+		item.drop()
+		delete(exporterClients, conf.Name)
+	}
+
+	client, err := Connection().createRedisClient(conf)
+	if err != nil {
+		return nil, err
+	}
+	item := &exporterClient{conf: conf, client: client, refs: 1}
+	exporterClients[conf.Name] = item
+	return item, nil
+}
+
+// closeExporterClients drop cached clients of connections not in keep,
+// clients still used by other scrapes are closed after released
+func closeExporterClients(keep map[string]bool) {
+	exporterMutex.Lock()
+	defer exporterMutex.Unlock()
+	for name, item := range exporterClients {
+		if !keep[name] {
+			item.drop()
+			delete(exporterClients, name)
+		}
+	}
+}
+
+// scrapeConnection collect INFO of each node of connection
+func (m *metricsService) scrapeConnection(p *promWriter, conf types.ConnectionConfig) {
+	const upHelp = "Whether the node could be reached"
+	item, err := getExporterClient(conf)
+	if err != nil {
+		p.gauge("redis_up", upHelp, 0, "connection", conf.Name, "node", "")
+		return
+	}
+	defer item.release()
+	ctx, cancelFunc := context.WithTimeout(context.Background(), exporterScrapeTimeout)
+	defer cancelFunc()
+
+	err = forEachNode(item.client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		var node string
+		if c, ok := cli.(*redis.Client); ok {
+			node = c.Options().Addr
+		}
+		res, err := cli.Info(ctx).Result()
+		if err != nil {
+			p.gauge("redis_up", upHelp, 0, "connection", conf.Name, "node", node)
+			return nil
+		}
+		p.gauge("redis_up", upHelp, 1, "connection", conf.Name, "node", node)
+
+		info := Browser().parseInfo(res)
+		p.gauge("redis_instance_info", "Information of the node", 1,
+			"connection", conf.Name, "node", node,
+			"version", info["Server"]["redis_version"],
+			"mode", info["Server"]["redis_mode"],
+			"role", info["Replication"]["role"])
+		for _, metric := range promInfoMetrics {
+			if val, ok := info[metric.section][metric.field]; ok {
+				if n, err := strconv.ParseFloat(val, 64); err == nil {
+					p.add(metric.name, metric.typ, metric.help, metric.name, n, "connection", conf.Name, "node", node)
+				}
+			}
+		}
+		// keyspace is like "db0:keys=1,expires=0,avg_ttl=0"
+		dbs := make([]string, 0, len(info["Keyspace"]))
+		for db := range info["Keyspace"] {
+			dbs = append(dbs, db)
+		}
+		sort.Strings(dbs)
+		for _, db := range dbs {
+			for _, field := range strings.Split(info["Keyspace"][db], ",") {
+				if k, v, ok := strings.Cut(field, "="); ok {
+					n, _ := strconv.ParseFloat(v, 64)
+					switch k {
+					case "keys":
+						p.gauge("redis_db_keys", "Number of keys in database", n,
+							"connection", conf.Name, "node", node, "db", db)
+					case "expires":
+						p.gauge("redis_db_keys_expiring", "Number of keys with expiration in database", n,
+							"connection", conf.Name, "node", node, "db", db)
+					}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// fail to list nodes of cluster
+		p.gauge("redis_up", upHelp, 0, "connection", conf.Name, "node", "")
+	}
+}
+
+// writeAppMetrics write metrics of app itself
+func (m *metricsService) writeAppMetrics(p *promWriter) {
+	b := Browser()
+	b.mutex.Lock()
+	opened := len(b.connMap)
+	b.mutex.Unlock()
+	p.gauge("tinyrdm_open_connections", "Number of connections opened in browser", float64(opened))
+
+	m.latencyMutex.Lock()
+	defer m.latencyMutex.Unlock()
+	servers := make([]string, 0, len(m.latency))
+	for server := range m.latency {
+		servers = append(servers, server)
+	}
+	sort.Strings(servers)
+	const family, help = "tinyrdm_command_duration_seconds", "Latency of commands executed by browser"
+	for _, server := range servers {
+		l := m.latency[server]
+		var cumulative int64
+		for i, bound := range cmdLatencyBuckets {
+			cumulative += l.buckets[i]
+			le := strconv.FormatFloat(float64(bound)/1000, 'g', -1, 64)
+			p.add(family, "histogram", help, family+"_bucket", float64(cumulative), "connection", server, "le", le)
+		}
+		p.add(family, "histogram", help, family+"_bucket", float64(l.count), "connection", server, "le", "+Inf")
+		p.add(family, "histogram", help, family+"_sum", float64(l.sum)/1000, "connection", server)
+		p.add(family, "histogram", help, family+"_count", float64(l.count), "connection", server)
+	}
+}
+
+// WritePrometheus write INFO metrics of connections which enabled exporter,
+// and metrics of app in prometheus text format
+func (m *metricsService) WritePrometheus(w io.Writer, wsClients int) error {
+	p := newPromWriter()
+	exported := map[string]bool{}
+	var wg sync.WaitGroup
+	for _, conn := range Connection().conns.GetConnectionsFlat() {
+		if !conn.Exporter {
+			continue
+		}
+		// secrets are not included in connection list
+		conf, err := Connection().getConnection(conn.Name)
+		if err != nil {
+			continue
+		}
+		exported[conn.Name] = true
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.scrapeConnection(p, conf.ConnectionConfig)
+		}()
+	}
+	wg.Wait()
+	closeExporterClients(exported)
+
+	m.writeAppMetrics(p)
+	p.gauge("tinyrdm_websocket_clients", "Number of connected websocket clients", float64(wsClients))
+	return p.writeTo(w)
+}
+
+// StopExporter close all clients to scrape connections
+func (m *metricsService) StopExporter() {
+	closeExporterClients(nil)
+}
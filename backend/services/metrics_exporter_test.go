@@ -0,0 +1,71 @@
+//go:build web
+
+package services
+
+import (
+	"testing"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// closeCountClient records times of closed
+type closeCountClient struct {
+	redis.UniversalClient
+	closed int
+}
+
+func (c *closeCountClient) Close() error {
+	c.closed += 1
+	return nil
+}
+
+func newTestExporterClient(t *testing.T, name string) *exporterClient {
+	t.Helper()
+	item := &exporterClient{
+		conf:   types.ConnectionConfig{Name: name},
+		client: &closeCountClient{},
+	}
+	exporterMutex.Lock()
+	exporterClients[name] = item
+	exporterMutex.Unlock()
+	return item
+}
+
+func exporterClientClosed(item *exporterClient) bool {
+	return item.client.(*closeCountClient).closed > 0
+}
+
+func TestExporterClientRelease(t *testing.T) {
+	tests := []struct {
+		name       string
+		keep       bool
+		wantClosed bool
+	}{
+		{"still exported", true, false},
+		{"no longer exported", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cached := newTestExporterClient(t, "conn")
+			item, err := getExporterClient(cached.conf)
+			if err != nil || item != cached {
+				t.Fatalf("getExporterClient() = %v, %v, want cached client", item, err)
+			}
+
+			// another scrape finished while client is still used
+			closeExporterClients(map[string]bool{"conn": tt.keep})
+			if exporterClientClosed(item) {
+				t.Fatalf("client in use should not be closed")
+			}
+			item.release()
+			if closed := exporterClientClosed(item); closed != tt.wantClosed {
+				t.Errorf("client closed after released = %v, want %v", closed, tt.wantClosed)
+			}
+			closeExporterClients(nil)
+			if n := item.client.(*closeCountClient).closed; n > 1 {
+				t.Errorf("client closed %d times", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinyrdm/backend/storage"
+	"tinyrdm/backend/types"
+	"tinyrdm/backend/utils/coll"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// metricsSeries samples of all nodes of a connection
+type metricsSeries struct {
+	nodes    map[string]*coll.Ring[types.MetricsPoint]
+	lastHits map[string][2]int64 // keyspace hits and misses of last sample
+	mutex    sync.Mutex
+}
+
+type metricsSnapshot struct {
+	Nodes map[string][]types.MetricsPoint `json:"nodes"`
+}
+
+// cmdLatency histogram of command latency of a connection
+type cmdLatency struct {
+	buckets []int64 // count of commands in each bucket, not cumulative
+	count   int64
+	sum     int64 // in milliseconds
+}
+
+// upper bounds of command latency buckets, in milliseconds
+var cmdLatencyBuckets = []int64{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000}
+
+type metricsService struct {
+	ctx          context.Context
+	series       map[string]*metricsSeries
+	collectors   map[string]context.CancelFunc
+	latency      map[string]*cmdLatency
+	latencyMutex sync.Mutex
+	mutex        sync.Mutex
+}
+
+var metrics *metricsService
+var onceMetrics sync.Once
+
+func Metrics() *metricsService {
+	if metrics == nil {
+		onceMetrics.Do(func() {
+			metrics = &metricsService{
+				series:     map[string]*metricsSeries{},
+				collectors: map[string]context.CancelFunc{},
+				latency:    map[string]*cmdLatency{},
+			}
+		})
+	}
+	return metrics
+}
+
+func (m *metricsService) Start(ctx context.Context) {
+	m.ctx = ctx
+}
+
+// metricsFilename name of local file to persist samples of connection
+func metricsFilename(server string) string {
+	return fmt.Sprintf("metrics_%s.json", url.QueryEscape(server))
+}
+
+// observeCommand record latency of command executed by browser of connection
+func (m *metricsService) observeCommand(server string, cost int64) {
+	m.latencyMutex.Lock()
+	defer m.latencyMutex.Unlock()
+	l, ok := m.latency[server]
+	if !ok {
+		l = &cmdLatency{buckets: make([]int64, len(cmdLatencyBuckets))}
+		m.latency[server] = l
+	}
+	for i, bound := range cmdLatencyBuckets {
+		if cost <= bound {
+			l.buckets[i] += 1
+			break
+		}
+	}
+	l.count += 1
+	l.sum += cost
+}
+
+func (s *metricsSeries) add(node string, point types.MetricsPoint, capacity int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ring, ok := s.nodes[node]
+	if !ok {
+		ring = coll.NewRing[types.MetricsPoint](capacity)
+		s.nodes[node] = ring
+	} else {
+		ring.Resize(capacity)
+	}
+	ring.Push(point)
+}
+
+// snapshot copy samples in range of all nodes, from and to are timestamps in milliseconds, 0 means unlimited
+func (s *metricsSeries) snapshot(from, to int64) map[string][]types.MetricsPoint {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ret := make(map[string][]types.MetricsPoint, len(s.nodes))
+	for node, ring := range s.nodes {
+		points := []types.MetricsPoint{}
+		for _, p := range ring.ToSlice() {
+			if (from <= 0 || p.Timestamp >= from) && (to <= 0 || p.Timestamp <= to) {
+				points = append(points, p)
+			}
+		}
+		ret[node] = points
+	}
+	return ret
+}
+
+// hitRatio calculate keyspace hit ratio since last sample of node
+func (s *metricsSeries) hitRatio(node string, hits, misses int64) float64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	last, ok := s.lastHits[node]
+	s.lastHits[node] = [2]int64{hits, misses}
+	// cumulative values are used for the first sample, or stats was reset between samples
+	if ok && hits >= last[0] && misses >= last[1] {
+		hits, misses = hits-last[0], misses-last[1]
+	}
+	if hits+misses <= 0 {
+		return -1
+	}
+	return float64(hits) / float64(hits+misses)
+}
+
+// parseMetricsPoint extract sample from parsed INFO
+func parseMetricsPoint(info map[string]map[string]string) (point types.MetricsPoint, hits, misses int64) {
+	parseInt := func(section, field string) int64 {
+		n, _ := strconv.ParseInt(info[section][field], 10, 64)
+		return n
+	}
+	parseFloat := func(section, field string) float64 {
+		n, _ := strconv.ParseFloat(info[section][field], 64)
+		return n
+	}
+
+	point.OpsPerSec = parseInt("Stats", "instantaneous_ops_per_sec")
+	point.UsedMemory = parseInt("Memory", "used_memory")
+	point.Clients = parseInt("Clients", "connected_clients")
+	point.NetInput = parseFloat("Stats", "instantaneous_input_kbps") * 1024
+	point.NetOutput = parseFloat("Stats", "instantaneous_output_kbps") * 1024
+	// keyspace is like "db0:keys=1,expires=0,avg_ttl=0"
+	for _, val := range info["Keyspace"] {
+		for _, field := range strings.Split(val, ",") {
+			if k, v, ok := strings.Cut(field, "="); ok {
+				n, _ := strconv.ParseInt(v, 10, 64)
+				switch k {
+				case "keys":
+					point.Keys += n
+				case "expires":
+					point.Expires += n
+				}
+			}
+		}
+	}
+	return point, parseInt("Stats", "keyspace_hits"), parseInt("Stats", "keyspace_misses")
+}
+
+func (m *metricsService) getSeries(server string) *metricsSeries {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	series, ok := m.series[server]
+	if !ok {
+		series = &metricsSeries{
+			nodes:    map[string]*coll.Ring[types.MetricsPoint]{},
+			lastHits: map[string][2]int64{},
+		}
+		m.series[server] = series
+	}
+	return series
+}
+
+// sample collect INFO of each node in cluster mode, or of connected node, and evaluate alert rules with it
+func (m *metricsService) sample(ctx context.Context, server string, client redis.UniversalClient, series *metricsSeries, capacity int) {
+	now := time.Now().UnixMilli()
+	_, isCluster := client.(*redis.ClusterClient)
+	_ = forEachNode(client, ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		res, err := cli.Info(ctx).Result()
+		if err != nil {
+			return err
+		}
+		var node string
+		if isCluster {
+			node = cli.(*redis.Client).Options().Addr
+		}
+		info := Browser().parseInfo(res)
+		point, hits, misses := parseMetricsPoint(info)
+		point.Timestamp = now
+		point.HitRatio = series.hitRatio(node, hits, misses)
+		series.add(node, point, capacity)
+		Alert().evaluate(ctx, server, node, cli, info)
+		return nil
+	})
+}
+
+// collect sample server INFO periodically until canceled, client is created separately
+// so that commands of collector are not recorded in command log
+func (m *metricsService) collect(ctx context.Context, server string, opt types.PreferencesMetrics) {
+	series := m.getSeries(server)
+	var client redis.UniversalClient
+	defer func() {
+		if client != nil {
+			client.Close()
+		}
+		if opt.Persist {
+			m.persist(server, series)
+		}
+	}()
+
+	ticker := time.NewTicker(time.Duration(opt.Interval) * time.Second)
+	defer ticker.Stop()
+	lastPersist := time.Now()
+	for {
+		if client == nil {
+			if conf, err := Connection().getConnection(server); err == nil {
+				client, _ = Connection().createRedisClient(conf.ConnectionConfig)
+			}
+		}
+		if client != nil {
+			m.sample(ctx, server, client, series, opt.Capacity)
+		}
+		if opt.Persist && time.Since(lastPersist) >= time.Minute {
+			m.persist(server, series)
+			lastPersist = time.Now()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// persist save samples of connection to local file
+func (m *metricsService) persist(server string, series *metricsSeries) {
+	b, err := json.Marshal(metricsSnapshot{Nodes: series.snapshot(0, 0)})
+	if err != nil {
+		return
+	}
+	_ = storage.NewLocalStore(metricsFilename(server)).Store(b)
+}
+
+// restore load samples of connection saved before
+func (m *metricsService) restore(server string, capacity int) {
+	b, err := storage.NewLocalStore(metricsFilename(server)).Load()
+	if err != nil {
+		return
+	}
+	var snapshot metricsSnapshot
+	if err = json.Unmarshal(b, &snapshot); err != nil {
+		return
+	}
+	series := m.getSeries(server)
+	series.mutex.Lock()
+	hasSamples := len(series.nodes) > 0
+	series.mutex.Unlock()
+	if hasSamples {
+		// samples in memory are newer
+		return
+	}
+	for node, points := range snapshot.Nodes {
+		for _, p := range points {
+			series.add(node, p, capacity)
+		}
+	}
+}
+
+// Watch start collecting metrics of connection in background if enabled
+func (m *metricsService) Watch(server string) {
+	opt := Preferences().GetMetricsOptions()
+	if !opt.Enable {
+		return
+	}
+
+	m.mutex.Lock()
+	if _, ok := m.collectors[server]; ok {
+		m.mutex.Unlock()
+		return
+	}
+	ctx, cancelFunc := context.WithCancel(context.Background())
+	m.collectors[server] = cancelFunc
+	m.mutex.Unlock()
+
+	if opt.Persist {
+		m.restore(server, opt.Capacity)
+	}
+	go m.collect(ctx, server, opt)
+}
+
+// Unwatch stop collecting metrics of connection, collected samples are kept
+func (m *metricsService) Unwatch(server string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if cancelFunc, ok := m.collectors[server]; ok {
+		cancelFunc()
+		delete(m.collectors, server)
+	}
+}
+
+// Reload restart all collectors with the latest options
+func (m *metricsService) Reload() {
+	m.mutex.Lock()
+	servers := make([]string, 0, len(m.collectors))
+	for server := range m.collectors {
+		servers = append(servers, server)
+	}
+	m.mutex.Unlock()
+
+	for _, server := range servers {
+		m.Unwatch(server)
+		m.Watch(server)
+	}
+}
+
+// StopAll stop all collectors
+func (m *metricsService) StopAll() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for server, cancelFunc := range m.collectors {
+		cancelFunc()
+		delete(m.collectors, server)
+	}
+}
+
+// GetMetrics get samples of all nodes in range, from and to are timestamps in milliseconds, 0 means unlimited
+func (m *metricsService) GetMetrics(server string, from, to int64) (resp types.JSResp) {
+	opt := Preferences().GetMetricsOptions()
+	m.mutex.Lock()
+	series, hasSeries := m.series[server]
+	_, collecting := m.collectors[server]
+	m.mutex.Unlock()
+
+	nodes := map[string][]types.MetricsPoint{}
+	if hasSeries {
+		nodes = series.snapshot(from, to)
+	}
+	names := make([]string, 0, len(nodes))
+	for node := range nodes {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+
+	resp.Success = true
+	resp.Data = map[string]any{
+		"enable":     opt.Enable,
+		"collecting": collecting,
+		"interval":   opt.Interval,
+		"nodes":      names,
+		"series":     nodes,
+	}
+	return
+}
+
+// ClearMetrics clean collected samples of connection, including the persisted file
+func (m *metricsService) ClearMetrics(server string) (resp types.JSResp) {
+	series := m.getSeries(server)
+	series.mutex.Lock()
+	series.nodes = map[string]*coll.Ring[types.MetricsPoint]{}
+	series.lastHits = map[string][2]int64{}
+	series.mutex.Unlock()
+
+	if err := storage.NewLocalStore(metricsFilename(server)).Remove(); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -51,9 +51,9 @@ func (c *monitorService) getItem(server string) (*monitorItem, error) {
 	item, ok := c.items[server]
 	if !ok {
 		var err error
-		conf := Connection().getConnection(server)
-		if conf == nil {
-			return nil, fmt.Errorf("no connection profile named: %s", server)
+		var conf *types.Connection
+		if conf, err = Connection().getConnection(server); err != nil {
+			return nil, err
 		}
 		var uniClient redis.UniversalClient
 		if uniClient, err = Connection().createRedisClient(conf.ConnectionConfig); err != nil {
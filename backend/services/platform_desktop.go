@@ -4,6 +4,9 @@ package services
 
 import (
 	"context"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -79,6 +82,30 @@ func WindowIsNormal(ctx context.Context) bool {
 	return runtime.WindowIsNormal(ctx)
 }
 
+var onceNotification sync.Once
+var notificationErr error
+
+// SendNotification sends a system notification (Wails desktop)
+func SendNotification(ctx context.Context, title, body string) error {
+	onceNotification.Do(func() {
+		if notificationErr = runtime.InitializeNotifications(ctx); notificationErr == nil {
+			// prompt for permission on macOS, always granted on other platforms
+			_, _ = runtime.RequestNotificationAuthorization(ctx)
+		}
+	})
+	if notificationErr != nil {
+		return notificationErr
+	}
+	if !runtime.IsNotificationAvailable(ctx) {
+		return nil
+	}
+	return runtime.SendNotification(ctx, runtime.NotificationOptions{
+		ID:    strconv.FormatInt(time.Now().UnixNano(), 10),
+		Title: title,
+		Body:  body,
+	})
+}
+
 // IsWeb returns false in desktop mode
 func IsWeb() bool { return false }
 
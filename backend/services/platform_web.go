@@ -116,6 +116,9 @@ func WindowIsMinimised(ctx context.Context) bool { return false }
 // WindowIsNormal returns true in web mode
 func WindowIsNormal(ctx context.Context) bool { return true }
 
+// SendNotification is a no-op in web mode
+func SendNotification(ctx context.Context, title, body string) error { return nil }
+
 // IsWeb returns true in web mode
 func IsWeb() bool { return true }
 
@@ -3,11 +3,13 @@ package services
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"os"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 	"tinyrdm/backend/consts"
 	storage2 "tinyrdm/backend/storage"
 	"tinyrdm/backend/types"
@@ -20,6 +22,7 @@ import (
 
 type preferencesService struct {
 	pref          *storage2.PreferencesStorage
+	cipherKeys    *storage2.CipherKeysStorage
 	clientVersion string
 }
 
@@ -31,6 +34,7 @@ func Preferences() *preferencesService {
 		oncePreferences.Do(func() {
 			preferences = &preferencesService{
 				pref:          storage2.NewPreferences(),
+				cipherKeys:    storage2.NewCipherKeys(),
 				clientVersion: "",
 			}
 		})
@@ -38,6 +42,12 @@ func Preferences() *preferencesService {
 	return preferences
 }
 
+// Stop release resources held by custom decoders
+func (p *preferencesService) Stop() {
+	convutil.StopPlugins()
+	convutil.StopWasmModules()
+}
+
 func (p *preferencesService) GetPreferences() (resp types.JSResp) {
 	resp.Data = p.pref.GetPreferences()
 	resp.Success = true
@@ -45,6 +55,10 @@ func (p *preferencesService) GetPreferences() (resp types.JSResp) {
 }
 
 func (p *preferencesService) SetPreferences(pf types.Preferences) (resp types.JSResp) {
+	if err := p.validateTimezone(pf.Editor.Timezone); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
 	err := p.pref.SetPreferences(&pf)
 	if err != nil {
 		resp.Msg = err.Error()
@@ -52,11 +66,21 @@ func (p *preferencesService) SetPreferences(pf types.Preferences) (resp types.JS
 	}
 
 	p.UpdateEnv()
+	// restart plugin decoders with new config when next used
+	convutil.StopPlugins()
+	// restart metrics collectors with new options
+	Metrics().Reload()
 	resp.Success = true
 	return
 }
 
 func (p *preferencesService) UpdatePreferences(value map[string]any) (resp types.JSResp) {
+	if tz, ok := value["editor.timezone"].(string); ok {
+		if err := p.validateTimezone(tz); err != nil {
+			resp.Msg = err.Error()
+			return
+		}
+	}
 	err := p.pref.UpdatePreferences(value)
 	if err != nil {
 		resp.Msg = err.Error()
@@ -66,6 +90,16 @@ func (p *preferencesService) UpdatePreferences(value map[string]any) (resp types
 	return
 }
 
+// check timezone name before save, empty name means local timezone
+func (p *preferencesService) validateTimezone(name string) error {
+	if len(name) > 0 {
+		if _, err := time.LoadLocation(name); err != nil {
+			return fmt.Errorf("invalid timezone \"%s\"", name)
+		}
+	}
+	return nil
+}
+
 func (p *preferencesService) RestorePreferences() (resp types.JSResp) {
 	defaultPref := p.pref.RestoreDefault()
 	resp.Data = map[string]any{
@@ -205,23 +239,93 @@ func (p *preferencesService) GetScanSize() int {
 	return size
 }
 
-func (p *preferencesService) GetDecoder() []convutil.CmdConvert {
+func (p *preferencesService) GetMetricsOptions() types.PreferencesMetrics {
+	return p.pref.GetPreferences().Metrics
+}
+
+func (p *preferencesService) GetDecoder() []convutil.CustomConvert {
 	data := p.pref.GetPreferences()
-	return sliceutil.FilterMap(data.Decoder, func(i int) (convutil.CmdConvert, bool) {
+	return sliceutil.FilterMap(data.Decoder, func(i int) (convutil.CustomConvert, bool) {
 		//if !data.Decoder[i].Enable {
-		//	return convutil.CmdConvert{}, false
+		//	return convutil.CustomConvert{}, false
 		//}
-		return convutil.CmdConvert{
-			Name:       data.Decoder[i].Name,
-			Auto:       data.Decoder[i].Auto,
-			DecodePath: data.Decoder[i].DecodePath,
-			DecodeArgs: data.Decoder[i].DecodeArgs,
-			EncodePath: data.Decoder[i].EncodePath,
-			EncodeArgs: data.Decoder[i].EncodeArgs,
+		dec := data.Decoder[i]
+		var conv convutil.DataConvert
+		switch dec.Type {
+		case types.DECODER_TYPE_CIPHER:
+			key, err := p.cipherKeys.GetKey(dec.Name)
+			if err != nil {
+				return convutil.CustomConvert{}, false
+			}
+			conv = convutil.NewCipherConvert(dec.Cipher.Algorithm, key, dec.Cipher.IVPosition, dec.Cipher.IV, dec.Cipher.Encoding)
+		case types.DECODER_TYPE_PLUGIN:
+			conv = convutil.PluginConvert{
+				Name:    dec.Name,
+				Path:    dec.DecodePath,
+				Args:    dec.DecodeArgs,
+				Timeout: time.Duration(dec.Timeout) * time.Second,
+			}
+		case types.DECODER_TYPE_WASM:
+			conv = convutil.WasmConvert{
+				Name: dec.Name,
+				Path: dec.DecodePath,
+			}
+		default:
+			conv = convutil.CmdConvert{
+				Name:       dec.Name,
+				Auto:       dec.Auto,
+				DecodePath: dec.DecodePath,
+				DecodeArgs: dec.DecodeArgs,
+				EncodePath: dec.EncodePath,
+				EncodeArgs: dec.EncodeArgs,
+			}
+		}
+		return convutil.CustomConvert{
+			DataConvert: conv,
+			Name:        dec.Name,
+			Auto:        dec.Auto,
 		}, true
 	})
 }
 
+// SetCipherKey save the key material of cipher decoder, the key is accepted in hex or base64
+func (p *preferencesService) SetCipherKey(name, key string) (resp types.JSResp) {
+	if len(name) <= 0 {
+		resp.Msg = "decoder name is required"
+		return
+	}
+	keyData, err := convutil.ParseCipherKey(key)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	if err = p.cipherKeys.SetKey(name, keyData); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// DeleteCipherKey remove the key material of cipher decoder
+func (p *preferencesService) DeleteCipherKey(name string) (resp types.JSResp) {
+	if err := p.cipherKeys.DeleteKey(name); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// ListCipherKeys list names of cipher decoder which key material has been saved
+func (p *preferencesService) ListCipherKeys() (resp types.JSResp) {
+	resp.Success = true
+	resp.Data = map[string]any{
+		"names": p.cipherKeys.ListNames(),
+	}
+	return
+}
+
 type sponsorItem struct {
 	Name   string   `json:"name"`
 	Link   string   `json:"link"`
@@ -272,4 +376,5 @@ func (p *preferencesService) UpdateEnv() {
 	} else {
 		os.Unsetenv("LANG")
 	}
+	convutil.SetTimezone(p.pref.GetPreferences().Editor.Timezone)
 }
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"tinyrdm/backend/types"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// class flags of "notify-keyspace-events" required by event types,
+// others are enabled by "A" which is an alias of all classes
+var keyspaceEventClasses = map[string]string{
+	"set":     "$",
+	"del":     "g",
+	"expired": "x",
+	"evicted": "e",
+}
+
+const keyspaceAllClasses = "g$lshzxet"
+
+type keyspaceEvent struct {
+	Timestamp int64  `json:"timestamp"`
+	Node      string `json:"node,omitempty"`
+	DB        int    `json:"db"`
+	Key       string `json:"key"`
+	Event     string `json:"event"`
+}
+
+type keyspaceItem struct {
+	client    redis.UniversalClient
+	pubsubs   []*redis.PubSub
+	closeCh   chan struct{}
+	eventName string
+	cache     []keyspaceEvent
+	counts    map[string]int64 // count of received events by event type
+	mutex     sync.Mutex
+}
+
+// expandKeyspaceFlags replace alias "A" with all classes it stands for
+func expandKeyspaceFlags(flags string) string {
+	return strings.ReplaceAll(flags, "A", keyspaceAllClasses)
+}
+
+// missingKeyspaceFlags flags required by events but not in config, empty events means all events
+func missingKeyspaceFlags(flags string, events []string) string {
+	flags = expandKeyspaceFlags(flags)
+	required := keyspaceAllClasses
+	if len(events) > 0 {
+		required = ""
+		for _, event := range events {
+			if class, ok := keyspaceEventClasses[event]; ok {
+				required += class
+			} else {
+				// class of other event is unknown
+				required = keyspaceAllClasses
+				break
+			}
+		}
+	}
+
+	var missing strings.Builder
+	if !strings.ContainsAny(flags, "KE") {
+		missing.WriteByte('K')
+	}
+	for _, c := range required {
+		if !strings.ContainsRune(flags, c) && !strings.ContainsRune(missing.String(), c) {
+			missing.WriteRune(c)
+		}
+	}
+	return missing.String()
+}
+
+// keyspaceGlob convert glob-style pattern of redis to regular expression
+func keyspaceGlob(pattern string) (*regexp.Regexp, error) {
+	var sb strings.Builder
+	sb.WriteString("^")
+	runes := []rune(pattern)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteString(".")
+		case '[':
+			end := slices.Index(runes[i+1:], ']')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := string(runes[i+1 : i+1+end])
+			if strings.HasPrefix(class, "^") {
+				class = "^" + strings.ReplaceAll(class[1:], `\`, `\\`)
+			} else {
+				class = strings.ReplaceAll(class, `\`, `\\`)
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(runes[i])))
+			}
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return regexp.Compile(sb.String())
+}
+
+// parseKeyspaceMessage extract event from message of channel "__keyspace@<db>__:<key>" or "__keyevent@<db>__:<event>"
+func parseKeyspaceMessage(msg *redis.Message) (event keyspaceEvent, ok bool) {
+	prefix, suffix, found := strings.Cut(msg.Channel, "__:")
+	if !found {
+		return
+	}
+	kind, db, found := strings.Cut(strings.TrimPrefix(prefix, "__"), "@")
+	if !found {
+		return
+	}
+	event.DB, _ = strconv.Atoi(db)
+	switch kind {
+	case "keyspace":
+		event.Key, event.Event = suffix, msg.Payload
+	case "keyevent":
+		event.Key, event.Event = msg.Payload, suffix
+	default:
+		return
+	}
+	return event, true
+}
+
+// getKeyspaceConfig get "notify-keyspace-events" of each master node, key by node address
+func (p *pubsubService) getKeyspaceConfig(ctx context.Context, client redis.UniversalClient) (map[string]string, error) {
+	configs := map[string]string{}
+	var mu sync.Mutex
+	getConfig := func(ctx context.Context, cli *redis.Client) error {
+		val, err := cli.ConfigGet(ctx, "notify-keyspace-events").Result()
+		if err != nil {
+			return fmt.Errorf("%s: %w", cli.Options().Addr, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		configs[cli.Options().Addr] = val["notify-keyspace-events"]
+		return nil
+	}
+
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		if err := cluster.ForEachMaster(ctx, getConfig); err != nil {
+			return nil, err
+		}
+	} else if cli, ok := client.(*redis.Client); ok {
+		if err := getConfig(ctx, cli); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// GetKeyspaceNotifyConfig check "notify-keyspace-events" of server, on all master nodes in cluster mode,
+// missing flags required by events are returned
+func (p *pubsubService) GetKeyspaceNotifyConfig(server string, events []string) (resp types.JSResp) {
+	item, err := Browser().getRedisClient(server, -1)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	configs, err := p.getKeyspaceConfig(item.ctx, item.client)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	var values []string
+	var missing string
+	for _, val := range configs {
+		if !slices.Contains(values, val) {
+			values = append(values, val)
+		}
+		for _, c := range missingKeyspaceFlags(val, events) {
+			if !strings.ContainsRune(missing, c) {
+				missing += string(c)
+			}
+		}
+	}
+	sort.Strings(values)
+
+	resp.Success = true
+	resp.Data = map[string]any{
+		"value":   strings.Join(values, ", "),
+		"missing": missing,
+	}
+	return
+}
+
+// EnableKeyspaceNotify append flags required by events to "notify-keyspace-events", on all nodes in cluster mode
+func (p *pubsubService) EnableKeyspaceNotify(server string, events []string) (resp types.JSResp) {
+	item, err := Browser().getRedisClient(server, -1)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+
+	err = forEachNode(item.client, item.ctx, func(ctx context.Context, cli redis.UniversalClient) error {
+		val, err := cli.ConfigGet(ctx, "notify-keyspace-events").Result()
+		if err != nil {
+			return err
+		}
+		flags := val["notify-keyspace-events"]
+		if missing := missingKeyspaceFlags(flags, events); len(missing) > 0 {
+			return cli.ConfigSet(ctx, "notify-keyspace-events", flags+missing).Err()
+		}
+		return nil
+	})
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
+
+// StartKeyspaceSubscribe subscribe keyspace events of database, on all master nodes in cluster mode.
+// keyspace channels are preferred so that keys are filtered by server, keyevent channels are used
+// if only "E" is configured. empty events means all events
+func (p *pubsubService) StartKeyspaceSubscribe(server string, db int, pattern string, events []string) (resp types.JSResp) {
+	conf, err := Connection().getConnection(server)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	p.StopKeyspaceSubscribe(server)
+
+	client, err := Connection().createRedisClient(conf.ConnectionConfig)
+	if err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	_, isCluster := client.(*redis.ClusterClient)
+	if isCluster {
+		db = 0
+	}
+	if len(pattern) <= 0 {
+		pattern = "*"
+	}
+
+	configs, err := p.getKeyspaceConfig(p.ctx, client)
+	if err != nil {
+		client.Close()
+		resp.Msg = err.Error()
+		return
+	}
+	useKeyspace, useKeyevent := true, true
+	for _, val := range configs {
+		useKeyspace = useKeyspace && strings.Contains(val, "K")
+		useKeyevent = useKeyevent && strings.Contains(val, "E")
+	}
+	if !useKeyspace && !useKeyevent {
+		client.Close()
+		resp.Msg = "keyspace notifications are not enabled by \"notify-keyspace-events\""
+		return
+	}
+
+	var channels []string
+	var keyFilter *regexp.Regexp
+	if useKeyspace {
+		channels = []string{fmt.Sprintf("__keyspace@%d__:%s", db, pattern)}
+	} else {
+		if keyFilter, err = keyspaceGlob(pattern); err != nil {
+			client.Close()
+			resp.Msg = err.Error()
+			return
+		}
+		if len(events) > 0 {
+			for _, event := range events {
+				channels = append(channels, fmt.Sprintf("__keyevent@%d__:%s", db, event))
+			}
+		} else {
+			channels = []string{fmt.Sprintf("__keyevent@%d__:*", db)}
+		}
+	}
+
+	item := &keyspaceItem{
+		client:    client,
+		closeCh:   make(chan struct{}),
+		eventName: "keyspace:" + strconv.Itoa(int(time.Now().Unix())),
+		counts:    map[string]int64{},
+	}
+	// keyspace events are only delivered by the node where key belongs to
+	var nodes []string
+	if cluster, ok := client.(*redis.ClusterClient); ok {
+		var mu sync.Mutex
+		err = cluster.ForEachMaster(p.ctx, func(ctx context.Context, cli *redis.Client) error {
+			ps := cli.PSubscribe(p.ctx, channels...)
+			mu.Lock()
+			defer mu.Unlock()
+			item.pubsubs = append(item.pubsubs, ps)
+			nodes = append(nodes, cli.Options().Addr)
+			return nil
+		})
+	} else {
+		item.pubsubs = []*redis.PubSub{client.PSubscribe(p.ctx, channels...)}
+		nodes = []string{""}
+	}
+	if err != nil {
+		for _, ps := range item.pubsubs {
+			ps.Close()
+		}
+		client.Close()
+		resp.Msg = err.Error()
+		return
+	}
+
+	for i, ps := range item.pubsubs {
+		go p.receiveKeyspaceEvents(item, ps.Channel(), nodes[i], events, keyFilter)
+	}
+	go p.emitKeyspaceEvents(item)
+
+	p.mutex.Lock()
+	p.keyspaceItems[server] = item
+	p.mutex.Unlock()
+
+	resp.Success = true
+	resp.Data = map[string]any{
+		"eventName": item.eventName,
+		"channels":  channels,
+	}
+	return
+}
+
+// receiveKeyspaceEvents filter and cache events from subscribed channels of a node
+func (p *pubsubService) receiveKeyspaceEvents(item *keyspaceItem, ch <-chan *redis.Message, node string,
+	events []string, keyFilter *regexp.Regexp) {
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			event, ok := parseKeyspaceMessage(msg)
+			if !ok || (len(events) > 0 && !slices.Contains(events, event.Event)) ||
+				(keyFilter != nil && !keyFilter.MatchString(event.Key)) {
+				continue
+			}
+			event.Timestamp = time.Now().UnixMilli()
+			event.Node = node
+
+			item.mutex.Lock()
+			item.cache = append(item.cache, event)
+			item.counts[event.Event] += 1
+			if len(item.cache) > 300 {
+				p.flushKeyspaceEvents(item)
+			}
+			item.mutex.Unlock()
+
+		case <-item.closeCh:
+			return
+		}
+	}
+}
+
+// flushKeyspaceEvents emit cached events with counts, must be called with lock held
+func (p *pubsubService) flushKeyspaceEvents(item *keyspaceItem) {
+	counts := make(map[string]int64, len(item.counts))
+	for k, v := range item.counts {
+		counts[k] = v
+	}
+	EventsEmit(p.ctx, item.eventName, map[string]any{
+		"list":   item.cache,
+		"counts": counts,
+	})
+	item.cache = make([]keyspaceEvent, 0, cap(item.cache))
+}
+
+func (p *pubsubService) emitKeyspaceEvents(item *keyspaceItem) {
+	ticker := time.NewTicker(300 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			item.mutex.Lock()
+			if len(item.cache) > 0 {
+				p.flushKeyspaceEvents(item)
+			}
+			item.mutex.Unlock()
+
+		case <-item.closeCh:
+			return
+		}
+	}
+}
+
+// StopKeyspaceSubscribe stop subscribing keyspace events by server name
+func (p *pubsubService) StopKeyspaceSubscribe(server string) (resp types.JSResp) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	item, ok := p.keyspaceItems[server]
+	if !ok {
+		resp.Success = true
+		return
+	}
+
+	close(item.closeCh)
+	var errs []error
+	for _, ps := range item.pubsubs {
+		errs = append(errs, ps.Close())
+	}
+	errs = append(errs, item.client.Close())
+	delete(p.keyspaceItems, server)
+	if err := errors.Join(errs...); err != nil {
+		resp.Msg = err.Error()
+		return
+	}
+	resp.Success = true
+	return
+}
@@ -0,0 +1,62 @@
+package services
+
+import "testing"
+
+func TestMissingKeyspaceFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  string
+		events []string
+		want   string
+	}{
+		{"disabled, all events", "", nil, "Kg$lshzxet"},
+		{"alias of all classes", "KA", nil, ""},
+		{"keyevent only", "EA", nil, ""},
+		{"missing channel type", "A", nil, "K"},
+		{"selected events", "K$", []string{"set", "del"}, "g"},
+		{"selected events enabled", "Kgx", []string{"del", "expired"}, ""},
+		{"evicted", "", []string{"evicted"}, "Ke"},
+		{"unknown event requires all", "K$", []string{"set", "hset"}, "glshzxet"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingKeyspaceFlags(tt.flags, tt.events); got != tt.want {
+				t.Errorf("missingKeyspaceFlags(%q, %v) = %q, want %q", tt.flags, tt.events, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyspaceGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		key     string
+		want    bool
+	}{
+		{"*", "any", true},
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"user:?", "user:1", true},
+		{"user:?", "user:10", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"a.b", "a.b", true},
+		{"a.b", "axb", false},
+		{`user\*`, "user*", true},
+		{`user\*`, "user1", false},
+		{"[abc", "[abc", true},
+		{"(x)+", "(x)+", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.key, func(t *testing.T) {
+			re, err := keyspaceGlob(tt.pattern)
+			if err != nil {
+				t.Fatalf("keyspaceGlob(%q) error: %v", tt.pattern, err)
+			}
+			if got := re.MatchString(tt.key); got != tt.want {
+				t.Errorf("keyspaceGlob(%q) match %q = %v, want %v", tt.pattern, tt.key, got, tt.want)
+			}
+		})
+	}
+}
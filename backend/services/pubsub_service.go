@@ -2,7 +2,6 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -30,6 +29,8 @@ type pubsubService struct {
 	ctxCancel context.CancelFunc
 	mutex     sync.Mutex
 	items     map[string]*pubsubItem
+
+	keyspaceItems map[string]*keyspaceItem
 }
 
 var pubsub *pubsubService
@@ -39,7 +40,8 @@ func Pubsub() *pubsubService {
 	if pubsub == nil {
 		oncePubsub.Do(func() {
 			pubsub = &pubsubService{
-				items: map[string]*pubsubItem{},
+				items:         map[string]*pubsubItem{},
+				keyspaceItems: map[string]*keyspaceItem{},
 			}
 		})
 	}
@@ -53,9 +55,9 @@ func (p *pubsubService) getItem(server string) (*pubsubItem, error) {
 	item, ok := p.items[server]
 	if !ok {
 		var err error
-		conf := Connection().getConnection(server)
-		if conf == nil {
-			return nil, fmt.Errorf("no connection profile named: %s", server)
+		var conf *types.Connection
+		if conf, err = Connection().getConnection(server); err != nil {
+			return nil, err
 		}
 		var uniClient redis.UniversalClient
 		if uniClient, err = Connection().createRedisClient(conf.ConnectionConfig); err != nil {
@@ -187,4 +189,7 @@ func (p *pubsubService) StopAll() {
 	for server := range p.items {
 		p.StopSubscribe(server)
 	}
+	for server := range p.keyspaceItems {
+		p.StopKeyspaceSubscribe(server)
+	}
 }
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+	"tinyrdm/backend/types"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const sshKeepAliveInterval = 15 * time.Second
+const sshKeepAliveTimeout = 10 * time.Second
+const sshTunnelIdleTimeout = 30 * time.Second
+const sshMaxReconnectDelay = 30 * time.Second
+
+// sshTunnel ssh connection shared by all redis clients of one connection profile.
+// every redis connection through tunnel holds a reference, tunnel is closed after idle for a while
+type sshTunnel struct {
+	name    string
+	key     string
+	connect func() (*ssh.Client, error)
+	manager *sshTunnelManager
+
+	mutex     sync.Mutex
+	client    *ssh.Client
+	dialing   *sshDial // pending connect, shared by concurrent callers
+	refs      int
+	status    string
+	lastErr   error
+	since     time.Time
+	idleTimer *time.Timer
+}
+
+// sshDial result of connecting ssh server, done is closed after finished
+type sshDial struct {
+	done   chan struct{}
+	client *ssh.Client
+	err    error
+}
+
+// sshTunnelManager keep ssh tunnels by connection name and options of ssh
+type sshTunnelManager struct {
+	mutex    sync.Mutex
+	tunnels  map[string]*sshTunnel
+	onStatus func(status types.SSHTunnelStatus)
+}
+
+func newSSHTunnelManager(onStatus func(status types.SSHTunnelStatus)) *sshTunnelManager {
+	return &sshTunnelManager{
+		tunnels:  map[string]*sshTunnel{},
+		onStatus: onStatus,
+	}
+}
+
+// get returns tunnel of connection, create one if not exists.
+// tunnels of the same name with different options(profile modified or connection testing) are kept apart
+func (m *sshTunnelManager) get(name, hash string, connect func() (*ssh.Client, error)) *sshTunnel {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	key := name + "\x00" + hash
+	t, ok := m.tunnels[key]
+	if !ok {
+		t = &sshTunnel{
+			name:    name,
+			key:     key,
+			connect: connect,
+			manager: m,
+			status:  types.SSH_TUNNEL_CLOSED,
+			since:   time.Now(),
+		}
+		m.tunnels[key] = t
+	}
+	return t
+}
+
+// closeIdle close tunnel and remove it if no connection through it
+func (m *sshTunnelManager) closeIdle(t *sshTunnel) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.refs > 0 {
+		return
+	}
+
+	m.mutex.Lock()
+	if m.tunnels[t.key] == t {
+		delete(m.tunnels, t.key)
+	}
+	m.mutex.Unlock()
+
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+	t.setStatus(types.SSH_TUNNEL_CLOSED, nil)
+}
+
+// List returns status of all tunnels
+func (m *sshTunnelManager) List() (ret []types.SSHTunnelStatus) {
+	m.mutex.Lock()
+	tunnels := make([]*sshTunnel, 0, len(m.tunnels))
+	for _, t := range m.tunnels {
+		tunnels = append(tunnels, t)
+	}
+	m.mutex.Unlock()
+
+	ret = make([]types.SSHTunnelStatus, 0, len(tunnels))
+	for _, t := range tunnels {
+		t.mutex.Lock()
+		ret = append(ret, t.statusOf())
+		t.mutex.Unlock()
+	}
+	return
+}
+
+func (t *sshTunnel) statusOf() types.SSHTunnelStatus {
+	status := types.SSHTunnelStatus{
+		Name:   t.name,
+		Status: t.status,
+		Refs:   t.refs,
+		Since:  t.since.UnixMilli(),
+	}
+	if t.lastErr != nil {
+		status.Error = t.lastErr.Error()
+	}
+	return status
+}
+
+// setStatus update status and notify, should be called with lock held
+func (t *sshTunnel) setStatus(status string, err error) {
+	if t.status == status && err == nil && t.lastErr == nil {
+		return
+	}
+	t.status, t.lastErr, t.since = status, err, time.Now()
+	if t.manager.onStatus != nil {
+		t.manager.onStatus(t.statusOf())
+	}
+}
+
+// scheduleIdle close tunnel later if no connection through it, should be called with lock held
+func (t *sshTunnel) scheduleIdle() {
+	if t.refs > 0 {
+		return
+	}
+	if t.idleTimer != nil {
+		t.idleTimer.Stop()
+	}
+	t.idleTimer = time.AfterFunc(sshTunnelIdleTimeout, func() {
+		t.manager.closeIdle(t)
+	})
+}
+
+// ensure returns connected ssh client, connect if not connected yet.
+// connecting is done without lock held, so that a hung dial would not block status querying or keepalive,
+// concurrent callers wait for the same dial
+func (t *sshTunnel) ensure() (*ssh.Client, error) {
+	t.mutex.Lock()
+	if t.client != nil {
+		client := t.client
+		t.mutex.Unlock()
+		return client, nil
+	}
+	if d := t.dialing; d != nil {
+		t.mutex.Unlock()
+		<-d.done
+		return d.client, d.err
+	}
+	d := &sshDial{done: make(chan struct{})}
+	t.dialing = d
+	if t.status != types.SSH_TUNNEL_RECONNECTING {
+		t.setStatus(types.SSH_TUNNEL_CONNECTING, nil)
+	}
+	t.mutex.Unlock()
+
+	d.client, d.err = t.connect()
+
+	t.mutex.Lock()
+	t.dialing = nil
+	if d.err != nil {
+		t.setStatus(types.SSH_TUNNEL_FAILED, d.err)
+	} else {
+		t.client = d.client
+		t.setStatus(types.SSH_TUNNEL_CONNECTED, nil)
+		go t.keepAlive(d.client)
+	}
+	t.scheduleIdle()
+	t.mutex.Unlock()
+	close(d.done)
+	return d.client, d.err
+}
+
+// Dial open connection through tunnel, implements proxy.Dialer
+func (t *sshTunnel) Dial(network, addr string) (net.Conn, error) {
+	client, err := t.ensure()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.Dial(network, addr)
+	if err != nil {
+		var openErr *ssh.OpenChannelError
+		if errors.As(err, &openErr) {
+			// rejected by ssh server, tunnel is still available
+			return nil, err
+		}
+		// ssh connection may be broken, retry with a new one
+		t.broken(client, err)
+		if client, err = t.ensure(); err != nil {
+			return nil, err
+		}
+		if conn, err = client.Dial(network, addr); err != nil {
+			return nil, err
+		}
+	}
+
+	t.mutex.Lock()
+	t.refs += 1
+	if t.idleTimer != nil {
+		t.idleTimer.Stop()
+		t.idleTimer = nil
+	}
+	t.mutex.Unlock()
+	return &tunnelConn{Conn: conn, tunnel: t}, nil
+}
+
+func (t *sshTunnel) release() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.refs -= 1
+	t.scheduleIdle()
+}
+
+// broken drop the broken ssh client, and reconnect in background if still in use
+func (t *sshTunnel) broken(client *ssh.Client, err error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.client != client {
+		// closed or replaced already
+		return
+	}
+	t.client = nil
+	client.Close()
+	if t.refs > 0 {
+		t.setStatus(types.SSH_TUNNEL_RECONNECTING, err)
+		go t.reconnect()
+	} else {
+		t.setStatus(types.SSH_TUNNEL_CLOSED, err)
+		t.scheduleIdle()
+	}
+}
+
+// reconnect retry with backoff until connected or no one use it
+func (t *sshTunnel) reconnect() {
+	delay := time.Second
+	for {
+		time.Sleep(delay)
+		t.mutex.Lock()
+		stop := t.client != nil || t.refs <= 0
+		if !stop && t.status != types.SSH_TUNNEL_RECONNECTING {
+			t.setStatus(types.SSH_TUNNEL_RECONNECTING, t.lastErr)
+		}
+		t.mutex.Unlock()
+		if stop {
+			return
+		}
+		if _, err := t.ensure(); err == nil {
+			return
+		}
+		if delay *= 2; delay > sshMaxReconnectDelay {
+			delay = sshMaxReconnectDelay
+		}
+	}
+}
+
+// keepAlive send keepalive request periodically, and watch for disconnection
+func (t *sshTunnel) keepAlive(client *ssh.Client) {
+	closeCh := make(chan error, 1)
+	go func() {
+		closeCh <- client.Wait()
+	}()
+
+	ticker := time.NewTicker(sshKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case err := <-closeCh:
+			if err == nil {
+				err = errors.New("ssh connection closed")
+			}
+			t.broken(client, err)
+			return
+		case <-ticker.C:
+			if err := sendKeepAlive(client); err != nil {
+				t.broken(client, err)
+				return
+			}
+		}
+	}
+}
+
+func sendKeepAlive(client *ssh.Client) error {
+	errCh := make(chan error, 1)
+	go func() {
+		// server replies failure to unknown request, which is also fine
+		_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+		errCh <- err
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(sshKeepAliveTimeout):
+		return errors.New("ssh keepalive timeout")
+	}
+}
+
+// tunnelConn connection through tunnel, release reference of tunnel after closed
+type tunnelConn struct {
+	net.Conn
+	tunnel *sshTunnel
+	once   sync.Once
+}
+
+func (c *tunnelConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.tunnel.release)
+	return err
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"tinyrdm/backend/types"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// newLocalSSHClient connect to an in-process ssh server which rejects every channel
+func newLocalSSHClient(t *testing.T) *ssh.Client {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverConf := &ssh.ServerConfig{NoClientAuth: true}
+	serverConf.AddHostKey(signer)
+
+	// listen on loopback, handshake over net.Pipe would be blocked as both sides write version first
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		serverSide, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_, chans, reqs, err := ssh.NewServerConn(serverSide, serverConf)
+		if err != nil {
+			return
+		}
+		go ssh.DiscardRequests(reqs)
+		for ch := range chans {
+			ch.Reject(ssh.Prohibited, "no channel")
+		}
+	}()
+	client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+		User:            "test",
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestSSHTunnelEnsure(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    string
+		wantErr bool
+	}{
+		{"connected", nil, types.SSH_TUNNEL_CONNECTED, false},
+		{"failed", errors.New("dial timeout"), types.SSH_TUNNEL_FAILED, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var client *ssh.Client
+			if tt.err == nil {
+				client = newLocalSSHClient(t)
+			}
+			var dials atomic.Int32
+			release := make(chan struct{})
+			connect := func() (*ssh.Client, error) {
+				dials.Add(1)
+				<-release
+				return client, tt.err
+			}
+			m := newSSHTunnelManager(nil)
+			tunnel := m.get("conn", "hash", connect)
+
+			var wg sync.WaitGroup
+			results := make([]error, 3)
+			for i := range results {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					got, err := tunnel.ensure()
+					if err == nil && got != client {
+						err = errors.New("unexpected client")
+					}
+					results[i] = err
+				}()
+			}
+
+			// status could be queried while dialing
+			deadline := time.Now().Add(time.Second)
+			for dials.Load() <= 0 && time.Now().Before(deadline) {
+				time.Sleep(time.Millisecond)
+			}
+			done := make(chan []types.SSHTunnelStatus)
+			go func() { done <- m.List() }()
+			select {
+			case list := <-done:
+				if len(list) != 1 || list[0].Status != types.SSH_TUNNEL_CONNECTING {
+					t.Errorf("List() while dialing = %+v", list)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("List() is blocked by dialing")
+			}
+
+			close(release)
+			wg.Wait()
+			if n := dials.Load(); n != 1 {
+				t.Errorf("connect called %d times, want 1", n)
+			}
+			for _, err := range results {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("ensure() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if list := m.List(); list[0].Status != tt.want {
+				t.Errorf("status = %s, want %s", list[0].Status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"tinyrdm/backend/types"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AlertsStorage alert rules and history of fired alerts
+type AlertsStorage struct {
+	storage *localStorage
+	history *localStorage
+	mutex   sync.Mutex
+}
+
+func NewAlerts() *AlertsStorage {
+	return &AlertsStorage{
+		storage: NewLocalStore("alerts.yaml"),
+		history: NewLocalStore("alerts_history.json"),
+	}
+}
+
+func (a *AlertsStorage) defaultSettings() types.AlertSettings {
+	return types.AlertSettings{
+		Notify: true,
+		Rules:  []types.AlertRule{},
+	}
+}
+
+// GetSettings get alert settings from local
+func (a *AlertsStorage) GetSettings() (ret types.AlertSettings) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	ret = a.defaultSettings()
+	b, err := a.storage.Load()
+	if err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(b, &ret); err != nil {
+		ret = a.defaultSettings()
+	}
+	if ret.Rules == nil {
+		ret.Rules = []types.AlertRule{}
+	}
+	return
+}
+
+// SaveSettings save alert settings to local
+func (a *AlertsStorage) SaveSettings(settings types.AlertSettings) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	b, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return a.storage.Store(b)
+}
+
+// GetHistory get alert events saved before, the oldest comes first
+func (a *AlertsStorage) GetHistory() (ret []types.AlertEvent) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	b, err := a.history.Load()
+	if err != nil {
+		return
+	}
+	_ = json.Unmarshal(b, &ret)
+	return
+}
+
+// SaveHistory save alert events to local
+func (a *AlertsStorage) SaveHistory(events []types.AlertEvent) error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	b, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+	return a.history.Store(b)
+}
+
+// CleanHistory remove all alert events
+func (a *AlertsStorage) CleanHistory() error {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	return a.history.Remove()
+}
@@ -0,0 +1,182 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"os"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// name of master key in secrets storage, never conflicts with connection names which could not contain "/"
+const cipherMasterKeyName = "cipher_keys/master"
+
+// CipherKeysStorage stores key materials of cipher decoders,
+// each key is encrypted by a random master key before written to disk,
+// the master key is kept in system keyring or protected by master password like connection secrets
+type CipherKeysStorage struct {
+	storage    *localStorage
+	keyStorage *localStorage // plaintext master key written by older version, moved to secrets once loaded
+	secrets    *SecretsStorage
+	master     []byte // cached master key
+	version    uint64 // version of secrets when master key cached
+	mutex      sync.Mutex
+}
+
+func NewCipherKeys() *CipherKeysStorage {
+	return &CipherKeysStorage{
+		storage:    NewLocalStore("cipher_keys.yaml"),
+		keyStorage: NewLocalStore("cipher_keys.key"),
+		secrets:    sharedSecrets(),
+	}
+}
+
+// load or generate the master key which used to encrypt key materials
+func (c *CipherKeysStorage) masterKey() ([]byte, error) {
+	version := c.secrets.Version()
+	if c.master != nil && c.version == version {
+		return c.master, nil
+	}
+	c.master = nil
+
+	key, err := c.secrets.GetRaw(cipherMasterKeyName)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		if key, err = c.keyStorage.Load(); err == nil {
+			// move plaintext master key into secrets
+			if len(key) == 32 {
+				if err = c.secrets.SetRaw(cipherMasterKeyName, key); err != nil {
+					return nil, err
+				}
+				os.Remove(c.keyStorage.ConfPath)
+			}
+		} else if os.IsNotExist(err) {
+			key = make([]byte, 32)
+			if _, err = rand.Read(key); err != nil {
+				return nil, err
+			}
+			if err = c.secrets.SetRaw(cipherMasterKeyName, key); err != nil {
+				return nil, err
+			}
+		} else {
+			// keys saved before could not be decrypted with a new master key
+			return nil, err
+		}
+	}
+	if len(key) != 32 {
+		return nil, errors.New("invalid master key of cipher keys")
+	}
+	c.master, c.version = key, version
+	return key, nil
+}
+
+func (c *CipherKeysStorage) getKeys() map[string]string {
+	ret := map[string]string{}
+	b, err := c.storage.Load()
+	if err != nil {
+		return ret
+	}
+	if err = yaml.Unmarshal(b, &ret); err != nil || ret == nil {
+		return map[string]string{}
+	}
+	return ret
+}
+
+func (c *CipherKeysStorage) saveKeys(keys map[string]string) error {
+	b, err := yaml.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return c.storage.Store(b)
+}
+
+// GetKey get decrypted key material by decoder name
+func (c *CipherKeysStorage) GetKey(name string) ([]byte, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	encoded, ok := c.getKeys()[name]
+	if !ok {
+		return nil, errors.New("no key for decoder \"" + name + "\"")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	master, err := c.masterKey()
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(master)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid key content")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(name))
+}
+
+// SetKey encrypt and save key material of decoder
+func (c *CipherKeysStorage) SetKey(name string, key []byte) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	master, err := c.masterKey()
+	if err != nil {
+		return err
+	}
+	gcm, err := newGCM(master)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return err
+	}
+	keys := c.getKeys()
+	keys[name] = base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, key, []byte(name)))
+	return c.saveKeys(keys)
+}
+
+// DeleteKey remove key material of decoder
+func (c *CipherKeysStorage) DeleteKey(name string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys := c.getKeys()
+	if _, ok := keys[name]; !ok {
+		return nil
+	}
+	delete(keys, name)
+	return c.saveKeys(keys)
+}
+
+// ListNames list all decoder names which has key material
+func (c *CipherKeysStorage) ListNames() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	keys := c.getKeys()
+	names := make([]string, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"tinyrdm/backend/types"
+)
+
+func newTestCipherKeys(t *testing.T, secrets *SecretsStorage) *CipherKeysStorage {
+	t.Helper()
+	dir := t.TempDir()
+	return &CipherKeysStorage{
+		storage:    &localStorage{ConfPath: filepath.Join(dir, "cipher_keys.yaml")},
+		keyStorage: &localStorage{ConfPath: filepath.Join(dir, "cipher_keys.key")},
+		secrets:    secrets,
+	}
+}
+
+func TestCipherKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+	}{
+		{"keyring", types.SECRET_MODE_KEYRING},
+		{"password", types.SECRET_MODE_PASSWORD},
+		{"plain", types.SECRET_MODE_PLAIN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCipherKeys(t, newTestSecrets(t, tt.mode, "master"))
+			if err := c.SetKey("dec", []byte("material")); err != nil {
+				t.Fatalf("SetKey() error: %v", err)
+			}
+			got, err := c.GetKey("dec")
+			if err != nil || string(got) != "material" {
+				t.Fatalf("GetKey() = %q, %v", got, err)
+			}
+			// master key is never written to disk in plaintext
+			if _, err = os.Stat(c.keyStorage.ConfPath); !os.IsNotExist(err) {
+				t.Errorf("plaintext master key file exists: %v", err)
+			}
+			if profile, _ := c.secrets.getProfile(); tt.mode == types.SECRET_MODE_KEYRING && len(profile.Entries) > 0 {
+				t.Errorf("master key should be kept in keyring: %v", profile.Entries)
+			}
+		})
+	}
+}
+
+func TestCipherKeysLocked(t *testing.T) {
+	secrets := newTestSecrets(t, types.SECRET_MODE_PASSWORD, "master")
+	c := newTestCipherKeys(t, secrets)
+	if err := c.SetKey("dec", []byte("material")); err != nil {
+		t.Fatalf("SetKey() error: %v", err)
+	}
+
+	secrets.Lock()
+	if _, err := c.GetKey("dec"); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("GetKey() while locked error = %v, want %v", err, ErrSecretsLocked)
+	}
+	if err := c.SetKey("other", []byte("material")); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("SetKey() while locked error = %v, want %v", err, ErrSecretsLocked)
+	}
+
+	if err := secrets.Unlock("master"); err != nil {
+		t.Fatalf("Unlock() error: %v", err)
+	}
+	if got, err := c.GetKey("dec"); err != nil || string(got) != "material" {
+		t.Errorf("GetKey() after unlocked = %q, %v", got, err)
+	}
+}
+
+func TestCipherKeysLegacyMasterKey(t *testing.T) {
+	c := newTestCipherKeys(t, newTestSecrets(t, types.SECRET_MODE_KEYRING, ""))
+	legacy := bytes.Repeat([]byte{7}, 32)
+	if err := c.keyStorage.Store(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SetKey("dec", []byte("material")); err != nil {
+		t.Fatalf("SetKey() error: %v", err)
+	}
+	if _, err := os.Stat(c.keyStorage.ConfPath); !os.IsNotExist(err) {
+		t.Errorf("plaintext master key file should be removed: %v", err)
+	}
+	if key, err := c.secrets.GetRaw(cipherMasterKeyName); err != nil || !bytes.Equal(key, legacy) {
+		t.Errorf("master key in secrets = %x, %v, want %x", key, err, legacy)
+	}
+
+	// keys saved before are still readable by a new instance
+	c2 := &CipherKeysStorage{storage: c.storage, keyStorage: c.keyStorage, secrets: c.secrets}
+	if got, err := c2.GetKey("dec"); err != nil || string(got) != "material" {
+		t.Errorf("GetKey() = %q, %v", got, err)
+	}
+}
+
+func TestCipherKeysMasterKeyCached(t *testing.T) {
+	secrets := newTestSecrets(t, types.SECRET_MODE_PASSWORD, "master")
+	c := newTestCipherKeys(t, secrets)
+	if err := c.SetKey("dec", []byte("material")); err != nil {
+		t.Fatalf("SetKey() error: %v", err)
+	}
+
+	// cached master key is used without reading secrets again
+	os.Remove(secrets.storage.ConfPath)
+	if got, err := c.GetKey("dec"); err != nil || string(got) != "material" {
+		t.Errorf("GetKey() with cached master key = %q, %v", got, err)
+	}
+
+	// cache is dropped after locked
+	secrets.Lock()
+	if _, err := c.GetKey("dec"); err == nil {
+		t.Errorf("GetKey() should fail after locked")
+	}
+}
+
+func TestCipherKeysSwitchMode(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+	}{
+		{"keyring to password", types.SECRET_MODE_KEYRING, types.SECRET_MODE_PASSWORD},
+		{"password to keyring", types.SECRET_MODE_PASSWORD, types.SECRET_MODE_KEYRING},
+		{"password to plain", types.SECRET_MODE_PASSWORD, types.SECRET_MODE_PLAIN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secrets := newTestSecrets(t, tt.from, "master")
+			c := newTestCipherKeys(t, secrets)
+			if err := c.SetKey("dec", []byte("material")); err != nil {
+				t.Fatalf("SetKey() error: %v", err)
+			}
+			if err := secrets.SwitchMode(tt.to, "another", nil); err != nil {
+				t.Fatalf("SwitchMode() error: %v", err)
+			}
+			// read by a new instance, so that master key is loaded from secrets again
+			c2 := &CipherKeysStorage{storage: c.storage, keyStorage: c.keyStorage, secrets: secrets}
+			if got, err := c2.GetKey("dec"); err != nil || string(got) != "material" {
+				t.Errorf("GetKey() after switched = %q, %v", got, err)
+			}
+		})
+	}
+}
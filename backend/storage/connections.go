@@ -2,6 +2,7 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"slices"
 	"sync"
 	"tinyrdm/backend/consts"
@@ -12,12 +13,14 @@ import (
 
 type ConnectionsStorage struct {
 	storage *localStorage
+	secrets *SecretsStorage
 	mutex   sync.Mutex
 }
 
 func NewConnections() *ConnectionsStorage {
 	return &ConnectionsStorage{
 		storage: NewLocalStore("connections.yaml"),
+		secrets: sharedSecrets(),
 	}
 }
 
@@ -25,7 +28,7 @@ func (c *ConnectionsStorage) defaultConnections() types.Connections {
 	return types.Connections{}
 }
 
-func (c *ConnectionsStorage) defaultConnectionItem() types.ConnectionConfig {
+func (c *ConnectionsStorage) DefaultConnectionItem() types.ConnectionConfig {
 	return types.ConnectionConfig{
 		Name:            "",
 		Network:         "tcp",
@@ -88,8 +91,21 @@ func (c *ConnectionsStorage) GetConnectionsFlat() (ret types.Connections) {
 	return
 }
 
-// GetConnection get connection by name
-func (c *ConnectionsStorage) GetConnection(name string) *types.Connection {
+// GetConnection get connection by name with secrets filled,
+// error is returned if secrets could not be read(locked or keyring unavailable)
+func (c *ConnectionsStorage) GetConnection(name string) (*types.Connection, error) {
+	conn := c.getConnection(name)
+	if conn != nil && c.secrets.Mode() != types.SECRET_MODE_PLAIN {
+		secrets, err := c.secrets.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		fillSecrets(&conn.ConnectionConfig, secrets)
+	}
+	return conn, nil
+}
+
+func (c *ConnectionsStorage) getConnection(name string) *types.Connection {
 	conns := c.getConnections()
 
 	var findConn func(string, string, types.Connections) *types.Connection
@@ -140,10 +156,14 @@ func (c *ConnectionsStorage) CreateConnection(param types.ConnectionConfig) erro
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	conn := c.GetConnection(param.Name)
+	conn := c.getConnection(param.Name)
 	if conn != nil {
 		return errors.New("duplicated connection name")
 	}
+	secrets, err := c.takeSecrets(&param)
+	if err != nil {
+		return err
+	}
 
 	conns := c.getConnections()
 	var group *types.Connection
@@ -177,7 +197,15 @@ func (c *ConnectionsStorage) CreateConnection(param types.ConnectionConfig) erro
 		}
 	}
 
-	return c.saveConnections(conns)
+	// write secrets first, so that they would not be lost if failed
+	if err = c.saveSecrets(param.Name, secrets); err != nil {
+		return err
+	}
+	if err = c.saveConnections(conns); err != nil {
+		c.deleteSecrets(param.Name)
+		return err
+	}
+	return nil
 }
 
 // UpdateConnection update existing connection by name
@@ -185,6 +213,10 @@ func (c *ConnectionsStorage) UpdateConnection(name string, param types.Connectio
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
+	secrets, err := c.takeSecrets(&param)
+	if err != nil {
+		return err
+	}
 	conns := c.getConnections()
 	var updated bool
 	var retrieve func(types.Connections, string, types.ConnectionConfig) error
@@ -208,7 +240,7 @@ func (c *ConnectionsStorage) UpdateConnection(name string, param types.Connectio
 		return nil
 	}
 
-	err := retrieve(conns, name, param)
+	err = retrieve(conns, name, param)
 	if err != nil {
 		return err
 	}
@@ -216,6 +248,42 @@ func (c *ConnectionsStorage) UpdateConnection(name string, param types.Connectio
 		return errors.New("connection not found")
 	}
 
+	// write secrets first, so that they would not be lost if failed
+	if err = c.saveSecrets(param.Name, secrets); err != nil {
+		return err
+	}
+	if err = c.saveConnections(conns); err != nil {
+		if name != param.Name {
+			c.deleteSecrets(param.Name)
+		}
+		return err
+	}
+	if name != param.Name {
+		c.deleteSecrets(name)
+	}
+	return nil
+}
+
+// UpdateConnectionProfile update fields of connection kept in profile, secrets are left untouched.
+// the profile is saved only if update returns true
+func (c *ConnectionsStorage) UpdateConnectionProfile(name string, update func(conf *types.ConnectionConfig) bool) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	conns := c.getConnections()
+	var found, updated bool
+	walkConnections(conns, func(conn *types.Connection) {
+		if !found && conn.Name == name {
+			found = true
+			updated = update(&conn.ConnectionConfig)
+		}
+	})
+	if !found {
+		return fmt.Errorf("no connection named \"%s\"", name)
+	}
+	if !updated {
+		return nil
+	}
 	return c.saveConnections(conns)
 }
 
@@ -247,7 +315,11 @@ func (c *ConnectionsStorage) DeleteConnection(name string) error {
 	if !updated {
 		return errors.New("no match connection")
 	}
-	return c.saveConnections(conns)
+	if err := c.saveConnections(conns); err != nil {
+		return err
+	}
+	c.deleteSecrets(name)
+	return nil
 }
 
 // SaveSortedConnection save connection after sort
@@ -349,8 +421,387 @@ func (c *ConnectionsStorage) DeleteGroup(group string, includeConnection bool) e
 			if includeConnection {
 				conns = append(conns, conn.Connections...)
 			}
-			return c.saveConnections(conns)
+			if err := c.saveConnections(conns); err != nil {
+				return err
+			}
+			if !includeConnection {
+				for _, subConn := range conn.Connections {
+					c.deleteSecrets(subConn.Name)
+				}
+			}
+			return nil
 		}
 	}
 	return errors.New("group not found")
 }
+
+// takeSecrets clear secrets in connection config and return them,
+// nothing is taken if secrets are kept in profile
+func (c *ConnectionsStorage) takeSecrets(conf *types.ConnectionConfig) (ret types.ConnectionSecrets, err error) {
+	if c.secrets.Locked() {
+		err = ErrSecretsLocked
+		return
+	}
+	if c.secrets.Mode() == types.SECRET_MODE_PLAIN {
+		return
+	}
+	ret = secretsOf(conf)
+	fillSecrets(conf, types.ConnectionSecrets{})
+	return
+}
+
+func secretsOf(conf *types.ConnectionConfig) types.ConnectionSecrets {
+	ret := types.ConnectionSecrets{
+		Password:         conf.Password,
+		SSHPassword:      conf.SSH.Password,
+		SSHPassphrase:    conf.SSH.Passphrase,
+		SentinelPassword: conf.Sentinel.Password,
+		ProxyPassword:    conf.Proxy.Password,
+		SSLKey:           conf.SSL.Key,
+		SSLKeyPassphrase: conf.SSL.KeyPassphrase,
+	}
+	var hasJumpSecrets bool
+	jumpSecrets := make([]types.SSHJumpHostSecrets, len(conf.SSH.JumpHosts))
+	for i, jump := range conf.SSH.JumpHosts {
+		jumpSecrets[i] = types.SSHJumpHostSecrets{
+			Password:   jump.Password,
+			Passphrase: jump.Passphrase,
+		}
+		hasJumpSecrets = hasJumpSecrets || jumpSecrets[i] != (types.SSHJumpHostSecrets{})
+	}
+	if hasJumpSecrets {
+		ret.SSHJumpHosts = jumpSecrets
+	}
+	return ret
+}
+
+func fillSecrets(conf *types.ConnectionConfig, secrets types.ConnectionSecrets) {
+	conf.Password = secrets.Password
+	conf.SSH.Password = secrets.SSHPassword
+	conf.SSH.Passphrase = secrets.SSHPassphrase
+	conf.Sentinel.Password = secrets.SentinelPassword
+	conf.Proxy.Password = secrets.ProxyPassword
+	conf.SSL.Key = secrets.SSLKey
+	conf.SSL.KeyPassphrase = secrets.SSLKeyPassphrase
+	for i := range conf.SSH.JumpHosts {
+		var jumpSecrets types.SSHJumpHostSecrets
+		if i < len(secrets.SSHJumpHosts) {
+			jumpSecrets = secrets.SSHJumpHosts[i]
+		}
+		conf.SSH.JumpHosts[i].Password = jumpSecrets.Password
+		conf.SSH.JumpHosts[i].Passphrase = jumpSecrets.Passphrase
+	}
+}
+
+// saveSecrets save secrets edited in connection dialog,
+// which are removed from storage only if all fields are cleared by user
+func (c *ConnectionsStorage) saveSecrets(name string, secrets types.ConnectionSecrets) error {
+	if c.secrets.Mode() == types.SECRET_MODE_PLAIN {
+		return nil
+	}
+	if secrets.IsEmpty() {
+		return c.secrets.Delete(name)
+	}
+	return c.secrets.Set(name, secrets)
+}
+
+func (c *ConnectionsStorage) deleteSecrets(name string) {
+	if c.secrets.Mode() != types.SECRET_MODE_PLAIN {
+		c.secrets.Delete(name)
+	}
+}
+
+// walk through all connections exclude group level
+func walkConnections(conns types.Connections, fn func(conn *types.Connection)) {
+	for i := range conns {
+		if conns[i].Type == "group" {
+			walkConnections(conns[i].Connections, fn)
+		} else {
+			fn(&conns[i])
+		}
+	}
+}
+
+// MigrateSecrets move plaintext secrets left in profile to secret storage
+func (c *ConnectionsStorage) MigrateSecrets() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.secrets.Init()
+	if c.secrets.Mode() == types.SECRET_MODE_PLAIN || c.secrets.Locked() {
+		return nil
+	}
+
+	conns := c.getConnections()
+	var migrated bool
+	var err error
+	walkConnections(conns, func(conn *types.Connection) {
+		if err != nil {
+			return
+		}
+		var secrets types.ConnectionSecrets
+		if secrets, err = c.takeSecrets(&conn.ConnectionConfig); err != nil || secrets.IsEmpty() {
+			return
+		}
+		if err = c.secrets.Set(conn.Name, secrets); err != nil {
+			return
+		}
+		migrated = true
+	})
+	if !migrated {
+		return err
+	}
+	// save profile even if partially migrated, so that stored secrets are not left in plaintext
+	if serr := c.saveConnections(conns); serr != nil {
+		return serr
+	}
+	return err
+}
+
+// SecretStatus get current mode of secret storage
+func (c *ConnectionsStorage) SecretStatus() types.SecretStatus {
+	return c.secrets.Status()
+}
+
+// SecretsLocked check if master password is required, without probing system keyring
+func (c *ConnectionsStorage) SecretsLocked() bool {
+	return c.secrets.Locked()
+}
+
+// UnlockSecrets unlock secret storage with master password
+func (c *ConnectionsStorage) UnlockSecrets(password string) error {
+	if err := c.secrets.Unlock(password); err != nil {
+		return err
+	}
+	return c.MigrateSecrets()
+}
+
+// LockSecrets lock secret storage, master password is required to access again
+func (c *ConnectionsStorage) LockSecrets() {
+	c.secrets.Lock()
+}
+
+// SwitchSecretMode move all secrets of connections to specified storage mode
+func (c *ConnectionsStorage) SwitchSecretMode(mode, password string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.secrets.Locked() {
+		return ErrSecretsLocked
+	}
+	conns := c.getConnections()
+	secrets := map[string]types.ConnectionSecrets{}
+	var err error
+	walkConnections(conns, func(conn *types.Connection) {
+		if err != nil {
+			return
+		}
+		// secrets may be still left in profile before migration
+		secret := secretsOf(&conn.ConnectionConfig)
+		if secret.IsEmpty() && c.secrets.Mode() != types.SECRET_MODE_PLAIN {
+			secret, err = c.secrets.Get(conn.Name)
+		}
+		secrets[conn.Name] = secret
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = c.secrets.SwitchMode(mode, password, secrets); err != nil {
+		return err
+	}
+	walkConnections(conns, func(conn *types.Connection) {
+		if mode == types.SECRET_MODE_PLAIN {
+			fillSecrets(&conn.ConnectionConfig, secrets[conn.Name])
+		} else {
+			fillSecrets(&conn.ConnectionConfig, types.ConnectionSecrets{})
+		}
+	})
+	return c.saveConnections(conns)
+}
+
+// ExportConnections get selected connections or groups, all connections are selected if names is empty
+// secrets are kept in connections, stripped, or returned separately for encryption
+func (c *ConnectionsStorage) ExportConnections(names []string, secretMode string) (ret types.Connections, secrets map[string]types.ConnectionSecrets, err error) {
+	withSecrets := secretMode != types.EXPORT_SECRET_STRIP
+	if withSecrets && c.secrets.Locked() {
+		err = ErrSecretsLocked
+		return
+	}
+
+	selected := func(name string) bool {
+		return len(names) <= 0 || slices.Contains(names, name)
+	}
+	conns := c.getConnections()
+	for _, conn := range conns {
+		if conn.Type == "group" {
+			if selected(conn.Name) {
+				ret = append(ret, conn)
+			} else {
+				// export part of connections in group
+				var subConns types.Connections
+				for _, subConn := range conn.Connections {
+					if selected(subConn.Name) {
+						subConns = append(subConns, subConn)
+					}
+				}
+				if len(subConns) > 0 {
+					conn.Connections = subConns
+					ret = append(ret, conn)
+				}
+			}
+		} else if selected(conn.Name) {
+			ret = append(ret, conn)
+		}
+	}
+
+	secrets = map[string]types.ConnectionSecrets{}
+	mode := c.secrets.Mode()
+	walkConnections(ret, func(conn *types.Connection) {
+		secret := secretsOf(&conn.ConnectionConfig)
+		fillSecrets(&conn.ConnectionConfig, types.ConnectionSecrets{})
+		if !withSecrets || err != nil {
+			return
+		}
+		if secret.IsEmpty() && mode != types.SECRET_MODE_PLAIN {
+			if secret, err = c.secrets.Get(conn.Name); err != nil {
+				return
+			}
+		}
+		if secretMode == types.EXPORT_SECRET_ENCRYPT {
+			if !secret.IsEmpty() {
+				secrets[conn.Name] = secret
+			}
+		} else {
+			fillSecrets(&conn.ConnectionConfig, secret)
+		}
+	})
+	return
+}
+
+// MergeConnections merge imported connections into local profile,
+// collided connection is skipped, renamed or overwritten according to resolutions
+func (c *ConnectionsStorage) MergeConnections(imported types.Connections, secrets map[string]types.ConnectionSecrets,
+	resolutions map[string]string) (ret types.ImportConnectionResult, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.secrets.Locked() {
+		err = ErrSecretsLocked
+		return
+	}
+
+	conns := c.getConnections()
+	names := map[string]bool{}
+	walkConnections(conns, func(conn *types.Connection) {
+		names[conn.Name] = true
+	})
+	collided := func(name string) bool {
+		return names[name]
+	}
+
+	// remove local connections which will be overwritten
+	overwrite := map[string]bool{}
+	walkConnections(imported, func(conn *types.Connection) {
+		if collided(conn.Name) && resolutions[conn.Name] == types.IMPORT_OVERWRITE {
+			overwrite[conn.Name] = true
+		}
+	})
+	removeOverwritten := func(cs types.Connections) types.Connections {
+		return slices.DeleteFunc(cs, func(conn types.Connection) bool {
+			return conn.Type != "group" && overwrite[conn.Name]
+		})
+	}
+	conns = removeOverwritten(conns)
+	for i := range conns {
+		if conns[i].Type == "group" {
+			conns[i].Connections = removeOverwritten(conns[i].Connections)
+		}
+	}
+
+	mode := c.secrets.Mode()
+	pendingSecrets := map[string]types.ConnectionSecrets{}
+	resolve := func(conn types.Connection) (types.Connection, bool) {
+		name := conn.Name
+		if collided(name) {
+			switch resolutions[name] {
+			case types.IMPORT_OVERWRITE:
+				ret.Overwritten = append(ret.Overwritten, name)
+			case types.IMPORT_RENAME:
+				newName := name
+				for i := 2; names[newName]; i++ {
+					newName = fmt.Sprintf("%s (%d)", name, i)
+				}
+				conn.Name = newName
+				if ret.Renamed == nil {
+					ret.Renamed = map[string]string{}
+				}
+				ret.Renamed[name] = newName
+			default:
+				ret.Skipped = append(ret.Skipped, name)
+				return conn, false
+			}
+		}
+		names[conn.Name] = true
+
+		// secrets in bundle take precedence over the ones left in profile
+		secret := secretsOf(&conn.ConnectionConfig)
+		if s, ok := secrets[name]; ok {
+			secret = s
+		}
+		if mode == types.SECRET_MODE_PLAIN {
+			fillSecrets(&conn.ConnectionConfig, secret)
+		} else {
+			fillSecrets(&conn.ConnectionConfig, types.ConnectionSecrets{})
+			pendingSecrets[conn.Name] = secret
+		}
+		ret.Imported = append(ret.Imported, conn.Name)
+		return types.Connection{ConnectionConfig: conn.ConnectionConfig}, true
+	}
+
+	for _, item := range imported {
+		if item.Type == "group" {
+			groupIndex := slices.IndexFunc(conns, func(conn types.Connection) bool {
+				return conn.Type == "group" && conn.Name == item.Name
+			})
+			if groupIndex < 0 {
+				conns = append(conns, types.Connection{
+					ConnectionConfig: types.ConnectionConfig{
+						Name: item.Name,
+					},
+					Type: "group",
+				})
+				groupIndex = len(conns) - 1
+			}
+			for _, subConn := range item.Connections {
+				if conn, ok := resolve(subConn); ok {
+					conns[groupIndex].Connections = append(conns[groupIndex].Connections, conn)
+				}
+			}
+		} else if conn, ok := resolve(item); ok {
+			conns = append(conns, conn)
+		}
+	}
+
+	// write secrets first, so that they would not be lost if failed.
+	// secrets of overwritten connection are kept if none is imported
+	var saved []string
+	for name, secret := range pendingSecrets {
+		if err = c.secrets.Set(name, secret); err != nil {
+			break
+		}
+		saved = append(saved, name)
+	}
+	if err == nil {
+		err = c.saveConnections(conns)
+	}
+	if err != nil {
+		// remove secrets of connections which are not imported
+		for _, name := range saved {
+			if !overwrite[name] {
+				c.deleteSecrets(name)
+			}
+		}
+	}
+	return
+}
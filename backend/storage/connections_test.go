@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"tinyrdm/backend/types"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestMergeConnections(t *testing.T) {
+	c := newTestConnections(t, types.SECRET_MODE_KEYRING, "")
+	for _, name := range []string{"a", "b", "c"} {
+		if err := c.CreateConnection(types.ConnectionConfig{Name: name, Password: "old-" + name}); err != nil {
+			t.Fatalf("CreateConnection() error: %v", err)
+		}
+	}
+
+	imported := types.Connections{
+		{ConnectionConfig: types.ConnectionConfig{Name: "a"}},
+		{ConnectionConfig: types.ConnectionConfig{Name: "b"}},
+		{Type: "group", ConnectionConfig: types.ConnectionConfig{Name: "g"}, Connections: types.Connections{
+			{ConnectionConfig: types.ConnectionConfig{Name: "c", Password: "in-profile"}},
+			{ConnectionConfig: types.ConnectionConfig{Name: "d"}},
+		}},
+	}
+	secrets := map[string]types.ConnectionSecrets{
+		"b": {Password: "new-b"},
+		"d": {Password: "new-d"},
+	}
+	resolutions := map[string]string{
+		"a": types.IMPORT_SKIP,
+		"b": types.IMPORT_OVERWRITE,
+		"c": types.IMPORT_RENAME,
+	}
+	ret, err := c.MergeConnections(imported, secrets, resolutions)
+	if err != nil {
+		t.Fatalf("MergeConnections() error: %v", err)
+	}
+	if len(ret.Skipped) != 1 || len(ret.Overwritten) != 1 || ret.Renamed["c"] != "c (2)" || len(ret.Imported) != 3 {
+		t.Errorf("MergeConnections() = %+v", ret)
+	}
+
+	wantPasswords := map[string]string{
+		"a":     "old-a",
+		"b":     "new-b",
+		"c":     "old-c",
+		"c (2)": "in-profile",
+		"d":     "new-d",
+	}
+	for name, want := range wantPasswords {
+		conn, err := c.GetConnection(name)
+		if err != nil || conn == nil {
+			t.Fatalf("GetConnection(%s) = %v, %v", name, conn, err)
+		}
+		if conn.Password != want {
+			t.Errorf("password of %s = %q, want %q", name, conn.Password, want)
+		}
+		if saved := c.getConnection(name); saved.Password != "" {
+			t.Errorf("password of %s should not be kept in profile", name)
+		}
+	}
+}
+
+func TestMergeConnectionsSecretsFailed(t *testing.T) {
+	c := newTestConnections(t, types.SECRET_MODE_KEYRING, "")
+	keyring.MockInitWithError(errors.New("keyring is unavailable"))
+
+	imported := types.Connections{
+		{ConnectionConfig: types.ConnectionConfig{Name: "a", Password: "pwd"}},
+	}
+	if _, err := c.MergeConnections(imported, nil, nil); err == nil {
+		t.Fatalf("MergeConnections() should fail if secrets could not be written")
+	}
+	// profile is not written if secrets are lost
+	if conn := c.getConnection("a"); conn != nil {
+		t.Errorf("connection should not be imported: %+v", conn)
+	}
+}
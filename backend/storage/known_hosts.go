@@ -0,0 +1,165 @@
+package storage
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// HostKeyError host key of ssh server is unknown or mismatched with known_hosts
+type HostKeyError struct {
+	Host        string   `json:"host"`
+	KeyType     string   `json:"keyType"`
+	Fingerprint string   `json:"fingerprint"`
+	Changed     bool     `json:"changed"`         // key mismatched with a known one
+	Known       []string `json:"known,omitempty"` // known entries mismatched, as "file:line"
+}
+
+func (e *HostKeyError) Error() string {
+	if e.Changed {
+		return fmt.Sprintf("host key of ssh server %s has changed (%s %s), mismatched with %s. "+
+			"it may be a man-in-the-middle attack, remove the stale entries if the change is expected",
+			e.Host, e.KeyType, e.Fingerprint, strings.Join(e.Known, ", "))
+	}
+	return fmt.Sprintf("unknown host key of ssh server %s (%s %s)", e.Host, e.KeyType, e.Fingerprint)
+}
+
+// KnownHostsStorage verify ssh host keys with ~/.ssh/known_hosts and the one managed by app
+type KnownHostsStorage struct {
+	storage *localStorage
+	pending map[string]ssh.PublicKey // unknown keys waiting for trust, key by host and fingerprint
+	mutex   sync.Mutex
+}
+
+func NewKnownHosts() *KnownHostsStorage {
+	return &KnownHostsStorage{
+		storage: NewLocalStore("known_hosts"),
+		pending: map[string]ssh.PublicKey{},
+	}
+}
+
+// files returns exist known_hosts files
+func (k *KnownHostsStorage) files() (ret []string) {
+	candidates := []string{k.storage.ConfPath}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, ".ssh", "known_hosts"))
+	}
+	for _, file := range candidates {
+		if _, err := os.Stat(file); err == nil {
+			ret = append(ret, file)
+		}
+	}
+	return
+}
+
+func pendingHostKey(host, fingerprint string) string {
+	return knownhosts.Normalize(host) + " " + fingerprint
+}
+
+// HostKeyCallback returns callback for verifying host key, unknown key is recorded for later trust
+func (k *KnownHostsStorage) HostKeyCallback() ssh.HostKeyCallback {
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		k.mutex.Lock()
+		defer k.mutex.Unlock()
+
+		var keyErr *knownhosts.KeyError
+		if files := k.files(); len(files) > 0 {
+			callback, err := knownhosts.New(files...)
+			if err != nil {
+				return err
+			}
+			if err = callback(hostname, remote, key); err == nil || !errors.As(err, &keyErr) {
+				return err
+			}
+		}
+
+		hostKeyErr := &HostKeyError{
+			Host:        hostname,
+			KeyType:     key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+		}
+		if keyErr != nil && len(keyErr.Want) > 0 {
+			hostKeyErr.Changed = true
+			for _, want := range keyErr.Want {
+				hostKeyErr.Known = append(hostKeyErr.Known, fmt.Sprintf("%s:%d", want.Filename, want.Line))
+			}
+		} else {
+			k.pending[pendingHostKey(hostname, hostKeyErr.Fingerprint)] = key
+		}
+		return hostKeyErr
+	}
+}
+
+// HostKeyAlgorithms returns algorithms of known keys for host, prefer them in handshake
+// to avoid treating key of another type as changed
+func (k *KnownHostsStorage) HostKeyAlgorithms(hostname string) (ret []string) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	files := k.files()
+	if len(files) <= 0 {
+		return
+	}
+	callback, err := knownhosts.New(files...)
+	if err != nil {
+		return
+	}
+	// check with a random key to list all known keys
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return
+	}
+	probe, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return
+	}
+	var keyErr *knownhosts.KeyError
+	if err = callback(hostname, &net.TCPAddr{IP: net.IPv4zero}, probe); !errors.As(err, &keyErr) {
+		return
+	}
+	for _, want := range keyErr.Want {
+		switch keyType := want.Key.Type(); keyType {
+		case ssh.KeyAlgoRSA:
+			ret = append(ret, ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA)
+		default:
+			ret = append(ret, keyType)
+		}
+	}
+	return
+}
+
+// Trust append unknown host key to known_hosts managed by app,
+// only the keys rejected by HostKeyCallback as unknown could be trusted
+func (k *KnownHostsStorage) Trust(host, fingerprint string) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	pendingKey := pendingHostKey(host, fingerprint)
+	key, ok := k.pending[pendingKey]
+	if !ok {
+		return errors.New("no pending host key to trust")
+	}
+
+	data, err := k.storage.Load()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(data) > 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	data = append(data, knownhosts.Line([]string{knownhosts.Normalize(host)}, key)+"\n"...)
+	if err = k.storage.Store(data); err != nil {
+		return err
+	}
+	delete(k.pending, pendingKey)
+	return nil
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newTestHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// newTestKnownHosts use known_hosts in temporary home, with lines written to ~/.ssh/known_hosts
+func newTestKnownHosts(t *testing.T, userLines ...string) *KnownHostsStorage {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if len(userLines) > 0 {
+		os.MkdirAll(filepath.Join(home, ".ssh"), 0700)
+		var content string
+		for _, line := range userLines {
+			content += line + "\n"
+		}
+		if err := os.WriteFile(filepath.Join(home, ".ssh", "known_hosts"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &KnownHostsStorage{
+		storage: &localStorage{ConfPath: filepath.Join(t.TempDir(), "known_hosts")},
+		pending: map[string]ssh.PublicKey{},
+	}
+}
+
+func TestKnownHostsCallback(t *testing.T) {
+	const host = "ssh.local:22"
+	remote := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 22}
+	key := newTestHostKey(t)
+	tests := []struct {
+		name        string
+		userLines   []string
+		wantErr     bool
+		wantChanged bool
+	}{
+		{"known", []string{knownhosts.Line([]string{"ssh.local"}, key)}, false, false},
+		{"unknown", nil, true, false},
+		{"other host known", []string{knownhosts.Line([]string{"other.local"}, newTestHostKey(t))}, true, false},
+		{"changed", []string{"# comment", knownhosts.Line([]string{"ssh.local"}, newTestHostKey(t))}, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := newTestKnownHosts(t, tt.userLines...)
+			err := k.HostKeyCallback()(host, remote, key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HostKeyCallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+			var hostKeyErr *HostKeyError
+			if !errors.As(err, &hostKeyErr) {
+				t.Fatalf("HostKeyCallback() error = %v, want HostKeyError", err)
+			}
+			if hostKeyErr.Changed != tt.wantChanged || hostKeyErr.Fingerprint != ssh.FingerprintSHA256(key) {
+				t.Errorf("HostKeyCallback() error = %+v", hostKeyErr)
+			}
+			if tt.wantChanged && (len(hostKeyErr.Known) != 1 || filepath.Base(hostKeyErr.Known[0]) != "known_hosts:2") {
+				t.Errorf("mismatched entries = %v", hostKeyErr.Known)
+			}
+
+			// only unknown key could be trusted
+			err = k.Trust(host, hostKeyErr.Fingerprint)
+			if (err != nil) != tt.wantChanged {
+				t.Fatalf("Trust() error = %v", err)
+			}
+			if err == nil {
+				if err = k.HostKeyCallback()(host, remote, key); err != nil {
+					t.Errorf("HostKeyCallback() after trusted error = %v", err)
+				}
+				if err = k.Trust(host, hostKeyErr.Fingerprint); err == nil {
+					t.Errorf("Trust() twice should fail")
+				}
+			}
+		})
+	}
+}
+
+func TestKnownHostsTrustUnrequested(t *testing.T) {
+	k := newTestKnownHosts(t)
+	if err := k.Trust("ssh.local:22", ssh.FingerprintSHA256(newTestHostKey(t))); err == nil {
+		t.Errorf("Trust() of key never rejected should fail")
+	}
+}
+
+func TestKnownHostsAlgorithms(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPub, err := ssh.NewPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := newTestKnownHosts(t,
+		knownhosts.Line([]string{"ssh.local"}, newTestHostKey(t)),
+		knownhosts.Line([]string{"[ssh.local]:2222"}, ecPub),
+	)
+	tests := []struct {
+		host string
+		want []string
+	}{
+		{"ssh.local:22", []string{ssh.KeyAlgoED25519}},
+		{"ssh.local:2222", []string{ssh.KeyAlgoECDSA256}},
+		{"other.local:22", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := k.HostKeyAlgorithms(tt.host); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("HostKeyAlgorithms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
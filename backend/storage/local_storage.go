@@ -45,6 +45,15 @@ func (l *localStorage) Store(data []byte) error {
 	return nil
 }
 
+// Remove deletes the file in the user's configuration directory, it's ok if
+// the file does not exist.
+func (l *localStorage) Remove() error {
+	if err := os.Remove(l.ConfPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // ensureDirExists checks for the existence of the directory at the given path,
 // which is created if it does not exist.
 func ensureDirExists(path string) error {
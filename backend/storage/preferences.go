@@ -52,6 +52,12 @@ func (p *PreferencesStorage) GetPreferences() (ret types.Preferences) {
 	if ret.General.ScanSize <= 0 {
 		ret.General.ScanSize = consts.DEFAULT_SCAN_SIZE
 	}
+	if ret.Metrics.Interval <= 0 {
+		ret.Metrics.Interval = consts.DEFAULT_METRICS_INTERVAL
+	}
+	if ret.Metrics.Capacity <= 0 {
+		ret.Metrics.Capacity = consts.DEFAULT_METRICS_CAPACITY
+	}
 	ret.Behavior.AsideWidth = max(ret.Behavior.AsideWidth, consts.DEFAULT_ASIDE_WIDTH)
 	ret.Behavior.WindowWidth = max(ret.Behavior.WindowWidth, consts.MIN_WINDOW_WIDTH)
 	ret.Behavior.WindowHeight = max(ret.Behavior.WindowHeight, consts.MIN_WINDOW_HEIGHT)
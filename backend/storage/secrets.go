@@ -0,0 +1,618 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"tinyrdm/backend/consts"
+	"tinyrdm/backend/types"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/crypto/argon2"
+	"gopkg.in/yaml.v3"
+)
+
+var ErrSecretsLocked = errors.New("saved secrets are locked, unlock with master password first")
+var ErrMasterPassword = errors.New("incorrect master password")
+
+const secretsCheckContent = "tinyrdm"
+const keyringProbeName = "__tinyrdm_probe__"
+
+type secretsKdf struct {
+	Salt    string `yaml:"salt"`
+	Time    uint32 `yaml:"time"`
+	Memory  uint32 `yaml:"memory"`
+	Threads uint8  `yaml:"threads"`
+}
+
+type secretsProfile struct {
+	Mode    string            `yaml:"mode"`
+	Kdf     *secretsKdf       `yaml:"kdf,omitempty"`
+	Check   string            `yaml:"check,omitempty"`
+	Entries map[string]string `yaml:"entries,omitempty"`
+	Raw     []string          `yaml:"raw,omitempty"` // names of raw entries, moved together when switching mode
+}
+
+// SecretsStorage stores connection secrets in system keyring,
+// or in local file encrypted by key derived from master password with Argon2id
+type SecretsStorage struct {
+	storage *localStorage
+	key     []byte // derived key of master password, nil if locked
+	version uint64 // increased when locked, unlocked or mode switched
+	mutex   sync.Mutex
+}
+
+func NewSecrets() *SecretsStorage {
+	return &SecretsStorage{
+		storage: NewLocalStore("secrets.yaml"),
+	}
+}
+
+var secretsOnce sync.Once
+var secrets *SecretsStorage
+
+// sharedSecrets returns secret storage shared by connections and cipher keys, so that they are unlocked together
+func sharedSecrets() *SecretsStorage {
+	secretsOnce.Do(func() {
+		secrets = NewSecrets()
+	})
+	return secrets
+}
+
+func (s *SecretsStorage) getProfile() (ret secretsProfile, exists bool) {
+	b, err := s.storage.Load()
+	if err != nil {
+		return
+	}
+	if err = yaml.Unmarshal(b, &ret); err != nil {
+		return secretsProfile{}, false
+	}
+	exists = true
+	return
+}
+
+func (s *SecretsStorage) saveProfile(profile secretsProfile) error {
+	b, err := yaml.Marshal(&profile)
+	if err != nil {
+		return err
+	}
+	return s.storage.Store(b)
+}
+
+// Init choose system keyring for new profile if it's available
+func (s *SecretsStorage) Init() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.getProfile(); exists {
+		return
+	}
+	s.saveProfile(newSecretsProfile())
+}
+
+func newSecretsProfile() secretsProfile {
+	mode := types.SECRET_MODE_PLAIN
+	if keyringAvailable() {
+		mode = types.SECRET_MODE_KEYRING
+	}
+	return secretsProfile{Mode: mode}
+}
+
+// Version returns a number which is changed once secrets become unavailable or moved,
+// content read before should be reloaded if changed
+func (s *SecretsStorage) Version() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.version
+}
+
+func (s *SecretsStorage) Mode() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	return profile.Mode
+}
+
+// Locked check if master password is required before accessing secrets
+func (s *SecretsStorage) Locked() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	return profile.Mode == types.SECRET_MODE_PASSWORD && s.key == nil
+}
+
+func (s *SecretsStorage) Status() types.SecretStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	return types.SecretStatus{
+		Mode:             profile.Mode,
+		Locked:           profile.Mode == types.SECRET_MODE_PASSWORD && s.key == nil,
+		KeyringAvailable: keyringAvailable(),
+	}
+}
+
+// Unlock derive key from master password and verify it
+func (s *SecretsStorage) Unlock(password string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	if profile.Mode != types.SECRET_MODE_PASSWORD || profile.Kdf == nil {
+		return nil
+	}
+	key, err := deriveKey(password, profile.Kdf)
+	if err != nil {
+		return err
+	}
+	if check, err := openSecret(key, profile.Check, "check"); err != nil || string(check) != secretsCheckContent {
+		return ErrMasterPassword
+	}
+	s.key = key
+	s.version += 1
+	return nil
+}
+
+// Lock forget the derived key
+func (s *SecretsStorage) Lock() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.key = nil
+	s.version += 1
+}
+
+// Get read secrets of connection, empty secrets returned if not found
+func (s *SecretsStorage) Get(name string) (ret types.ConnectionSecrets, err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	return s.get(profile, name)
+}
+
+func (s *SecretsStorage) get(profile secretsProfile, name string) (ret types.ConnectionSecrets, err error) {
+	if profile.Mode == types.SECRET_MODE_PLAIN {
+		// secrets are kept in connection profile
+		return
+	}
+	var content []byte
+	if content, err = s.getContent(profile, name); err != nil || content == nil {
+		return
+	}
+	err = json.Unmarshal(content, &ret)
+	return
+}
+
+// getContent read content of entry, nil returned if not found
+func (s *SecretsStorage) getContent(profile secretsProfile, name string) ([]byte, error) {
+	switch profile.Mode {
+	case types.SECRET_MODE_KEYRING:
+		str, err := keyringGet(name)
+		if err != nil {
+			if errors.Is(err, keyring.ErrNotFound) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		return []byte(str), nil
+	case types.SECRET_MODE_PASSWORD:
+		if s.key == nil {
+			return nil, ErrSecretsLocked
+		}
+		encrypted, ok := profile.Entries[name]
+		if !ok {
+			return nil, nil
+		}
+		return openSecret(s.key, encrypted, name)
+	default:
+		// nothing could protect content in plain mode
+		content, ok := profile.Entries[name]
+		if !ok {
+			return nil, nil
+		}
+		return []byte(content), nil
+	}
+}
+
+// Set save secrets of connection, nothing is changed if secrets is empty,
+// use Delete to remove the entry
+func (s *SecretsStorage) Set(name string, secrets types.ConnectionSecrets) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	if err := s.set(&profile, name, secrets); err != nil {
+		return err
+	}
+	if profile.Mode == types.SECRET_MODE_PASSWORD {
+		return s.saveProfile(profile)
+	}
+	return nil
+}
+
+func (s *SecretsStorage) set(profile *secretsProfile, name string, secrets types.ConnectionSecrets) error {
+	if secrets.IsEmpty() || profile.Mode == types.SECRET_MODE_PLAIN {
+		return nil
+	}
+	content, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	return s.setContent(profile, name, content)
+}
+
+func (s *SecretsStorage) setContent(profile *secretsProfile, name string, content []byte) error {
+	switch profile.Mode {
+	case types.SECRET_MODE_KEYRING:
+		return keyringSet(name, string(content))
+	case types.SECRET_MODE_PASSWORD:
+		if s.key == nil {
+			return ErrSecretsLocked
+		}
+		encrypted, err := sealSecret(s.key, content, name)
+		if err != nil {
+			return err
+		}
+		if profile.Entries == nil {
+			profile.Entries = map[string]string{}
+		}
+		profile.Entries[name] = encrypted
+	default:
+		if profile.Entries == nil {
+			profile.Entries = map[string]string{}
+		}
+		profile.Entries[name] = string(content)
+	}
+	return nil
+}
+
+// GetRaw read content saved by SetRaw, nil returned if not found
+func (s *SecretsStorage) GetRaw(name string) ([]byte, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	content, err := s.getContent(profile, name)
+	if err != nil || content == nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(string(content))
+}
+
+// SetRaw save content which is not secrets of connection(e.g. master key of cipher decoders),
+// it's encoded so that binary content could be saved in system keyring
+func (s *SecretsStorage) SetRaw(name string, content []byte) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, exists := s.getProfile()
+	if !exists {
+		profile = newSecretsProfile()
+	}
+	if err := s.setContent(&profile, name, []byte(base64.StdEncoding.EncodeToString(content))); err != nil {
+		return err
+	}
+	if !slices.Contains(profile.Raw, name) {
+		profile.Raw = append(profile.Raw, name)
+	} else if profile.Mode == types.SECRET_MODE_KEYRING && exists {
+		return nil
+	}
+	return s.saveProfile(profile)
+}
+
+// Delete remove secrets of connection
+func (s *SecretsStorage) Delete(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	profile, _ := s.getProfile()
+	if err := s.delete(&profile, name); err != nil {
+		return err
+	}
+	if profile.Mode == types.SECRET_MODE_PASSWORD {
+		return s.saveProfile(profile)
+	}
+	return nil
+}
+
+func (s *SecretsStorage) delete(profile *secretsProfile, name string) error {
+	switch profile.Mode {
+	case types.SECRET_MODE_KEYRING:
+		if err := keyringDelete(name); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return err
+		}
+	default:
+		delete(profile.Entries, name)
+	}
+	return nil
+}
+
+// SwitchMode move all secrets to another storage mode,
+// the master password is required when switch to password mode
+func (s *SecretsStorage) SwitchMode(mode, password string, secrets map[string]types.ConnectionSecrets) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	oldProfile, _ := s.getProfile()
+	if oldProfile.Mode == types.SECRET_MODE_PASSWORD && s.key == nil {
+		return ErrSecretsLocked
+	}
+
+	newProfile := secretsProfile{Mode: mode, Raw: oldProfile.Raw}
+	oldKey, newKey := s.key, []byte(nil)
+	switch mode {
+	case types.SECRET_MODE_KEYRING:
+		if !keyringAvailable() {
+			return errors.New("system keyring is unavailable")
+		}
+	case types.SECRET_MODE_PASSWORD:
+		if len(password) <= 0 {
+			return errors.New("master password is required")
+		}
+		var err error
+		if newProfile.Kdf, err = newKdf(); err != nil {
+			return err
+		}
+		if newKey, err = deriveKey(password, newProfile.Kdf); err != nil {
+			return err
+		}
+		if newProfile.Check, err = sealSecret(newKey, []byte(secretsCheckContent), "check"); err != nil {
+			return err
+		}
+	case types.SECRET_MODE_PLAIN:
+	default:
+		return errors.New("unknown secret storage mode")
+	}
+
+	// raw entries are read before switching
+	raw := map[string][]byte{}
+	for _, name := range oldProfile.Raw {
+		content, err := s.getContent(oldProfile, name)
+		if err != nil {
+			return err
+		}
+		if content != nil {
+			raw[name] = content
+		}
+	}
+
+	// write secrets to new storage first
+	s.key = newKey
+	for name, content := range raw {
+		if err := s.setContent(&newProfile, name, content); err != nil {
+			s.key = oldKey
+			return err
+		}
+	}
+	for name, secret := range secrets {
+		if err := s.set(&newProfile, name, secret); err != nil {
+			s.key = oldKey
+			return err
+		}
+	}
+	if err := s.saveProfile(newProfile); err != nil {
+		s.key = oldKey
+		return err
+	}
+	s.version += 1
+
+	// clean secrets in system keyring if no longer used
+	if oldProfile.Mode == types.SECRET_MODE_KEYRING && mode != types.SECRET_MODE_KEYRING {
+		for name := range secrets {
+			s.delete(&oldProfile, name)
+		}
+		for name := range raw {
+			s.delete(&oldProfile, name)
+		}
+	}
+	return nil
+}
+
+// keyringChunkSize max size of each keyring item, credential blob is limited to 2560 bytes on Windows.
+// larger content(like inline private key) is split into multiple items
+const keyringChunkSize = 2000
+
+const keyringChunksPrefix = "chunks:"
+
+func keyringChunkName(name string, i int) string {
+	return fmt.Sprintf("%s#%d", name, i)
+}
+
+// keyringChunks returns count of chunks if the item is split, 0 if not
+func keyringChunks(str string) int {
+	if !strings.HasPrefix(str, keyringChunksPrefix) {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimPrefix(str, keyringChunksPrefix))
+	return n
+}
+
+func keyringGet(name string) (string, error) {
+	str, err := keyring.Get(consts.APP_DATA_FOLDER, name)
+	if err != nil {
+		return "", err
+	}
+	n := keyringChunks(str)
+	if n <= 0 {
+		return str, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		chunk, err := keyring.Get(consts.APP_DATA_FOLDER, keyringChunkName(name, i))
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(chunk)
+	}
+	return sb.String(), nil
+}
+
+func keyringSet(name, content string) error {
+	// remove chunks of previous content
+	if err := keyringDelete(name); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return err
+	}
+	if len(content) <= keyringChunkSize {
+		return keyring.Set(consts.APP_DATA_FOLDER, name, content)
+	}
+	var n int
+	for ; len(content) > 0; n++ {
+		size := min(keyringChunkSize, len(content))
+		if err := keyring.Set(consts.APP_DATA_FOLDER, keyringChunkName(name, n), content[:size]); err != nil {
+			return err
+		}
+		content = content[size:]
+	}
+	return keyring.Set(consts.APP_DATA_FOLDER, name, keyringChunksPrefix+strconv.Itoa(n))
+}
+
+func keyringDelete(name string) error {
+	if str, err := keyring.Get(consts.APP_DATA_FOLDER, name); err == nil {
+		for i := keyringChunks(str) - 1; i >= 0; i-- {
+			keyring.Delete(consts.APP_DATA_FOLDER, keyringChunkName(name, i))
+		}
+	}
+	return keyring.Delete(consts.APP_DATA_FOLDER, name)
+}
+
+// check if system secret service could be accessed
+func keyringAvailable() bool {
+	if err := keyring.Set(consts.APP_DATA_FOLDER, keyringProbeName, "probe"); err != nil {
+		return false
+	}
+	keyring.Delete(consts.APP_DATA_FOLDER, keyringProbeName)
+	return true
+}
+
+func newKdf() (*secretsKdf, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return &secretsKdf{
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    3,
+		Memory:  64 * 1024,
+		Threads: 4,
+	}, nil
+}
+
+// validate parameters of key derivation, which could be read from imported file
+func (k *secretsKdf) validate() error {
+	if k.Time < 1 || k.Time > 16 {
+		return fmt.Errorf("invalid key derivation time: %d", k.Time)
+	}
+	// memory in KiB, 8MiB ~ 1GiB
+	if k.Memory < 8*1024 || k.Memory > 1024*1024 {
+		return fmt.Errorf("invalid key derivation memory: %d", k.Memory)
+	}
+	if k.Threads < 1 || k.Threads > 16 {
+		return fmt.Errorf("invalid key derivation threads: %d", k.Threads)
+	}
+	return nil
+}
+
+func deriveKey(password string, kdf *secretsKdf) ([]byte, error) {
+	if err := kdf.validate(); err != nil {
+		return nil, err
+	}
+	salt, err := base64.StdEncoding.DecodeString(kdf.Salt)
+	if err != nil {
+		return nil, err
+	}
+	return argon2.IDKey([]byte(password), salt, kdf.Time, kdf.Memory, kdf.Threads, 32), nil
+}
+
+func sealSecret(key, content []byte, name string) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, content, []byte(name))), nil
+}
+
+func openSecret(key []byte, encrypted, name string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid secret content")
+	}
+	return gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], []byte(name))
+}
+
+type secretsBundle struct {
+	Kdf  *secretsKdf `yaml:"kdf"`
+	Data string      `yaml:"data"`
+}
+
+// EncryptSecretsBundle encrypt secrets of multiple connections with passphrase for exporting
+func EncryptSecretsBundle(secrets map[string]types.ConnectionSecrets, passphrase string) ([]byte, error) {
+	if len(passphrase) <= 0 {
+		return nil, errors.New("passphrase is required")
+	}
+	kdf, err := newKdf()
+	if err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, kdf)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	data, err := sealSecret(key, content, "bundle")
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&secretsBundle{
+		Kdf:  kdf,
+		Data: data,
+	})
+}
+
+// DecryptSecretsBundle decrypt exported secrets with passphrase
+func DecryptSecretsBundle(bundle []byte, passphrase string) (map[string]types.ConnectionSecrets, error) {
+	if len(passphrase) <= 0 {
+		return nil, errors.New("passphrase is required")
+	}
+	var b secretsBundle
+	if err := yaml.Unmarshal(bundle, &b); err != nil || b.Kdf == nil {
+		return nil, errors.New("invalid secrets content")
+	}
+	key, err := deriveKey(passphrase, b.Kdf)
+	if err != nil {
+		return nil, err
+	}
+	content, err := openSecret(key, b.Data, "bundle")
+	if err != nil {
+		return nil, errors.New("incorrect passphrase")
+	}
+	ret := map[string]types.ConnectionSecrets{}
+	if err = json.Unmarshal(content, &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
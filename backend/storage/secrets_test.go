@@ -0,0 +1,240 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"tinyrdm/backend/consts"
+	"tinyrdm/backend/types"
+
+	"github.com/zalando/go-keyring"
+)
+
+func newTestSecrets(t *testing.T, mode, password string) *SecretsStorage {
+	t.Helper()
+	keyring.MockInit()
+	s := &SecretsStorage{
+		storage: &localStorage{ConfPath: filepath.Join(t.TempDir(), "secrets.yaml")},
+	}
+	if err := s.SwitchMode(mode, password, nil); err != nil {
+		t.Fatalf("SwitchMode(%s) error: %v", mode, err)
+	}
+	return s
+}
+
+func newTestConnections(t *testing.T, mode, password string) *ConnectionsStorage {
+	t.Helper()
+	return &ConnectionsStorage{
+		storage: &localStorage{ConfPath: filepath.Join(t.TempDir(), "connections.yaml")},
+		secrets: newTestSecrets(t, mode, password),
+	}
+}
+
+func TestSecretsSetGet(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		secrets types.ConnectionSecrets
+	}{
+		{"keyring", types.SECRET_MODE_KEYRING, types.ConnectionSecrets{Password: "pwd", SSHPassword: "ssh"}},
+		{"keyring chunks", types.SECRET_MODE_KEYRING, types.ConnectionSecrets{SSLKey: strings.Repeat("k", 3*keyringChunkSize)}},
+		{"keyring jump hosts", types.SECRET_MODE_KEYRING, types.ConnectionSecrets{
+			SSHJumpHosts: []types.SSHJumpHostSecrets{{}, {Passphrase: "jump"}}}},
+		{"password", types.SECRET_MODE_PASSWORD, types.ConnectionSecrets{Password: "pwd", ProxyPassword: "proxy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestSecrets(t, tt.mode, "master")
+			if err := s.Set("conn", tt.secrets); err != nil {
+				t.Fatalf("Set() error: %v", err)
+			}
+			got, err := s.Get("conn")
+			if err != nil {
+				t.Fatalf("Get() error: %v", err)
+			}
+			if got.Password != tt.secrets.Password || got.SSLKey != tt.secrets.SSLKey ||
+				got.ProxyPassword != tt.secrets.ProxyPassword || len(got.SSHJumpHosts) != len(tt.secrets.SSHJumpHosts) {
+				t.Errorf("Get() = %+v, want %+v", got, tt.secrets)
+			}
+
+			// empty secrets never remove saved entry
+			if err = s.Set("conn", types.ConnectionSecrets{}); err != nil {
+				t.Fatalf("Set(empty) error: %v", err)
+			}
+			if got, _ = s.Get("conn"); got.IsEmpty() {
+				t.Errorf("Set(empty) removed saved secrets")
+			}
+
+			if err = s.Delete("conn"); err != nil {
+				t.Fatalf("Delete() error: %v", err)
+			}
+			if got, err = s.Get("conn"); err != nil || !got.IsEmpty() {
+				t.Errorf("Get() after Delete() = %+v, %v, want empty", got, err)
+			}
+		})
+	}
+}
+
+func TestSecretsKeyringError(t *testing.T) {
+	s := newTestSecrets(t, types.SECRET_MODE_KEYRING, "")
+	if err := s.Set("conn", types.ConnectionSecrets{Password: "pwd"}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	keyringErr := errors.New("dbus timeout")
+	keyring.MockInitWithError(keyringErr)
+	if _, err := s.Get("conn"); !errors.Is(err, keyringErr) {
+		t.Errorf("Get() error = %v, want %v", err, keyringErr)
+	}
+
+	// entry not found is not an error
+	keyring.MockInit()
+	if got, err := s.Get("conn"); err != nil || !got.IsEmpty() {
+		t.Errorf("Get() missing entry = %+v, %v, want empty", got, err)
+	}
+}
+
+func TestSecretsMasterPassword(t *testing.T) {
+	s := newTestSecrets(t, types.SECRET_MODE_PASSWORD, "master")
+	if err := s.Set("conn", types.ConnectionSecrets{Password: "pwd"}); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	s.Lock()
+	if !s.Locked() {
+		t.Fatalf("Locked() = false after Lock()")
+	}
+	if _, err := s.Get("conn"); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("Get() locked error = %v, want %v", err, ErrSecretsLocked)
+	}
+	if err := s.Set("conn", types.ConnectionSecrets{Password: "new"}); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("Set() locked error = %v, want %v", err, ErrSecretsLocked)
+	}
+	if err := s.Unlock("wrong"); !errors.Is(err, ErrMasterPassword) {
+		t.Errorf("Unlock(wrong) error = %v, want %v", err, ErrMasterPassword)
+	}
+	if err := s.Unlock("master"); err != nil {
+		t.Fatalf("Unlock() error: %v", err)
+	}
+	if got, err := s.Get("conn"); err != nil || got.Password != "pwd" {
+		t.Errorf("Get() after Unlock() = %+v, %v", got, err)
+	}
+}
+
+func TestSecretsSwitchMode(t *testing.T) {
+	s := newTestSecrets(t, types.SECRET_MODE_KEYRING, "")
+	secrets := map[string]types.ConnectionSecrets{
+		"a": {Password: "pa"},
+		"b": {},
+	}
+	for name, secret := range secrets {
+		s.Set(name, secret)
+	}
+	if err := s.SwitchMode(types.SECRET_MODE_PASSWORD, "master", secrets); err != nil {
+		t.Fatalf("SwitchMode() error: %v", err)
+	}
+	if _, err := keyring.Get(consts.APP_DATA_FOLDER, "a"); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("secrets left in keyring after switching mode: %v", err)
+	}
+	if got, err := s.Get("a"); err != nil || got.Password != "pa" {
+		t.Errorf("Get() after SwitchMode() = %+v, %v", got, err)
+	}
+}
+
+// secrets should never be wiped by routine saves, even if secret storage could not be read
+func TestConnectionSecretsNotWiped(t *testing.T) {
+	c := newTestConnections(t, types.SECRET_MODE_KEYRING, "")
+	conf := types.ConnectionConfig{Name: "conn", Addr: "127.0.0.1", Port: 6379, Password: "pwd"}
+	if err := c.CreateConnection(conf); err != nil {
+		t.Fatalf("CreateConnection() error: %v", err)
+	}
+	if saved := c.getConnection("conn"); saved == nil || saved.Password != "" {
+		t.Fatalf("password should not be kept in profile: %+v", saved)
+	}
+
+	keyringErr := errors.New("keyring is locked")
+	keyring.MockInitWithError(keyringErr)
+	if _, err := c.GetConnection("conn"); !errors.Is(err, keyringErr) {
+		t.Errorf("GetConnection() error = %v, want %v", err, keyringErr)
+	}
+	err := c.UpdateConnectionProfile("conn", func(conf *types.ConnectionConfig) bool {
+		conf.LastDB = 3
+		return true
+	})
+	if err != nil {
+		t.Fatalf("UpdateConnectionProfile() error: %v", err)
+	}
+	if err = c.UpdateConnectionProfile("missing", func(conf *types.ConnectionConfig) bool { return true }); err == nil {
+		t.Errorf("UpdateConnectionProfile() of missing connection should fail")
+	}
+	if err = c.DeleteConnection("conn"); err != nil {
+		t.Fatalf("DeleteConnection() error: %v", err)
+	}
+	if err = c.CreateConnection(conf); err == nil {
+		t.Errorf("CreateConnection() should fail if secrets could not be written")
+	}
+}
+
+func TestConnectionSecretsCleared(t *testing.T) {
+	c := newTestConnections(t, types.SECRET_MODE_KEYRING, "")
+	conf := types.ConnectionConfig{Name: "conn", Password: "pwd", SSH: types.ConnectionSSH{Password: "ssh"}}
+	if err := c.CreateConnection(conf); err != nil {
+		t.Fatalf("CreateConnection() error: %v", err)
+	}
+
+	// routine save keeps secrets
+	c.UpdateConnectionProfile("conn", func(conf *types.ConnectionConfig) bool {
+		conf.RefreshInterval = 10
+		return true
+	})
+	got, err := c.GetConnection("conn")
+	if err != nil || got.Password != "pwd" || got.RefreshInterval != 10 {
+		t.Fatalf("GetConnection() = %+v, %v", got, err)
+	}
+
+	// clear one field in dialog
+	got.Password = ""
+	if err = c.UpdateConnection("conn", got.ConnectionConfig); err != nil {
+		t.Fatalf("UpdateConnection() error: %v", err)
+	}
+	if got, _ = c.GetConnection("conn"); got.Password != "" || got.SSH.Password != "ssh" {
+		t.Errorf("GetConnection() after clearing password = %+v", got)
+	}
+
+	// clear all fields in dialog
+	got.SSH.Password = ""
+	if err = c.UpdateConnection("conn", got.ConnectionConfig); err != nil {
+		t.Fatalf("UpdateConnection() error: %v", err)
+	}
+	if _, err = keyring.Get(consts.APP_DATA_FOLDER, "conn"); !errors.Is(err, keyring.ErrNotFound) {
+		t.Errorf("secrets should be removed after all fields cleared: %v", err)
+	}
+}
+
+func TestConnectionSecretsKeptWhenLocked(t *testing.T) {
+	c := newTestConnections(t, types.SECRET_MODE_PASSWORD, "master")
+	if err := c.CreateConnection(types.ConnectionConfig{Name: "conn", Password: "pwd"}); err != nil {
+		t.Fatalf("CreateConnection() error: %v", err)
+	}
+
+	c.LockSecrets()
+	if _, err := c.GetConnection("conn"); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("GetConnection() locked error = %v, want %v", err, ErrSecretsLocked)
+	}
+	if err := c.UpdateConnection("conn", types.ConnectionConfig{Name: "conn"}); !errors.Is(err, ErrSecretsLocked) {
+		t.Errorf("UpdateConnection() locked error = %v, want %v", err, ErrSecretsLocked)
+	}
+	c.UpdateConnectionProfile("conn", func(conf *types.ConnectionConfig) bool {
+		conf.PinnedNode = "10.0.0.1:6379"
+		return true
+	})
+
+	if err := c.secrets.Unlock("master"); err != nil {
+		t.Fatalf("Unlock() error: %v", err)
+	}
+	got, err := c.GetConnection("conn")
+	if err != nil || got.Password != "pwd" || got.PinnedNode != "10.0.0.1:6379" {
+		t.Errorf("GetConnection() after Unlock() = %+v, %v", got, err)
+	}
+}
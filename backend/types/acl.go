@@ -0,0 +1,47 @@
+package types
+
+type ACLSelector struct {
+	Commands string `json:"commands"`
+	Keys     string `json:"keys"`
+	Channels string `json:"channels"`
+}
+
+type ACLUser struct {
+	Name      string        `json:"name"`
+	Enabled   bool          `json:"enabled"`
+	NoPass    bool          `json:"noPass"`
+	Flags     []string      `json:"flags"`
+	Passwords []string      `json:"passwords"` // SHA-256 hashes of passwords
+	Commands  string        `json:"commands"`  // e.g. "+@all -flushdb"
+	Keys      string        `json:"keys"`      // e.g. "~* %R~cache:*"
+	Channels  string        `json:"channels"`  // e.g. "&*"
+	Selectors []ACLSelector `json:"selectors,omitempty"`
+	Rule      string        `json:"rule"` // rule line in "ACL LIST"
+}
+
+// ACLUserForm structured rules of user to be saved
+type ACLUserForm struct {
+	Name         string   `json:"name"`
+	Enabled      bool     `json:"enabled"`
+	NoPass       bool     `json:"noPass"`
+	Passwords    []string `json:"passwords"`    // hashes of passwords to keep
+	AddPasswords []string `json:"addPasswords"` // new passwords in plain text
+	Commands     []string `json:"commands"`     // e.g. "+@read", "-keys"
+	Keys         []string `json:"keys"`         // e.g. "~user:*", "%R~cache:*", "allkeys"
+	Channels     []string `json:"channels"`     // e.g. "&news.*", "allchannels"
+	Selectors    []string `json:"selectors"`    // raw selectors, e.g. "(~tmp:* +@write)"
+}
+
+type ACLLogEntry struct {
+	Node        string            `json:"node,omitempty"` // available in cluster mode
+	EntryID     int64             `json:"entryId"`
+	Count       int64             `json:"count"`
+	Reason      string            `json:"reason"`  // command, key, channel or auth
+	Context     string            `json:"context"` // toplevel, multi, lua or module
+	Object      string            `json:"object"`  // denied command, key or channel
+	Username    string            `json:"username"`
+	AgeSeconds  float64           `json:"ageSeconds"`
+	Client      map[string]string `json:"client"`
+	CreatedAt   int64             `json:"createdAt"` // timestamp in milliseconds
+	LastUpdated int64             `json:"lastUpdated"`
+}
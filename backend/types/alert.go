@@ -0,0 +1,47 @@
+package types
+
+// metrics that alert rules can be evaluated against
+const (
+	ALERT_METRIC_MEMORY_RATIO   = "memory_ratio"   // used memory in percentage of maxmemory
+	ALERT_METRIC_MEMORY_USED    = "memory_used"    // used memory in MB
+	ALERT_METRIC_CLIENTS        = "clients"        // connected clients
+	ALERT_METRIC_REPLICA_LAG    = "replica_lag"    // max lag of replicas in seconds
+	ALERT_METRIC_REJECTED_CONNS = "rejected_conns" // connections rejected since last sample
+	ALERT_METRIC_EVICTED_RATE   = "evicted_rate"   // keys evicted per second
+	ALERT_METRIC_SLOWLOG_GROWTH = "slowlog_growth" // slow logs generated since last sample
+)
+
+const (
+	ALERT_STATE_FIRING   = "firing"
+	ALERT_STATE_RESOLVED = "resolved"
+)
+
+type AlertRule struct {
+	ID        string  `json:"id" yaml:"id"`
+	Name      string  `json:"name" yaml:"name"`
+	Enable    bool    `json:"enable" yaml:"enable"`
+	Server    string  `json:"server,omitempty" yaml:"server,omitempty"` // empty for all connections
+	Metric    string  `json:"metric" yaml:"metric"`
+	Operator  string  `json:"operator" yaml:"operator"` // one of ">", ">=", "<", "<="
+	Threshold float64 `json:"threshold" yaml:"threshold"`
+	Duration  int     `json:"duration,omitempty" yaml:"duration,omitempty"` // seconds the condition lasts before firing
+}
+
+type AlertSettings struct {
+	Notify  bool        `json:"notify" yaml:"notify"`                       // send desktop notification
+	Webhook string      `json:"webhook,omitempty" yaml:"webhook,omitempty"` // url to post events in web mode
+	Rules   []AlertRule `json:"rules" yaml:"rules"`
+}
+
+type AlertEvent struct {
+	Timestamp int64   `json:"timestamp"`
+	State     string  `json:"state"`
+	RuleID    string  `json:"ruleId"`
+	RuleName  string  `json:"ruleName"`
+	Server    string  `json:"server"`
+	Node      string  `json:"node,omitempty"`
+	Metric    string  `json:"metric"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Value     float64 `json:"value"`
+}
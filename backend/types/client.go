@@ -0,0 +1,25 @@
+package types
+
+type ClientTracking struct {
+	Enabled   bool  `json:"enabled"`
+	Broadcast bool  `json:"broadcast"`
+	Invalid   bool  `json:"invalid"`  // redirect client is closed
+	Redirect  int64 `json:"redirect"` // id of client to redirect invalidation messages
+}
+
+type ClientInfo struct {
+	Node     string            `json:"node"` // empty for connected node
+	ID       int64             `json:"id"`
+	Addr     string            `json:"addr"`
+	LAddr    string            `json:"laddr"`
+	Name     string            `json:"name"`
+	User     string            `json:"user"`
+	DB       int               `json:"db"`
+	Age      int64             `json:"age"`
+	Idle     int64             `json:"idle"`
+	Flags    []string          `json:"flags"`
+	Cmd      string            `json:"cmd"`
+	Tracking ClientTracking    `json:"tracking"`
+	Fields   map[string]string `json:"fields"` // all fields in "CLIENT LIST"/"CLIENT INFO"
+	Self     bool              `json:"self"`   // connection created by this app
+}
@@ -0,0 +1,52 @@
+package types
+
+type ClusterNode struct {
+	ID          string         `json:"id"`
+	Addr        string         `json:"addr"`
+	Hostname    string         `json:"hostname,omitempty"`
+	Role        string         `json:"role"`               // master or replica
+	MasterID    string         `json:"masterId,omitempty"` // master of replica
+	Flags       []string       `json:"flags"`
+	Myself      bool           `json:"myself"`
+	Failing     bool           `json:"failing"`          // flagged as fail or pfail
+	Connected   bool           `json:"connected"`        // link state of cluster bus
+	Health      string         `json:"health,omitempty"` // online, failed or loading, only available in redis 7.0+
+	ConfigEpoch int64          `json:"configEpoch"`
+	Slots       [][2]int       `json:"slots,omitempty"` // assigned slot ranges
+	SlotCount   int            `json:"slotCount"`
+	Migrating   map[int]string `json:"migrating,omitempty"` // slot to id of target node
+	Importing   map[int]string `json:"importing,omitempty"` // slot to id of source node
+
+	UsedMemory int64  `json:"usedMemory"`
+	OpsPerSec  int64  `json:"opsPerSec"`
+	Keys       int64  `json:"keys"`
+	Clients    int64  `json:"clients"`
+	ReplOffset int64  `json:"replOffset"`
+	Error      string `json:"error,omitempty"` // fail to get info of node
+}
+
+type ClusterTopology struct {
+	State         string        `json:"state"` // ok or fail
+	SlotsAssigned int           `json:"slotsAssigned"`
+	SlotsPFail    int           `json:"slotsPFail"`
+	SlotsFail     int           `json:"slotsFail"`
+	Size          int           `json:"size"` // count of masters serving slots
+	Nodes         []ClusterNode `json:"nodes"`
+}
+
+type ClusterMigrateParam struct {
+	Server    string `json:"server"`
+	Source    string `json:"source"` // id of source master
+	Target    string `json:"target"` // id of target master
+	StartSlot int    `json:"startSlot"`
+	EndSlot   int    `json:"endSlot"`
+	SerialNo  string `json:"serialNo"`
+}
+
+type ClusterMigrateResult struct {
+	Canceled      bool   `json:"canceled"`
+	MigratedSlots int    `json:"migratedSlots"`
+	MigratedKeys  int64  `json:"migratedKeys"`
+	Error         string `json:"error,omitempty"`        // migration stopped by error
+	UnstableSlot  *int   `json:"unstableSlot,omitempty"` // slot left in importing/migrating state with keys moved
+}
@@ -0,0 +1,22 @@
+package types
+
+type ConfigParam struct {
+	Name       string   `json:"name"`
+	Section    string   `json:"section"`
+	Value      string   `json:"value"`
+	Default    string   `json:"default"`
+	HasDefault bool     `json:"hasDefault"` // parameter is known with default value
+	Modified   bool     `json:"modified"`   // value differs from default
+	Kind       string   `json:"kind"`       // bool, int, memory, enum or string
+	Options    []string `json:"options,omitempty"`
+	ReadOnly   bool     `json:"readOnly"` // could not be modified at runtime
+}
+
+// ConfigSetResult result of setting parameters on one node
+type ConfigSetResult struct {
+	Node     string   `json:"node"`
+	Applied  []string `json:"applied"`            // parameters set successfully
+	Failed   string   `json:"failed,omitempty"`   // parameter failed to set
+	Error    string   `json:"error,omitempty"`    // error of failed parameter or rollback
+	Restored bool     `json:"restored,omitempty"` // applied parameters are restored to previous values
+}
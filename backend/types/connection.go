@@ -22,12 +22,15 @@ type ConnectionConfig struct {
 	LoadSize        int                `json:"loadSize,omitempty" yaml:"load_size,omitempty"`
 	MarkColor       string             `json:"markColor,omitempty" yaml:"mark_color,omitempty"`
 	RefreshInterval int                `json:"refreshInterval,omitempty" yaml:"refresh_interval,omitempty"`
+	PinnedNode      string             `json:"pinnedNode,omitempty" yaml:"pinned_node,omitempty"` // node which browser connect to directly
+	Exporter        bool               `json:"exporter,omitempty" yaml:"exporter,omitempty"`      // expose INFO metrics on /metrics in web mode
 	Alias           map[int]string     `json:"alias,omitempty" yaml:"alias,omitempty"`
 	SSL             ConnectionSSL      `json:"ssl,omitempty" yaml:"ssl,omitempty"`
 	SSH             ConnectionSSH      `json:"ssh,omitempty" yaml:"ssh,omitempty"`
 	Sentinel        ConnectionSentinel `json:"sentinel,omitempty" yaml:"sentinel,omitempty"`
 	Cluster         ConnectionCluster  `json:"cluster,omitempty" yaml:"cluster,omitempty"`
 	Proxy           ConnectionProxy    `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	DecoderRules    []DecoderRule      `json:"decoderRules,omitempty" yaml:"decoder_rules,omitempty"`
 }
 
 type Connection struct {
@@ -48,16 +51,25 @@ type ConnectionDB struct {
 }
 
 type ConnectionSSL struct {
-	Enable        bool   `json:"enable,omitempty" yaml:"enable,omitempty"`
-	KeyFile       string `json:"keyFile,omitempty" yaml:"keyfile,omitempty"`
-	CertFile      string `json:"certFile,omitempty" yaml:"certfile,omitempty"`
-	CAFile        string `json:"caFile,omitempty" yaml:"cafile,omitempty"`
-	AllowInsecure bool   `json:"allowInsecure,omitempty" yaml:"allow_insecure,omitempty"`
-	SNI           string `json:"sni,omitempty" yaml:"sni,omitempty"`
+	Enable        bool     `json:"enable,omitempty" yaml:"enable,omitempty"`
+	KeyFile       string   `json:"keyFile,omitempty" yaml:"keyfile,omitempty"`
+	CertFile      string   `json:"certFile,omitempty" yaml:"certfile,omitempty"`
+	CAFile        string   `json:"caFile,omitempty" yaml:"cafile,omitempty"`
+	Key           string   `json:"key,omitempty" yaml:"key,omitempty"`   // PEM content of private key, take precedence over key file
+	Cert          string   `json:"cert,omitempty" yaml:"cert,omitempty"` // PEM content of certificate, take precedence over cert file
+	CA            string   `json:"ca,omitempty" yaml:"ca,omitempty"`     // PEM content of CA certificates, take precedence over CA file
+	KeyPassphrase string   `json:"keyPassphrase,omitempty" yaml:"key_passphrase,omitempty"`
+	AllowInsecure bool     `json:"allowInsecure,omitempty" yaml:"allow_insecure,omitempty"`
+	SNI           string   `json:"sni,omitempty" yaml:"sni,omitempty"`
+	MinVersion    string   `json:"minVersion,omitempty" yaml:"min_version,omitempty"` // 1.0, 1.1, 1.2 or 1.3
+	MaxVersion    string   `json:"maxVersion,omitempty" yaml:"max_version,omitempty"`
+	CipherSuites  []string `json:"cipherSuites,omitempty" yaml:"cipher_suites,omitempty"` // names of cipher suites for TLS 1.0-1.2
+	PinnedSPKI    []string `json:"pinnedSpki,omitempty" yaml:"pinned_spki,omitempty"`     // base64 encoded SHA-256 of subject public key info
 }
 
 type ConnectionSSH struct {
 	Enable     bool   `json:"enable,omitempty" yaml:"enable,omitempty"`
+	Alias      string `json:"alias,omitempty" yaml:"alias,omitempty"` // host alias in ~/.ssh/config
 	Addr       string `json:"addr,omitempty" yaml:"addr,omitempty"`
 	Port       int    `json:"port,omitempty" yaml:"port,omitempty"`
 	LoginType  string `json:"loginType,omitempty" yaml:"login_type"`
@@ -65,17 +77,41 @@ type ConnectionSSH struct {
 	Password   string `json:"password,omitempty" yaml:"password,omitempty"`
 	PKFile     string `json:"pkFile,omitempty" yaml:"pk_file,omitempty"`
 	Passphrase string `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
+	// hosts to jump through in order before connecting to ssh server, like ProxyJump
+	JumpHosts []SSHJumpHost `json:"jumpHosts,omitempty" yaml:"jump_hosts,omitempty"`
+}
+
+type SSHJumpHost struct {
+	Alias      string `json:"alias,omitempty" yaml:"alias,omitempty"`
+	Addr       string `json:"addr,omitempty" yaml:"addr,omitempty"`
+	Port       int    `json:"port,omitempty" yaml:"port,omitempty"`
+	LoginType  string `json:"loginType,omitempty" yaml:"login_type,omitempty"`
+	Username   string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password   string `json:"password,omitempty" yaml:"password,omitempty"`
+	PKFile     string `json:"pkFile,omitempty" yaml:"pk_file,omitempty"`
+	Passphrase string `json:"passphrase,omitempty" yaml:"passphrase,omitempty"`
 }
 
 type ConnectionSentinel struct {
-	Enable   bool   `json:"enable,omitempty" yaml:"enable,omitempty"`
-	Master   string `json:"master,omitempty" yaml:"master,omitempty"`
-	Username string `json:"username,omitempty" yaml:"username,omitempty"`
-	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	Enable           bool   `json:"enable,omitempty" yaml:"enable,omitempty"`
+	Master           string `json:"master,omitempty" yaml:"master,omitempty"`
+	Username         string `json:"username,omitempty" yaml:"username,omitempty"`
+	Password         string `json:"password,omitempty" yaml:"password,omitempty"`
+	ReplicaPreferred bool   `json:"replicaPreferred,omitempty" yaml:"replica_preferred,omitempty"` // connect to a healthy replica if available
 }
 
 type ConnectionCluster struct {
-	Enable bool `json:"enable,omitempty" yaml:"enable,omitempty"`
+	Enable         bool `json:"enable,omitempty" yaml:"enable,omitempty"`
+	ReadOnly       bool `json:"readOnly,omitempty" yaml:"read_only,omitempty"`              // send read commands to replicas
+	RouteByLatency bool `json:"routeByLatency,omitempty" yaml:"route_by_latency,omitempty"` // route read commands to the closest node
+	RouteRandomly  bool `json:"routeRandomly,omitempty" yaml:"route_randomly,omitempty"`    // route read commands to random node
+}
+
+// ServerNode node which can be connected directly in cluster, sentinel or replication
+type ServerNode struct {
+	Addr    string `json:"addr"`
+	Role    string `json:"role"` // master or replica
+	Healthy bool   `json:"healthy"`
 }
 
 type ConnectionProxy struct {
@@ -86,3 +122,71 @@ type ConnectionProxy struct {
 	Username string `json:"username,omitempty" yaml:"username,omitempty"`
 	Password string `json:"password,omitempty" yaml:"password,omitempty"`
 }
+
+// DecoderRule decode and format applied automatically to keys which name match the pattern
+// the rule with non-empty field only applies to hash fields which name match the field pattern
+type DecoderRule struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Field   string `json:"field,omitempty" yaml:"field,omitempty"`
+	Decode  string `json:"decode,omitempty" yaml:"decode,omitempty"`
+	Format  string `json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+const EXPORT_SECRET_KEEP = "keep"
+const EXPORT_SECRET_STRIP = "strip"
+const EXPORT_SECRET_ENCRYPT = "encrypt"
+
+type ExportConnectionParam struct {
+	Names      []string `json:"names"`  // connection or group names to export, empty means all
+	Secret     string   `json:"secret"` // keep, strip or encrypt secrets
+	Passphrase string   `json:"passphrase,omitempty"`
+}
+
+const IMPORT_SKIP = "skip"
+const IMPORT_RENAME = "rename"
+const IMPORT_OVERWRITE = "overwrite"
+
+type ImportConnectionPreview struct {
+	Connections []string `json:"connections"`
+	Collisions  []string `json:"collisions"` // connection names already exist in local profile
+	Encrypted   bool     `json:"encrypted"`  // passphrase is required to import secrets
+	Source      string   `json:"source"`     // tinyrdm, ardm, resp, redisinsight or url
+	// fields of source file which could not be mapped, grouped by connection name
+	Unmapped map[string][]string `json:"unmapped,omitempty"`
+	Token    string              `json:"token"` // identify pending import when applying
+}
+
+type ImportConnectionParam struct {
+	Token       string            `json:"token"`
+	Passphrase  string            `json:"passphrase,omitempty"`
+	Resolutions map[string]string `json:"resolutions,omitempty"` // skip, rename or overwrite collided connection, skip by default
+}
+
+type ImportConnectionResult struct {
+	Imported    []string          `json:"imported"`
+	Skipped     []string          `json:"skipped,omitempty"`
+	Overwritten []string          `json:"overwritten,omitempty"`
+	Renamed     map[string]string `json:"renamed,omitempty"`
+}
+
+type ConnectionSnippets struct {
+	URL     string `json:"url"`
+	Cli     string `json:"cli"`
+	GoRedis string `json:"goRedis"`
+	RedisPy string `json:"redisPy"`
+	IORedis string `json:"ioredis"`
+}
+
+const SSH_TUNNEL_CONNECTING = "connecting"
+const SSH_TUNNEL_CONNECTED = "connected"
+const SSH_TUNNEL_RECONNECTING = "reconnecting"
+const SSH_TUNNEL_FAILED = "failed"
+const SSH_TUNNEL_CLOSED = "closed"
+
+type SSHTunnelStatus struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Refs   int    `json:"refs"` // count of redis connections through tunnel
+	Error  string `json:"error,omitempty"`
+	Since  int64  `json:"since"` // unix milli of last status change
+}
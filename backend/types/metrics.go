@@ -0,0 +1,14 @@
+package types
+
+// MetricsPoint sample of server INFO at a moment
+type MetricsPoint struct {
+	Timestamp  int64   `json:"timestamp"` // in milliseconds
+	OpsPerSec  int64   `json:"opsPerSec"`
+	UsedMemory int64   `json:"usedMemory"` // in bytes
+	Clients    int64   `json:"clients"`
+	HitRatio   float64 `json:"hitRatio"` // keyspace hit ratio during interval, -1 if no key accessed
+	Keys       int64   `json:"keys"`
+	Expires    int64   `json:"expires"`
+	NetInput   float64 `json:"netInput"`  // in bytes per second
+	NetOutput  float64 `json:"netOutput"` // in bytes per second
+}
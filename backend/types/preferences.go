@@ -7,6 +7,7 @@ type Preferences struct {
 	General  PreferencesGeneral   `json:"general" yaml:"general"`
 	Editor   PreferencesEditor    `json:"editor" yaml:"editor"`
 	Cli      PreferencesCli       `json:"cli" yaml:"cli"`
+	Metrics  PreferencesMetrics   `json:"metrics" yaml:"metrics"`
 	Decoder  []PreferencesDecoder `json:"decoder" yaml:"decoder,omitempty"`
 }
 
@@ -38,6 +39,11 @@ func NewPreferences() Preferences {
 			FontSize:    consts.DEFAULT_FONT_SIZE,
 			CursorStyle: "block",
 		},
+		Metrics: PreferencesMetrics{
+			Enable:   true,
+			Interval: consts.DEFAULT_METRICS_INTERVAL,
+			Capacity: consts.DEFAULT_METRICS_CAPACITY,
+		},
 		Decoder: []PreferencesDecoder{},
 	}
 }
@@ -76,6 +82,7 @@ type PreferencesEditor struct {
 	DropText       bool     `json:"dropText" yaml:"drop_text"`
 	Links          bool     `json:"links" yaml:"links"`
 	EntryTextAlign int      `json:"entryTextAlign" yaml:"entry_text_align"`
+	Timezone       string   `json:"timezone" yaml:"timezone,omitempty"` // IANA timezone name to display timestamps, empty means local
 }
 
 type PreferencesCli struct {
@@ -84,12 +91,37 @@ type PreferencesCli struct {
 	CursorStyle string   `json:"cursorStyle" yaml:"cursor_style,omitempty"`
 }
 
+// PreferencesMetrics options of collecting server INFO in background for opened connections
+type PreferencesMetrics struct {
+	Enable   bool `json:"enable" yaml:"enable"`
+	Interval int  `json:"interval" yaml:"interval"` // sampling interval(seconds)
+	Capacity int  `json:"capacity" yaml:"capacity"` // max samples kept for each node
+	Persist  bool `json:"persist" yaml:"persist"`   // save samples to local file
+}
+
+const DECODER_TYPE_CMD = "cmd"
+const DECODER_TYPE_CIPHER = "cipher"
+const DECODER_TYPE_PLUGIN = "plugin"
+const DECODER_TYPE_WASM = "wasm"
+
 type PreferencesDecoder struct {
-	Name       string   `json:"name" yaml:"name"`
-	Enable     bool     `json:"enable" yaml:"enable"`
-	Auto       bool     `json:"auto" yaml:"auto"`
-	DecodePath string   `json:"decodePath" yaml:"decode_path"`
-	DecodeArgs []string `json:"decodeArgs" yaml:"decode_args,omitempty"`
-	EncodePath string   `json:"encodePath" yaml:"encode_path"`
-	EncodeArgs []string `json:"encodeArgs" yaml:"encode_args,omitempty"`
+	Name string `json:"name" yaml:"name"`
+	Type string `json:"type,omitempty" yaml:"type,omitempty"` // empty means external command, wasm module is loaded from decode path
+
+	Enable     bool              `json:"enable" yaml:"enable"`
+	Auto       bool              `json:"auto" yaml:"auto"`
+	DecodePath string            `json:"decodePath" yaml:"decode_path"`
+	DecodeArgs []string          `json:"decodeArgs" yaml:"decode_args,omitempty"`
+	EncodePath string            `json:"encodePath" yaml:"encode_path"`
+	EncodeArgs []string          `json:"encodeArgs" yaml:"encode_args,omitempty"`
+	Timeout    int               `json:"timeout,omitempty" yaml:"timeout,omitempty"` // request timeout(seconds) of plugin
+	Cipher     PreferencesCipher `json:"cipher,omitempty" yaml:"cipher,omitempty"`
+}
+
+// PreferencesCipher layout of encrypted value, the key material is stored separately
+type PreferencesCipher struct {
+	Algorithm  string `json:"algorithm" yaml:"algorithm"`                   // aes-gcm, aes-cbc
+	IVPosition string `json:"ivPosition" yaml:"iv_position"`                // prefix, suffix, fixed
+	IV         string `json:"iv,omitempty" yaml:"iv,omitempty"`             // hex encoded iv/nonce when position is fixed
+	Encoding   string `json:"encoding,omitempty" yaml:"encoding,omitempty"` // raw, base64, hex
 }
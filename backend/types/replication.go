@@ -0,0 +1,27 @@
+package types
+
+type ReplicationNode struct {
+	Addr  string `json:"addr"`
+	Role  string `json:"role"`            // master or replica
+	Error string `json:"error,omitempty"` // fail to get info of node
+
+	ReplID            string `json:"replId,omitempty"`
+	ReplOffset        int64  `json:"replOffset"` // master_repl_offset of master, slave_repl_offset of replica
+	ConnectedReplicas int    `json:"connectedReplicas"`
+	BacklogActive     bool   `json:"backlogActive"`
+	BacklogSize       int64  `json:"backlogSize"`
+	BacklogHistLen    int64  `json:"backlogHistLen"`
+	SyncFull          int64  `json:"syncFull"` // count of full and partial resync served by node
+	SyncPartialOK     int64  `json:"syncPartialOk"`
+	SyncPartialErr    int64  `json:"syncPartialErr"`
+
+	// only for replica
+	MasterAddr     string `json:"masterAddr,omitempty"`
+	LinkStatus     string `json:"linkStatus,omitempty"` // up or down
+	LastIOSeconds  int64  `json:"lastIOSeconds"`        // seconds since last interaction with master
+	LinkDownSince  int64  `json:"linkDownSince"`        // seconds since link down
+	SyncInProgress bool   `json:"syncInProgress"`
+	ReadOnly       bool   `json:"readOnly"`
+	LagBytes       int64  `json:"lagBytes"`   // offset behind master
+	LagSeconds     int64  `json:"lagSeconds"` // lag reported by master, -1 if unknown
+}
@@ -0,0 +1,40 @@
+package types
+
+const SECRET_MODE_PLAIN = ""            // secrets are saved inside connection profile
+const SECRET_MODE_KEYRING = "keyring"   // secrets are saved in system secret service
+const SECRET_MODE_PASSWORD = "password" // secrets are encrypted by key derived from master password
+
+// ConnectionSecrets sensitive fields of one connection, saved apart from connection profile
+type ConnectionSecrets struct {
+	Password         string `json:"password,omitempty"`
+	SSHPassword      string `json:"sshPassword,omitempty"`
+	SSHPassphrase    string `json:"sshPassphrase,omitempty"`
+	SentinelPassword string `json:"sentinelPassword,omitempty"`
+	ProxyPassword    string `json:"proxyPassword,omitempty"`
+	SSLKey           string `json:"sslKey,omitempty"`
+	SSLKeyPassphrase string `json:"sslKeyPassphrase,omitempty"`
+	// secrets of ssh jump hosts, in the same order of jump hosts
+	SSHJumpHosts []SSHJumpHostSecrets `json:"sshJumpHosts,omitempty"`
+}
+
+type SSHJumpHostSecrets struct {
+	Password   string `json:"password,omitempty"`
+	Passphrase string `json:"passphrase,omitempty"`
+}
+
+func (s ConnectionSecrets) IsEmpty() bool {
+	for _, jump := range s.SSHJumpHosts {
+		if jump != (SSHJumpHostSecrets{}) {
+			return false
+		}
+	}
+	return len(s.Password) <= 0 && len(s.SSHPassword) <= 0 && len(s.SSHPassphrase) <= 0 &&
+		len(s.SentinelPassword) <= 0 && len(s.ProxyPassword) <= 0 &&
+		len(s.SSLKey) <= 0 && len(s.SSLKeyPassphrase) <= 0
+}
+
+type SecretStatus struct {
+	Mode             string `json:"mode"`
+	Locked           bool   `json:"locked"`
+	KeyringAvailable bool   `json:"keyringAvailable"`
+}
@@ -0,0 +1,33 @@
+package types
+
+type SentinelNode struct {
+	Addr             string   `json:"addr"`
+	RunID            string   `json:"runId,omitempty"`
+	Flags            []string `json:"flags"`
+	SDown            bool     `json:"sDown"` // subjectively down
+	ODown            bool     `json:"oDown"` // objectively down, only for master
+	Disconnected     bool     `json:"disconnected"`
+	LastOkPing       int64    `json:"lastOkPing"`                 // milliseconds since last valid reply of ping
+	MasterLinkStatus string   `json:"masterLinkStatus,omitempty"` // only for replica
+	ReplOffset       int64    `json:"replOffset,omitempty"`       // only for replica
+	VotedLeader      string   `json:"votedLeader,omitempty"`      // only for sentinel
+}
+
+type SentinelMaster struct {
+	SentinelNode      `json:",inline"`
+	Name              string         `json:"name"`
+	Quorum            int            `json:"quorum"`
+	NumReplicas       int            `json:"numReplicas"`
+	NumOtherSentinels int            `json:"numOtherSentinels"`
+	ConfigEpoch       int64          `json:"configEpoch"` // increased by each failover
+	DownAfter         int64          `json:"downAfter"`   // milliseconds
+	FailoverTimeout   int64          `json:"failoverTimeout"`
+	FailoverState     string         `json:"failoverState,omitempty"` // failover in progress
+	Replicas          []SentinelNode `json:"replicas"`
+	Sentinels         []SentinelNode `json:"sentinels"` // other sentinels
+}
+
+type SentinelOverview struct {
+	Addr    string           `json:"addr"` // address of connected sentinel
+	Masters []SentinelMaster `json:"masters"`
+}
@@ -8,6 +8,9 @@ const FORMAT_XML = "XML"
 const FORMAT_HEX = "Hex"
 const FORMAT_BINARY = "Binary"
 const FORMAT_BITSET = "BitSet"
+const FORMAT_TIMESTAMP = "Timestamp"
+const FORMAT_JWT = "JWT"
+const FORMAT_URL_ENCODED = "URL Encoded"
 
 const DECODE_NONE = "None"
 const DECODE_BASE64 = "Base64"
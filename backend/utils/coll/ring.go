@@ -0,0 +1,67 @@
+package coll
+
+// Ring 固定容量的环形缓冲区, 写满后覆盖最早的元素
+type Ring[T any] struct {
+	data  []T
+	start int
+	size  int
+}
+
+func NewRing[T any](capacity int) *Ring[T] {
+	return &Ring[T]{
+		data: make([]T, max(1, capacity)),
+	}
+}
+
+// Push 追加元素, 缓冲区已满时覆盖最早的元素
+func (r *Ring[T]) Push(elem T) {
+	if r.size < len(r.data) {
+		r.data[(r.start+r.size)%len(r.data)] = elem
+		r.size += 1
+	} else {
+		r.data[r.start] = elem
+		r.start = (r.start + 1) % len(r.data)
+	}
+}
+
+// Size 元素数量
+func (r *Ring[T]) Size() int {
+	return r.size
+}
+
+// Cap 容量
+func (r *Ring[T]) Cap() int {
+	return len(r.data)
+}
+
+// Last 最新的元素
+func (r *Ring[T]) Last() (elem T, ok bool) {
+	if r.size <= 0 {
+		return
+	}
+	return r.data[(r.start+r.size-1)%len(r.data)], true
+}
+
+// ToSlice 按写入顺序转为切片
+func (r *Ring[T]) ToSlice() []T {
+	ret := make([]T, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		ret = append(ret, r.data[(r.start+i)%len(r.data)])
+	}
+	return ret
+}
+
+// Resize 调整容量, 容量减小时保留最新的元素
+func (r *Ring[T]) Resize(capacity int) {
+	capacity = max(1, capacity)
+	if capacity == len(r.data) {
+		return
+	}
+	elems := r.ToSlice()
+	if len(elems) > capacity {
+		elems = elems[len(elems)-capacity:]
+	}
+	r.data = make([]T, capacity)
+	r.start = 0
+	r.size = copy(r.data, elems)
+}
@@ -0,0 +1,49 @@
+package coll
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRing(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity int
+		push     []int
+		resize   int // resize after push if greater than 0
+		want     []int
+		wantCap  int
+	}{
+		{name: "empty", capacity: 3, want: []int{}, wantCap: 3},
+		{name: "not full", capacity: 3, push: []int{1, 2}, want: []int{1, 2}, wantCap: 3},
+		{name: "full", capacity: 3, push: []int{1, 2, 3}, want: []int{1, 2, 3}, wantCap: 3},
+		{name: "overwrite oldest", capacity: 3, push: []int{1, 2, 3, 4, 5}, want: []int{3, 4, 5}, wantCap: 3},
+		{name: "zero capacity", capacity: 0, push: []int{1, 2}, want: []int{2}, wantCap: 1},
+		{name: "grow", capacity: 2, push: []int{1, 2, 3}, resize: 4, want: []int{2, 3}, wantCap: 4},
+		{name: "shrink keeps latest", capacity: 4, push: []int{1, 2, 3, 4, 5}, resize: 2, want: []int{4, 5}, wantCap: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRing[int](tt.capacity)
+			for _, v := range tt.push {
+				r.Push(v)
+			}
+			if tt.resize > 0 {
+				r.Resize(tt.resize)
+			}
+			if got := r.ToSlice(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToSlice() = %v, want %v", got, tt.want)
+			}
+			if r.Size() != len(tt.want) {
+				t.Errorf("Size() = %d, want %d", r.Size(), len(tt.want))
+			}
+			if r.Cap() != tt.wantCap {
+				t.Errorf("Cap() = %d, want %d", r.Cap(), tt.wantCap)
+			}
+			last, ok := r.Last()
+			if ok != (len(tt.want) > 0) || (ok && last != tt.want[len(tt.want)-1]) {
+				t.Errorf("Last() = %v, %v", last, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package convutil
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	CIPHER_AES_GCM = "aes-gcm"
+	CIPHER_AES_CBC = "aes-cbc"
+
+	IV_PREFIX = "prefix"
+	IV_SUFFIX = "suffix"
+	IV_FIXED  = "fixed"
+
+	CIPHER_ENCODING_RAW    = "raw"
+	CIPHER_ENCODING_BASE64 = "base64"
+	CIPHER_ENCODING_HEX    = "hex"
+)
+
+// CipherConvert decrypt and encrypt value with AES-GCM or AES-CBC
+type CipherConvert struct {
+	algorithm  string
+	key        []byte
+	ivPosition string
+	iv         []byte
+	encoding   string
+}
+
+func NewCipherConvert(algorithm string, key []byte, ivPosition, iv, encoding string) *CipherConvert {
+	c := &CipherConvert{
+		algorithm:  strings.ToLower(algorithm),
+		key:        key,
+		ivPosition: strings.ToLower(ivPosition),
+		encoding:   strings.ToLower(encoding),
+	}
+	if len(c.ivPosition) <= 0 {
+		c.ivPosition = IV_PREFIX
+	}
+	if c.ivPosition == IV_FIXED {
+		c.iv, _ = hex.DecodeString(iv)
+	}
+	return c
+}
+
+// ParseCipherKey parse AES key from hex or base64 string
+func ParseCipherKey(key string) ([]byte, error) {
+	key = strings.TrimSpace(key)
+	validSize := func(b []byte) bool {
+		return len(b) == 16 || len(b) == 24 || len(b) == 32
+	}
+	if b, err := hex.DecodeString(key); err == nil && validSize(b) {
+		return b, nil
+	}
+	if b, err := base64.StdEncoding.DecodeString(key); err == nil && validSize(b) {
+		return b, nil
+	}
+	return nil, errors.New("invalid key, require 16, 24 or 32 bytes in hex or base64")
+}
+
+func (c *CipherConvert) Enable() bool {
+	if c == nil {
+		return false
+	}
+	switch len(c.key) {
+	case 16, 24, 32:
+		return true
+	}
+	return false
+}
+
+// split iv/nonce and cipher content by position
+func (c *CipherConvert) splitIV(data []byte, size int) (iv, content []byte, ok bool) {
+	switch c.ivPosition {
+	case IV_FIXED:
+		if len(c.iv) != size {
+			return nil, nil, false
+		}
+		return c.iv, data, true
+	case IV_SUFFIX:
+		if len(data) < size {
+			return nil, nil, false
+		}
+		return data[len(data)-size:], data[:len(data)-size], true
+	default:
+		if len(data) < size {
+			return nil, nil, false
+		}
+		return data[:size], data[size:], true
+	}
+}
+
+// join iv/nonce and cipher content by position
+func (c *CipherConvert) joinIV(iv, content []byte) []byte {
+	switch c.ivPosition {
+	case IV_FIXED:
+		return content
+	case IV_SUFFIX:
+		return append(content, iv...)
+	default:
+		return append(iv, content...)
+	}
+}
+
+// generate iv/nonce for encryption, reuse the configured one in fixed position
+func (c *CipherConvert) newIV(size int) ([]byte, bool) {
+	if c.ivPosition == IV_FIXED {
+		return c.iv, len(c.iv) == size
+	}
+	iv := make([]byte, size)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, false
+	}
+	return iv, true
+}
+
+func (c *CipherConvert) decodeContent(str string) ([]byte, bool) {
+	switch c.encoding {
+	case CIPHER_ENCODING_BASE64:
+		b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(str))
+		return b, err == nil
+	case CIPHER_ENCODING_HEX:
+		b, err := hex.DecodeString(strings.TrimSpace(str))
+		return b, err == nil
+	default:
+		return []byte(str), true
+	}
+}
+
+func (c *CipherConvert) encodeContent(b []byte) string {
+	switch c.encoding {
+	case CIPHER_ENCODING_BASE64:
+		return base64.StdEncoding.EncodeToString(b)
+	case CIPHER_ENCODING_HEX:
+		return hex.EncodeToString(b)
+	default:
+		return string(b)
+	}
+}
+
+func (c *CipherConvert) Encode(str string) (string, bool) {
+	if !c.Enable() {
+		return str, false
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return str, false
+	}
+
+	var encrypted []byte
+	switch c.algorithm {
+	case CIPHER_AES_CBC:
+		iv, ok := c.newIV(block.BlockSize())
+		if !ok {
+			return str, false
+		}
+		padded := pkcs7Pad([]byte(str), block.BlockSize())
+		content := make([]byte, len(padded))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(content, padded)
+		encrypted = c.joinIV(iv, content)
+	default:
+		// reusing nonce breaks confidentiality and authenticity of GCM, values are readonly with fixed IV
+		if c.ivPosition == IV_FIXED {
+			return str, false
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return str, false
+		}
+		nonce, ok := c.newIV(gcm.NonceSize())
+		if !ok {
+			return str, false
+		}
+		encrypted = c.joinIV(nonce, gcm.Seal(nil, nonce, []byte(str), nil))
+	}
+	return c.encodeContent(encrypted), true
+}
+
+func (c *CipherConvert) Decode(str string) (string, bool) {
+	if !c.Enable() {
+		return str, false
+	}
+	data, ok := c.decodeContent(str)
+	if !ok {
+		return str, false
+	}
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return str, false
+	}
+
+	var decrypted []byte
+	switch c.algorithm {
+	case CIPHER_AES_CBC:
+		iv, content, ok := c.splitIV(data, block.BlockSize())
+		if !ok || len(content) <= 0 || len(content)%block.BlockSize() != 0 {
+			return str, false
+		}
+		decrypted = make([]byte, len(content))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, content)
+		if decrypted, ok = pkcs7Unpad(decrypted, block.BlockSize()); !ok {
+			return str, false
+		}
+	default:
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return str, false
+		}
+		nonce, content, ok := c.splitIV(data, gcm.NonceSize())
+		if !ok {
+			return str, false
+		}
+		if decrypted, err = gcm.Open(nil, nonce, content, nil); err != nil {
+			return str, false
+		}
+	}
+	return string(decrypted), true
+}
+
+func pkcs7Pad(data []byte, blockSize int) []byte {
+	padding := blockSize - len(data)%blockSize
+	return append(data, bytes.Repeat([]byte{byte(padding)}, padding)...)
+}
+
+func pkcs7Unpad(data []byte, blockSize int) ([]byte, bool) {
+	size := len(data)
+	if size <= 0 || size%blockSize != 0 {
+		return nil, false
+	}
+	padding := int(data[size-1])
+	if padding <= 0 || padding > blockSize || padding > size {
+		return nil, false
+	}
+	for _, b := range data[size-padding:] {
+		if int(b) != padding {
+			return nil, false
+		}
+	}
+	return data[:size-padding], true
+}
@@ -0,0 +1,120 @@
+package convutil
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+var testCipherKey, _ = hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+
+const testCipherIV = "0f0e0d0c0b0a09080706050403020100"
+
+func TestCipherConvertRoundTrip(t *testing.T) {
+	tests := []struct {
+		name       string
+		algorithm  string
+		ivPosition string
+		iv         string
+		encoding   string
+	}{
+		{"gcm prefix base64", CIPHER_AES_GCM, IV_PREFIX, "", CIPHER_ENCODING_BASE64},
+		{"gcm suffix hex", CIPHER_AES_GCM, IV_SUFFIX, "", CIPHER_ENCODING_HEX},
+		{"gcm default position raw", CIPHER_AES_GCM, "", "", CIPHER_ENCODING_RAW},
+		{"cbc prefix base64", CIPHER_AES_CBC, IV_PREFIX, "", CIPHER_ENCODING_BASE64},
+		{"cbc suffix hex", CIPHER_AES_CBC, IV_SUFFIX, "", CIPHER_ENCODING_HEX},
+		{"cbc fixed base64", CIPHER_AES_CBC, IV_FIXED, testCipherIV, CIPHER_ENCODING_BASE64},
+		{"upper case options", "AES-CBC", "Suffix", "", "Base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewCipherConvert(tt.algorithm, testCipherKey, tt.ivPosition, tt.iv, tt.encoding)
+			for _, plain := range []string{"", "hello", "exactly 16 bytes", `{"name":"tiny rdm"}`} {
+				encrypted, ok := c.Encode(plain)
+				if !ok {
+					t.Fatalf("Encode(%q) failed", plain)
+				}
+				decrypted, ok := c.Decode(encrypted)
+				if !ok || decrypted != plain {
+					t.Errorf("Decode(Encode(%q)) = %q, %v", plain, decrypted, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestCipherConvertFixedIV(t *testing.T) {
+	cbc := NewCipherConvert(CIPHER_AES_CBC, testCipherKey, IV_FIXED, testCipherIV, CIPHER_ENCODING_HEX)
+	first, _ := cbc.Encode("hello")
+	second, _ := cbc.Encode("hello")
+	if first != second {
+		t.Errorf("CBC with fixed IV should be deterministic, got %q and %q", first, second)
+	}
+	// 16 bytes of block without iv
+	if len(first) != 32 {
+		t.Errorf("CBC with fixed IV should not contain iv, got %q", first)
+	}
+
+	gcm := NewCipherConvert(CIPHER_AES_GCM, testCipherKey, IV_FIXED, "000102030405060708090a0b", CIPHER_ENCODING_HEX)
+	if _, ok := gcm.Encode("hello"); ok {
+		t.Error("GCM with fixed nonce should refuse to encode")
+	}
+
+	invalid := NewCipherConvert(CIPHER_AES_CBC, testCipherKey, IV_FIXED, "0011", CIPHER_ENCODING_HEX)
+	if _, ok := invalid.Encode("hello"); ok {
+		t.Error("fixed IV with invalid size should refuse to encode")
+	}
+}
+
+func TestCipherConvertDecodeFail(t *testing.T) {
+	gcm := NewCipherConvert(CIPHER_AES_GCM, testCipherKey, IV_PREFIX, "", CIPHER_ENCODING_BASE64)
+	encrypted, _ := gcm.Encode("hello")
+
+	otherKey := make([]byte, 32)
+	tests := []struct {
+		name string
+		conv *CipherConvert
+		str  string
+	}{
+		{"wrong key", NewCipherConvert(CIPHER_AES_GCM, otherKey, IV_PREFIX, "", CIPHER_ENCODING_BASE64), encrypted},
+		{"wrong iv position", NewCipherConvert(CIPHER_AES_GCM, testCipherKey, IV_SUFFIX, "", CIPHER_ENCODING_BASE64), encrypted},
+		{"wrong algorithm", NewCipherConvert(CIPHER_AES_CBC, testCipherKey, IV_PREFIX, "", CIPHER_ENCODING_BASE64), encrypted},
+		{"invalid encoding", gcm, "not base64!"},
+		{"too short", gcm, "AAAA"},
+		{"invalid key size", NewCipherConvert(CIPHER_AES_GCM, []byte("short"), IV_PREFIX, "", CIPHER_ENCODING_BASE64), encrypted},
+		{"nil convert", nil, encrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, ok := tt.conv.Decode(tt.str); ok || got != tt.str {
+				t.Errorf("Decode() = %q, %v, want original value and false", got, ok)
+			}
+		})
+	}
+}
+
+func TestParseCipherKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantLen int
+		wantErr bool
+	}{
+		{"hex 16 bytes", "000102030405060708090a0b0c0d0e0f", 16, false},
+		{"hex 32 bytes", hex.EncodeToString(testCipherKey), 32, false},
+		{"base64 24 bytes", "AAECAwQFBgcICQoLDA0ODxAREhMUFRYX", 24, false},
+		{"trim spaces", " 000102030405060708090a0b0c0d0e0f\n", 16, false},
+		{"invalid size", "00010203", 0, true},
+		{"invalid content", "not a key", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParseCipherKey(tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCipherKey() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(key) != tt.wantLen {
+				t.Errorf("ParseCipherKey() got %d bytes, want %d", len(key), tt.wantLen)
+			}
+		})
+	}
+}
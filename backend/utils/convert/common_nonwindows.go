@@ -6,7 +6,11 @@ import (
 	"os/exec"
 )
 
+func newCommand(name string, arg ...string) *exec.Cmd {
+	return exec.Command(name, arg...)
+}
+
 func runCommand(name string, arg ...string) ([]byte, error) {
-	cmd := exec.Command(name, arg...)
+	cmd := newCommand(name, arg...)
 	return cmd.Output()
 }
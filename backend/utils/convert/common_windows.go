@@ -7,8 +7,13 @@ import (
 	"syscall"
 )
 
-func runCommand(name string, arg ...string) ([]byte, error) {
+func newCommand(name string, arg ...string) *exec.Cmd {
 	cmd := exec.Command(name, arg...)
 	cmd.SysProcAttr = &syscall.SysProcAttr{HideWindow: true}
+	return cmd
+}
+
+func runCommand(name string, arg ...string) ([]byte, error) {
+	cmd := newCommand(name, arg...)
 	return cmd.Output()
 }
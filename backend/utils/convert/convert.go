@@ -13,23 +13,38 @@ type DataConvert interface {
 	Decode(string) (string, bool)
 }
 
+// BatchConvert decoder which could decode multiple values in one call
+type BatchConvert interface {
+	DecodeBatch([]string) ([]string, []bool)
+}
+
+// CustomConvert user defined decoder which could be selected by name
+type CustomConvert struct {
+	DataConvert
+	Name string
+	Auto bool
+}
+
 var (
-	jsonConv    JsonConvert
-	uniJsonConv UnicodeJsonConvert
-	yamlConv    YamlConvert
-	xmlConv     XmlConvert
-	base64Conv  Base64Convert
-	binaryConv  BinaryConvert
-	bitSetConv  BitSetConvert
-	hexConv     HexConvert
-	gzipConv    GZipConvert
-	deflateConv DeflateConvert
-	zstdConv    ZStdConvert
-	lz4Conv     LZ4Convert
-	brotliConv  BrotliConvert
-	msgpackConv MsgpackConvert
-	phpConv     = NewPhpConvert()
-	pickleConv  = NewPickleConvert()
+	jsonConv       JsonConvert
+	uniJsonConv    UnicodeJsonConvert
+	yamlConv       YamlConvert
+	xmlConv        XmlConvert
+	base64Conv     Base64Convert
+	binaryConv     BinaryConvert
+	bitSetConv     BitSetConvert
+	hexConv        HexConvert
+	timestampConv  TimestampConvert
+	jwtConv        JwtConvert
+	urlEncodedConv UrlEncodedConvert
+	gzipConv       GZipConvert
+	deflateConv    DeflateConvert
+	zstdConv       ZStdConvert
+	lz4Conv        LZ4Convert
+	brotliConv     BrotliConvert
+	msgpackConv    MsgpackConvert
+	phpConv        = NewPhpConvert()
+	pickleConv     = NewPickleConvert()
 )
 
 var BuildInFormatters = map[string]DataConvert{
@@ -40,6 +55,9 @@ var BuildInFormatters = map[string]DataConvert{
 	types.FORMAT_HEX:          hexConv,
 	types.FORMAT_BINARY:       binaryConv,
 	types.FORMAT_BITSET:       bitSetConv,
+	types.FORMAT_TIMESTAMP:    timestampConv,
+	types.FORMAT_JWT:          jwtConv,
+	types.FORMAT_URL_ENCODED:  urlEncodedConv,
 }
 
 var BuildInDecoders = map[string]DataConvert{
@@ -58,7 +76,7 @@ var BuildInDecoders = map[string]DataConvert{
 // @param decodeType empty string indicates automatic detection
 // @param formatType empty string indicates automatic detection
 // @param custom decoder if any
-func ConvertTo(str, decodeType, formatType string, customDecoder []CmdConvert) (value, resultDecode, resultFormat string) {
+func ConvertTo(str, decodeType, formatType string, customDecoder []CustomConvert) (value, resultDecode, resultFormat string) {
 	if len(str) <= 0 {
 		// empty content
 		if len(formatType) <= 0 {
@@ -85,7 +103,45 @@ func ConvertTo(str, decodeType, formatType string, customDecoder []CmdConvert) (
 	return
 }
 
-func decodeWith(str, decodeType string, customDecoder []CmdConvert) (value, resultDecode string) {
+// ConvertBatch convert multiple values with the same decode type and format
+// custom decoder which implements BatchConvert will be invoked only once
+// @return converted values, in the same order of input
+func ConvertBatch(strs []string, decodeType, formatType string, customDecoder []CustomConvert) []string {
+	values := make([]string, len(strs))
+	var batchDecoder BatchConvert
+	if len(decodeType) > 0 {
+		for _, decoder := range customDecoder {
+			if decoder.Name == decodeType {
+				batchDecoder, _ = decoder.DataConvert.(BatchConvert)
+				break
+			}
+		}
+	}
+	if batchDecoder == nil {
+		for i, str := range strs {
+			values[i], _, _ = ConvertTo(str, decodeType, formatType, customDecoder)
+		}
+		return values
+	}
+
+	decoded, oks := batchDecoder.DecodeBatch(strs)
+	for i, str := range strs {
+		if len(str) <= 0 {
+			continue
+		}
+		if oks[i] {
+			str = decoded[i]
+		}
+		if len(formatType) <= 0 {
+			values[i], _ = autoViewAs(str)
+		} else {
+			values[i], _ = viewAs(str, formatType)
+		}
+	}
+	return values
+}
+
+func decodeWith(str, decodeType string, customDecoder []CustomConvert) (value, resultDecode string) {
 	if len(decodeType) > 0 {
 		value = str
 
@@ -114,7 +170,7 @@ func decodeWith(str, decodeType string, customDecoder []CmdConvert) (value, resu
 
 // attempt try possible decode method
 // if no decode is possible, it will return the origin string value and "none" decode type
-func autoDecode(str string, customDecoder []CmdConvert) (value, resultDecode string) {
+func autoDecode(str string, customDecoder []CustomConvert) (value, resultDecode string) {
 	if len(str) > 0 {
 		// pure digit content may incorrect regard as some encoded type, skip decode
 		if match, _ := regexp.MatchString(`^\d+$`, str); !match {
@@ -219,6 +275,11 @@ func autoViewAs(str string) (value, resultFormat string) {
 			return
 		}
 
+		if value, ok = jwtConv.Decode(str); ok {
+			resultFormat = types.FORMAT_JWT
+			return
+		}
+
 		if strutil.ContainsBinary(str) {
 			if value, ok = hexConv.Decode(str); ok {
 				resultFormat = types.FORMAT_HEX
@@ -232,7 +293,7 @@ func autoViewAs(str string) (value, resultFormat string) {
 	return
 }
 
-func SaveAs(str, format, decode string, customDecoder []CmdConvert) (value string, err error) {
+func SaveAs(str, format, decode string, customDecoder []CustomConvert) (value string, err error) {
 	value = str
 	if buildingFormatter, ok := BuildInFormatters[format]; ok {
 		if formattedStr, ok := buildingFormatter.Encode(str); ok {
@@ -0,0 +1,150 @@
+package convutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// JwtConvert split JSON Web Token into decoded header and payload, signature is not verified
+type JwtConvert struct{}
+
+type jwtContent struct {
+	Header    json.RawMessage `json:"header"`
+	Payload   json.RawMessage `json:"payload"`
+	Signature string          `json:"signature"`
+	Status    string          `json:"status,omitempty"`
+	IssuedAt  string          `json:"issued_at,omitempty"`
+	NotBefore string          `json:"not_before,omitempty"`
+	ExpiresAt string          `json:"expires_at,omitempty"`
+	Token     string          `json:"token,omitempty"` // original token, unchanged segments are kept when encoding
+}
+
+const (
+	JWT_STATUS_VALID     = "valid"
+	JWT_STATUS_EXPIRED   = "expired"
+	JWT_STATUS_NOT_YET   = "not yet valid"
+	JWT_STATUS_NO_EXPIRY = "no expiry"
+)
+
+func (JwtConvert) Enable() bool {
+	return true
+}
+
+func (JwtConvert) Encode(str string) (string, bool) {
+	var content jwtContent
+	if err := json.Unmarshal([]byte(str), &content); err != nil {
+		return str, false
+	}
+	var origin []string
+	if len(content.Token) > 0 {
+		if origin = strings.Split(content.Token, "."); len(origin) != 3 {
+			origin = nil
+		}
+	}
+	// re-encode edited segment only, the original one may be padded or formatted differently
+	encodePart := func(content json.RawMessage, idx int) (string, bool) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, content); err != nil {
+			return "", false
+		}
+		if origin != nil {
+			var originBuf bytes.Buffer
+			if decoded, ok := decodeJwtPart(origin[idx]); ok && json.Compact(&originBuf, decoded) == nil &&
+				bytes.Equal(originBuf.Bytes(), buf.Bytes()) {
+				return origin[idx], true
+			}
+		}
+		return base64.RawURLEncoding.EncodeToString(buf.Bytes()), true
+	}
+	header, ok := encodePart(content.Header, 0)
+	if !ok {
+		return str, false
+	}
+	payload, ok := encodePart(content.Payload, 1)
+	if !ok {
+		return str, false
+	}
+	return header + "." + payload + "." + content.Signature, true
+}
+
+func (JwtConvert) Decode(str string) (string, bool) {
+	parts := strings.Split(strings.TrimSpace(str), ".")
+	if len(parts) != 3 {
+		return str, false
+	}
+	header, ok := decodeJwtPart(parts[0])
+	if !ok {
+		return str, false
+	}
+	var headerObj map[string]any
+	if err := json.Unmarshal(header, &headerObj); err != nil || headerObj["alg"] == nil {
+		return str, false
+	}
+	payload, ok := decodeJwtPart(parts[1])
+	if !ok {
+		return str, false
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+		Nbf *float64 `json:"nbf"`
+		Iat *float64 `json:"iat"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return str, false
+	}
+
+	content := jwtContent{
+		Header:    header,
+		Payload:   payload,
+		Signature: parts[2],
+		Token:     strings.TrimSpace(str),
+	}
+	now := time.Now()
+	loc := currentTimezone()
+	formatClaim := func(val *float64) (time.Time, string) {
+		t := time.Unix(int64(*val), 0)
+		return t, t.In(loc).Format(time.RFC3339)
+	}
+	if claims.Iat != nil {
+		_, content.IssuedAt = formatClaim(claims.Iat)
+	}
+	content.Status = JWT_STATUS_NO_EXPIRY
+	if claims.Exp != nil {
+		var exp time.Time
+		exp, content.ExpiresAt = formatClaim(claims.Exp)
+		if now.After(exp) {
+			content.Status = JWT_STATUS_EXPIRED
+		} else {
+			content.Status = JWT_STATUS_VALID
+		}
+	}
+	if claims.Nbf != nil {
+		var nbf time.Time
+		nbf, content.NotBefore = formatClaim(claims.Nbf)
+		if now.Before(nbf) {
+			content.Status = JWT_STATUS_NOT_YET
+		}
+	}
+
+	b, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return str, false
+	}
+	return string(b), true
+}
+
+// decode base64url segment of token which must be a JSON object
+func decodeJwtPart(part string) ([]byte, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(part, "="))
+	if err != nil {
+		return nil, false
+	}
+	trimed := bytes.TrimSpace(b)
+	if !bytes.HasPrefix(trimed, []byte("{")) || !json.Valid(trimed) {
+		return nil, false
+	}
+	return trimed, true
+}
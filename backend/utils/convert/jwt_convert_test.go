@@ -0,0 +1,114 @@
+package convutil
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func testJwtToken(header, payload string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(header)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(payload)) + ".signature"
+}
+
+func TestJwtConvertDecode(t *testing.T) {
+	SetTimezone("UTC")
+	defer SetTimezone("")
+
+	header := `{"alg":"HS256","typ":"JWT"}`
+	future := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	past := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+	tests := []struct {
+		name       string
+		token      string
+		wantOk     bool
+		wantStatus string
+	}{
+		{"no expiry", testJwtToken(header, `{"sub":"1"}`), true, JWT_STATUS_NO_EXPIRY},
+		{"valid", testJwtToken(header, `{"sub":"1","exp":`+future+`}`), true, JWT_STATUS_VALID},
+		{"expired", testJwtToken(header, `{"exp":`+past+`}`), true, JWT_STATUS_EXPIRED},
+		{"not yet valid", testJwtToken(header, `{"nbf":`+future+`,"exp":`+future+`}`), true, JWT_STATUS_NOT_YET},
+		{"padded segments", base64.URLEncoding.EncodeToString([]byte(header)) + "." +
+			base64.URLEncoding.EncodeToString([]byte(`{"sub":"1"}`)) + ".sig", true, JWT_STATUS_NO_EXPIRY},
+		{"missing alg", testJwtToken(`{"typ":"JWT"}`, `{}`), false, ""},
+		{"payload not object", testJwtToken(header, `[1,2]`), false, ""},
+		{"two parts", "abc.def", false, ""},
+		{"not base64", "a$b.c$d.e", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := JwtConvert{}.Decode(tt.token)
+			if ok != tt.wantOk {
+				t.Fatalf("Decode() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				if got != tt.token {
+					t.Errorf("Decode() should return original value on failure, got %q", got)
+				}
+				return
+			}
+			var content jwtContent
+			if err := json.Unmarshal([]byte(got), &content); err != nil {
+				t.Fatal(err)
+			}
+			if content.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", content.Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestJwtConvertRoundTrip(t *testing.T) {
+	token := testJwtToken(`{"alg":"HS256","typ":"JWT"}`, `{"sub":"1","iat":1700000000}`)
+	decoded, ok := JwtConvert{}.Decode(token)
+	if !ok {
+		t.Fatal("Decode() failed")
+	}
+	if encoded, ok := (JwtConvert{}).Encode(decoded); !ok || encoded != token {
+		t.Errorf("Encode(Decode()) = %q, %v, want %q", encoded, ok, token)
+	}
+	if _, ok := (JwtConvert{}).Encode("not json"); ok {
+		t.Error("Encode() should fail with invalid content")
+	}
+}
+
+func TestJwtConvertEncode(t *testing.T) {
+	header := base64.URLEncoding.EncodeToString([]byte(`{"alg":"HS256", "typ":"JWT"}`))
+	payload := base64.URLEncoding.EncodeToString([]byte(`{"sub":"1"}`))
+	token := header + "." + payload + ".sig"
+	decoded, ok := JwtConvert{}.Decode(token)
+	if !ok {
+		t.Fatal("Decode() failed")
+	}
+	var content jwtContent
+	if err := json.Unmarshal([]byte(decoded), &content); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(c *jwtContent)
+		want   string
+	}{
+		{"unchanged", func(c *jwtContent) {}, token},
+		{"signature edited", func(c *jwtContent) { c.Signature = "sig2" }, header + "." + payload + ".sig2"},
+		{"payload edited", func(c *jwtContent) { c.Payload = json.RawMessage(`{"sub":"2"}`) },
+			header + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"2"}`)) + ".sig"},
+		{"payload reformatted", func(c *jwtContent) { c.Payload = json.RawMessage("{\n  \"sub\": \"1\"\n}") }, token},
+		{"no original token", func(c *jwtContent) { c.Token = "" },
+			base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+				base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"1"}`)) + ".sig"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := content
+			tt.modify(&c)
+			b, _ := json.MarshalIndent(c, "", "  ")
+			if got, ok := (JwtConvert{}).Encode(string(b)); !ok || got != tt.want {
+				t.Errorf("Encode() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+}
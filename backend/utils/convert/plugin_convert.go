@@ -0,0 +1,270 @@
+package convutil
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PluginConvert decoder served by a long-running executable
+// the executable is started once and speaks a framed protocol over stdin/stdout,
+// each frame is a 4-byte big-endian length followed by a JSON body:
+//
+//	request:  {"id": 1, "action": "decode", "values": ["<base64>", ...]}
+//	response: {"id": 1, "values": ["<base64>", null, ...], "error": ""}
+//
+// null in response values indicates the value can not be converted
+type PluginConvert struct {
+	Name    string
+	Path    string
+	Args    []string
+	Timeout time.Duration
+}
+
+type pluginRequest struct {
+	ID     uint64   `json:"id"`
+	Action string   `json:"action"`
+	Values []string `json:"values"`
+}
+
+type pluginResponse struct {
+	ID     uint64    `json:"id"`
+	Values []*string `json:"values"`
+	Error  string    `json:"error,omitempty"`
+}
+
+type pluginProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	nextID uint64
+	mutex  sync.Mutex
+}
+
+const defaultPluginTimeout = 10 * time.Second
+const maxPluginFrameSize = 256 << 20
+
+var errPluginTimeout = errors.New("plugin timeout")
+
+var pluginPool = map[string]*pluginProcess{}
+var pluginMutex sync.Mutex
+
+func (p PluginConvert) Enable() bool {
+	return len(p.Path) > 0
+}
+
+func (p PluginConvert) Encode(str string) (string, bool) {
+	values, oks := p.call("encode", []string{str})
+	return values[0], oks[0]
+}
+
+func (p PluginConvert) Decode(str string) (string, bool) {
+	values, oks := p.call("decode", []string{str})
+	return values[0], oks[0]
+}
+
+// DecodeBatch decode multiple values in a single request
+func (p PluginConvert) DecodeBatch(strs []string) ([]string, []bool) {
+	return p.call("decode", strs)
+}
+
+func (p PluginConvert) poolKey() string {
+	return p.Name + "\x00" + p.Path + "\x00" + strings.Join(p.Args, "\x00")
+}
+
+// send request to plugin process, restart the process and retry once if it has crashed
+func (p PluginConvert) call(action string, strs []string) ([]string, []bool) {
+	values, oks := make([]string, len(strs)), make([]bool, len(strs))
+	copy(values, strs)
+	if !p.Enable() || len(strs) <= 0 {
+		return values, oks
+	}
+
+	timeout := p.Timeout
+	if timeout <= 0 {
+		timeout = defaultPluginTimeout
+	}
+	encoded := make([]string, len(strs))
+	for i, str := range strs {
+		encoded[i] = base64.StdEncoding.EncodeToString([]byte(str))
+	}
+
+	var resp *pluginResponse
+	var err error
+	for retry := 0; retry < 2; retry++ {
+		var proc *pluginProcess
+		if proc, err = p.process(); err != nil {
+			break
+		}
+		if resp, err = proc.request(action, encoded, timeout); err == nil {
+			break
+		}
+		// process crashed or hung, kill it and restart in next attempt
+		p.kill(proc)
+		if errors.Is(err, errPluginTimeout) {
+			// do not resend request which may hang again
+			break
+		}
+	}
+	if err != nil || resp == nil || len(resp.Error) > 0 || len(resp.Values) != len(strs) {
+		return values, oks
+	}
+
+	for i, val := range resp.Values {
+		if val == nil {
+			continue
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(*val); err == nil {
+			values[i], oks[i] = string(decoded), true
+		}
+	}
+	return values, oks
+}
+
+// get running plugin process or start a new one
+func (p PluginConvert) process() (*pluginProcess, error) {
+	pluginMutex.Lock()
+	defer pluginMutex.Unlock()
+
+	key := p.poolKey()
+	if proc, ok := pluginPool[key]; ok {
+		return proc, nil
+	}
+
+	cmd := newCommand(p.Path, p.Args...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, err
+	}
+	proc := &pluginProcess{
+		cmd:    cmd,
+		stdin:  stdin,
+		stdout: bufio.NewReader(stdout),
+	}
+	pluginPool[key] = proc
+	go cmd.Wait()
+	return proc, nil
+}
+
+func (p PluginConvert) kill(proc *pluginProcess) {
+	pluginMutex.Lock()
+	defer pluginMutex.Unlock()
+
+	key := p.poolKey()
+	if pluginPool[key] == proc {
+		delete(pluginPool, key)
+	}
+	proc.close()
+}
+
+func (p *pluginProcess) close() {
+	p.stdin.Close()
+	if p.cmd.Process != nil {
+		p.cmd.Process.Kill()
+	}
+}
+
+// write one request frame and wait for the response frame with timeout
+func (p *pluginProcess) request(action string, values []string, timeout time.Duration) (*pluginResponse, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.nextID += 1
+	id := p.nextID
+	body, err := json.Marshal(pluginRequest{
+		ID:     id,
+		Action: action,
+		Values: values,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		resp *pluginResponse
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		var res result
+		if res.err = writeFrame(p.stdin, body); res.err == nil {
+			res.resp, res.err = p.readResponse(id)
+		}
+		ch <- res
+	}()
+
+	select {
+	case res := <-ch:
+		return res.resp, res.err
+	case <-time.After(timeout):
+		return nil, errPluginTimeout
+	}
+}
+
+// read frames until the response of specified id is found
+func (p *pluginProcess) readResponse(id uint64) (*pluginResponse, error) {
+	for {
+		body, err := readFrame(p.stdout)
+		if err != nil {
+			return nil, err
+		}
+		var resp pluginResponse
+		if err = json.Unmarshal(body, &resp); err != nil {
+			return nil, err
+		}
+		if resp.ID == id {
+			return &resp, nil
+		}
+	}
+}
+
+func writeFrame(w io.Writer, body []byte) error {
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(body)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.Write(body)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if size > maxPluginFrameSize {
+		return nil, errors.New("plugin frame too large")
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// StopPlugins stop all running plugin processes
+func StopPlugins() {
+	pluginMutex.Lock()
+	defer pluginMutex.Unlock()
+
+	for key, proc := range pluginPool {
+		proc.close()
+		delete(pluginPool, key)
+	}
+}
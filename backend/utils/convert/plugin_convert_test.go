@@ -0,0 +1,154 @@
+package convutil
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPluginFrame(t *testing.T) {
+	tests := []struct {
+		name string
+		body []byte
+	}{
+		{"empty", []byte{}},
+		{"json", []byte(`{"id":1,"action":"decode","values":["aGVsbG8="]}`)},
+		{"binary", []byte{0, 1, 2, 0xff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := writeFrame(&buf, tt.body); err != nil {
+				t.Fatal(err)
+			}
+			if size := binary.BigEndian.Uint32(buf.Bytes()[:4]); int(size) != len(tt.body) {
+				t.Errorf("header = %d, want %d", size, len(tt.body))
+			}
+			body, err := readFrame(&buf)
+			if err != nil || !bytes.Equal(body, tt.body) {
+				t.Errorf("readFrame() = %v, %v, want %v", body, err, tt.body)
+			}
+		})
+	}
+}
+
+func TestPluginFrameError(t *testing.T) {
+	tooLarge := make([]byte, 4)
+	binary.BigEndian.PutUint32(tooLarge, maxPluginFrameSize+1)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"truncated header", []byte{0, 0}},
+		{"truncated body", []byte{0, 0, 0, 5, 'a', 'b'}},
+		{"too large", tooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readFrame(bytes.NewReader(tt.data)); err == nil {
+				t.Error("readFrame() should fail")
+			}
+		})
+	}
+}
+
+// TestPluginHelperProcess is not a real test, it serves as plugin executable for TestPluginConvert.
+// values are upper-cased on decode and lower-cased on encode, and some values trigger special behaviors
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("TINYRDM_PLUGIN_HELPER") != "1" {
+		return
+	}
+	defer os.Exit(0)
+	for {
+		body, err := readFrame(os.Stdin)
+		if err != nil {
+			return
+		}
+		var req pluginRequest
+		json.Unmarshal(body, &req)
+		resp := pluginResponse{ID: req.ID}
+		for _, val := range req.Values {
+			decoded, _ := base64.StdEncoding.DecodeString(val)
+			str := string(decoded)
+			switch str {
+			case "crash":
+				os.Exit(1)
+			case "hang":
+				time.Sleep(time.Minute)
+			case "fail":
+				resp.Values = append(resp.Values, nil)
+				continue
+			case "error":
+				resp.Error = "plugin error"
+			}
+			if req.Action == "encode" {
+				str = strings.ToLower(str)
+			} else {
+				str = strings.ToUpper(str)
+			}
+			encoded := base64.StdEncoding.EncodeToString([]byte(str))
+			resp.Values = append(resp.Values, &encoded)
+		}
+		// stale response of other request should be skipped
+		stale, _ := json.Marshal(pluginResponse{ID: req.ID + 1000})
+		writeFrame(os.Stdout, stale)
+		out, _ := json.Marshal(resp)
+		writeFrame(os.Stdout, out)
+	}
+}
+
+func TestPluginConvert(t *testing.T) {
+	t.Setenv("TINYRDM_PLUGIN_HELPER", "1")
+	defer StopPlugins()
+
+	conv := PluginConvert{
+		Name:    "test",
+		Path:    os.Args[0],
+		Args:    []string{"-test.run=^TestPluginHelperProcess$"},
+		Timeout: 2 * time.Second,
+	}
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantOks []bool
+	}{
+		{"single", []string{"hello"}, []string{"HELLO"}, []bool{true}},
+		{"batch", []string{"a", "", "b"}, []string{"A", "", "B"}, []bool{true, true, true}},
+		{"partial fail", []string{"a", "fail"}, []string{"A", "fail"}, []bool{true, false}},
+		{"plugin error", []string{"a", "error"}, []string{"a", "error"}, []bool{false, false}},
+		{"crash", []string{"crash"}, []string{"crash"}, []bool{false}},
+		{"restart after crash", []string{"hello"}, []string{"HELLO"}, []bool{true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, oks := conv.DecodeBatch(tt.values)
+			if !reflect.DeepEqual(values, tt.want) || !reflect.DeepEqual(oks, tt.wantOks) {
+				t.Errorf("DecodeBatch(%v) = %v, %v, want %v, %v", tt.values, values, oks, tt.want, tt.wantOks)
+			}
+		})
+	}
+
+	if val, ok := conv.Encode("HELLO"); !ok || val != "hello" {
+		t.Errorf("Encode() = %q, %v", val, ok)
+	}
+
+	conv.Timeout = 200 * time.Millisecond
+	if val, ok := conv.Decode("hang"); ok || val != "hang" {
+		t.Errorf("Decode() should time out, got %q, %v", val, ok)
+	}
+	if val, ok := conv.Decode("hello"); !ok || val != "HELLO" {
+		t.Errorf("Decode() after timeout = %q, %v", val, ok)
+	}
+
+	disabled := PluginConvert{Name: "disabled"}
+	if val, ok := disabled.Decode("hello"); ok || val != "hello" {
+		t.Errorf("Decode() without path = %q, %v", val, ok)
+	}
+}
@@ -0,0 +1,109 @@
+package convutil
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimestampConvert render epoch seconds/millis/micros/nanos as ISO 8601 time
+// the unit is detected by digit count, and kept by fraction precision when encoding back
+type TimestampConvert struct{}
+
+var timezone = time.Local
+var timezoneMutex sync.RWMutex
+
+var epochPattern = regexp.MustCompile(`^\d{1,19}$`)
+
+// SetTimezone set timezone used to display timestamps, empty name means local timezone,
+// the current timezone is kept if name is unknown
+func SetTimezone(name string) error {
+	loc := time.Local
+	if len(name) > 0 {
+		var err error
+		if loc, err = time.LoadLocation(name); err != nil {
+			return err
+		}
+	}
+	timezoneMutex.Lock()
+	timezone = loc
+	timezoneMutex.Unlock()
+	return nil
+}
+
+func currentTimezone() *time.Location {
+	timezoneMutex.RLock()
+	defer timezoneMutex.RUnlock()
+	return timezone
+}
+
+func (TimestampConvert) Enable() bool {
+	return true
+}
+
+func (TimestampConvert) Encode(str string) (string, bool) {
+	trimedStr := strings.TrimSpace(str)
+	if epochPattern.MatchString(trimedStr) {
+		return trimedStr, true
+	}
+	t, err := time.Parse(time.RFC3339Nano, trimedStr)
+	if err != nil {
+		return str, false
+	}
+	// restore unit by the precision of fraction part
+	fraction := 0
+	if idx := strings.IndexByte(trimedStr, '.'); idx >= 0 {
+		for _, c := range trimedStr[idx+1:] {
+			if c < '0' || c > '9' {
+				break
+			}
+			fraction += 1
+		}
+	}
+	var epoch int64
+	switch {
+	case fraction <= 0:
+		epoch = t.Unix()
+	case fraction <= 3:
+		epoch = t.UnixMilli()
+	case fraction <= 6:
+		epoch = t.UnixMicro()
+	default:
+		epoch = t.UnixNano()
+	}
+	return strconv.FormatInt(epoch, 10), true
+}
+
+func (TimestampConvert) Decode(str string) (string, bool) {
+	t, layout, ok := parseEpoch(str)
+	if !ok {
+		return str, false
+	}
+	return t.In(currentTimezone()).Format(layout), true
+}
+
+// parse epoch time and get the layout with matched precision
+func parseEpoch(str string) (t time.Time, layout string, ok bool) {
+	trimedStr := strings.TrimSpace(str)
+	if !epochPattern.MatchString(trimedStr) {
+		return
+	}
+	epoch, err := strconv.ParseInt(trimedStr, 10, 64)
+	if err != nil {
+		return
+	}
+	switch size := len(strings.TrimLeft(trimedStr, "0")); {
+	case size <= 10:
+		t, layout = time.Unix(epoch, 0), "2006-01-02T15:04:05Z07:00"
+	case size <= 13:
+		t, layout = time.UnixMilli(epoch), "2006-01-02T15:04:05.000Z07:00"
+	case size <= 16:
+		t, layout = time.UnixMicro(epoch), "2006-01-02T15:04:05.000000Z07:00"
+	default:
+		t, layout = time.Unix(0, epoch), "2006-01-02T15:04:05.000000000Z07:00"
+	}
+	ok = true
+	return
+}
@@ -0,0 +1,67 @@
+package convutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTimestampConvert(t *testing.T) {
+	if err := SetTimezone("UTC"); err != nil {
+		t.Fatal(err)
+	}
+	defer SetTimezone("")
+
+	tests := []struct {
+		name   string
+		epoch  string
+		want   string
+		wantOk bool
+	}{
+		{"seconds", "1700000000", "2023-11-14T22:13:20Z", true},
+		{"millis", "1700000000123", "2023-11-14T22:13:20.123Z", true},
+		{"micros", "1700000000123456", "2023-11-14T22:13:20.123456Z", true},
+		{"nanos", "1700000000123456789", "2023-11-14T22:13:20.123456789Z", true},
+		{"zero", "0", "1970-01-01T00:00:00Z", true},
+		{"spaces", " 1700000000 ", "2023-11-14T22:13:20Z", true},
+		{"negative", "-1", "-1", false},
+		{"not number", "abc", "abc", false},
+		{"too long", "12345678901234567890", "12345678901234567890", false},
+	}
+	var conv TimestampConvert
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := conv.Decode(tt.epoch)
+			if got != tt.want || ok != tt.wantOk {
+				t.Fatalf("Decode(%q) = %q, %v, want %q, %v", tt.epoch, got, ok, tt.want, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+			// unit is restored by precision when encoding back
+			if back, ok := conv.Encode(got); !ok || back != strings.TrimSpace(tt.epoch) {
+				t.Errorf("Encode(%q) = %q, %v, want %q", got, back, ok, tt.epoch)
+			}
+		})
+	}
+}
+
+func TestTimestampConvertTimezone(t *testing.T) {
+	if err := SetTimezone("Asia/Tokyo"); err != nil {
+		t.Skip("timezone database not available")
+	}
+	defer SetTimezone("")
+
+	var conv TimestampConvert
+	if got, _ := conv.Decode("1700000000"); got != "2023-11-15T07:13:20+09:00" {
+		t.Errorf("Decode() = %q", got)
+	}
+	if back, _ := conv.Encode("2023-11-15T07:13:20+09:00"); back != "1700000000" {
+		t.Errorf("Encode() = %q", back)
+	}
+	if err := SetTimezone("Invalid/Zone"); err == nil {
+		t.Error("SetTimezone should fail with unknown name")
+	}
+	if got, _ := conv.Decode("1700000000"); got != "2023-11-15T07:13:20+09:00" {
+		t.Errorf("timezone should be kept after invalid name, got %q", got)
+	}
+}
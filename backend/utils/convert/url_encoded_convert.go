@@ -0,0 +1,65 @@
+package convutil
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// UrlEncodedConvert expand URL-encoded query string into key/value pairs,
+// the order and duplicated keys are preserved
+type UrlEncodedConvert struct{}
+
+type urlEncodedPair struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func (UrlEncodedConvert) Enable() bool {
+	return true
+}
+
+func (UrlEncodedConvert) Encode(str string) (string, bool) {
+	var pairs []urlEncodedPair
+	if err := json.Unmarshal([]byte(str), &pairs); err != nil {
+		return str, false
+	}
+	var sb strings.Builder
+	for i, pair := range pairs {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(url.QueryEscape(pair.Key))
+		sb.WriteByte('=')
+		sb.WriteString(url.QueryEscape(pair.Value))
+	}
+	return sb.String(), true
+}
+
+func (UrlEncodedConvert) Decode(str string) (string, bool) {
+	query := strings.TrimPrefix(strings.TrimSpace(str), "?")
+	if len(query) <= 0 || !strings.Contains(query, "=") || strings.ContainsAny(query, " \t\r\n") {
+		return str, false
+	}
+	var pairs []urlEncodedPair
+	for _, segment := range strings.Split(query, "&") {
+		if len(segment) <= 0 {
+			continue
+		}
+		key, value, _ := strings.Cut(segment, "=")
+		var err error
+		var pair urlEncodedPair
+		if pair.Key, err = url.QueryUnescape(key); err != nil {
+			return str, false
+		}
+		if pair.Value, err = url.QueryUnescape(value); err != nil {
+			return str, false
+		}
+		pairs = append(pairs, pair)
+	}
+	b, err := json.MarshalIndent(pairs, "", "  ")
+	if err != nil {
+		return str, false
+	}
+	return string(b), true
+}
@@ -0,0 +1,57 @@
+package convutil
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestUrlEncodedConvertDecode(t *testing.T) {
+	tests := []struct {
+		name   string
+		str    string
+		want   []urlEncodedPair
+		wantOk bool
+	}{
+		{"simple", "a=1&b=2", []urlEncodedPair{{"a", "1"}, {"b", "2"}}, true},
+		{"leading question mark", "?a=1", []urlEncodedPair{{"a", "1"}}, true},
+		{"escaped", "name=tiny+rdm&q=%E4%BD%A0%E5%A5%BD", []urlEncodedPair{{"name", "tiny rdm"}, {"q", "你好"}}, true},
+		{"duplicated keys kept in order", "k=2&k=1", []urlEncodedPair{{"k", "2"}, {"k", "1"}}, true},
+		{"empty value and segment", "a=&&b", []urlEncodedPair{{"a", ""}, {"b", ""}}, true},
+		{"no equal sign", "hello", nil, false},
+		{"contains space", "a=1 b=2", nil, false},
+		{"invalid escape", "a=%zz", nil, false},
+		{"empty", "", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := UrlEncodedConvert{}.Decode(tt.str)
+			if ok != tt.wantOk {
+				t.Fatalf("Decode(%q) ok = %v, want %v", tt.str, ok, tt.wantOk)
+			}
+			if !ok {
+				if got != tt.str {
+					t.Errorf("Decode() should return original value on failure, got %q", got)
+				}
+				return
+			}
+			var pairs []urlEncodedPair
+			if err := json.Unmarshal([]byte(got), &pairs); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(pairs, tt.want) {
+				t.Errorf("Decode(%q) = %v, want %v", tt.str, pairs, tt.want)
+			}
+		})
+	}
+}
+
+func TestUrlEncodedConvertEncode(t *testing.T) {
+	encoded, ok := UrlEncodedConvert{}.Encode(`[{"key":"name","value":"tiny rdm"},{"key":"k","value":"a&b"}]`)
+	if !ok || encoded != "name=tiny+rdm&k=a%26b" {
+		t.Errorf("Encode() = %q, %v", encoded, ok)
+	}
+	if _, ok = (UrlEncodedConvert{}).Encode("a=1"); ok {
+		t.Error("Encode() should fail with invalid content")
+	}
+}
@@ -0,0 +1,227 @@
+package convutil
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// WasmConvert decoder implemented by a WebAssembly module
+// the module runs in a sandbox without filesystem, network, environment or arguments,
+// and must export the functions below:
+//
+//	memory
+//	alloc(size: i32) -> i32               allocate input buffer in module memory
+//	decode(ptr: i32, len: i32) -> i64     return (output_ptr << 32 | output_len), 0 means fail
+//	encode(ptr: i32, len: i32) -> i64     same as decode, optional
+//	dealloc(ptr: i32, size: i32)          release buffer, optional
+type WasmConvert struct {
+	Name string
+	Path string
+}
+
+type wasmModule struct {
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	module   api.Module
+	modTime  time.Time
+	checked  time.Time // last time the file modification was checked
+	mutex    sync.Mutex
+}
+
+const wasmCallTimeout = 5 * time.Second
+const wasmMemoryLimitPages = 1024 // 64MB
+const wasmCheckInterval = time.Second
+
+var wasmModules = map[string]*wasmModule{}
+var wasmMutex sync.Mutex
+
+func (w WasmConvert) Enable() bool {
+	return len(w.Path) > 0
+}
+
+func (w WasmConvert) Encode(str string) (string, bool) {
+	return w.call("encode", str)
+}
+
+func (w WasmConvert) Decode(str string) (string, bool) {
+	return w.call("decode", str)
+}
+
+// DecodeBatch decode values with the module loaded once for the whole batch
+func (w WasmConvert) DecodeBatch(strs []string) ([]string, []bool) {
+	values := make([]string, len(strs))
+	oks := make([]bool, len(strs))
+	copy(values, strs)
+	if !w.Enable() {
+		return values, oks
+	}
+	mod, err := w.load()
+	if err != nil {
+		return values, oks
+	}
+	for i, str := range strs {
+		if output, err := mod.invoke("decode", []byte(str)); err == nil {
+			values[i], oks[i] = string(output), true
+		}
+	}
+	return values, oks
+}
+
+func (w WasmConvert) call(action, str string) (string, bool) {
+	if !w.Enable() {
+		return str, false
+	}
+	mod, err := w.load()
+	if err != nil {
+		return str, false
+	}
+	output, err := mod.invoke(action, []byte(str))
+	if err != nil {
+		return str, false
+	}
+	return string(output), true
+}
+
+// load compiled module from cache, recompile if the file has been modified,
+// modification is checked at most once per interval
+func (w WasmConvert) load() (*wasmModule, error) {
+	wasmMutex.Lock()
+	defer wasmMutex.Unlock()
+
+	now := time.Now()
+	mod, ok := wasmModules[w.Path]
+	if ok && now.Sub(mod.checked) < wasmCheckInterval {
+		return mod, nil
+	}
+
+	stat, err := os.Stat(w.Path)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if mod.modTime.Equal(stat.ModTime()) {
+			mod.checked = now
+			return mod, nil
+		}
+		mod.close()
+		delete(wasmModules, w.Path)
+	}
+
+	content, err := os.ReadFile(w.Path)
+	if err != nil {
+		return nil, err
+	}
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithCloseOnContextDone(true).
+		WithMemoryLimitPages(wasmMemoryLimitPages))
+	// provide WASI for modules built by common toolchains, no filesystem or network is granted
+	if _, err = wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	compiled, err := runtime.CompileModule(ctx, content)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, err
+	}
+	mod = &wasmModule{
+		runtime:  runtime,
+		compiled: compiled,
+		modTime:  stat.ModTime(),
+		checked:  now,
+	}
+	wasmModules[w.Path] = mod
+	return mod, nil
+}
+
+// instantiate module if not exists or closed by previous timeout
+func (m *wasmModule) instance(ctx context.Context) (api.Module, error) {
+	if m.module != nil && !m.module.IsClosed() {
+		return m.module, nil
+	}
+	module, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, err
+	}
+	m.module = module
+	return module, nil
+}
+
+func (m *wasmModule) invoke(action string, input []byte) ([]byte, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	module, err := m.instance(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	fn := module.ExportedFunction(action)
+	alloc := module.ExportedFunction("alloc")
+	if fn == nil || alloc == nil {
+		return nil, errors.New("function not exported")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), wasmCallTimeout)
+	defer cancel()
+
+	size := uint64(len(input))
+	res, err := alloc.Call(ctx, size)
+	if err != nil {
+		return nil, err
+	}
+	inputPtr := res[0]
+	if !module.Memory().Write(uint32(inputPtr), input) {
+		return nil, errors.New("write memory out of range")
+	}
+	dealloc := module.ExportedFunction("dealloc")
+	if dealloc != nil {
+		defer dealloc.Call(ctx, inputPtr, size)
+	}
+
+	if res, err = fn.Call(ctx, inputPtr, size); err != nil {
+		return nil, err
+	}
+	outputPtr, outputLen := uint32(res[0]>>32), uint32(res[0])
+	if outputPtr == 0 && outputLen == 0 {
+		return nil, errors.New(action + " fail")
+	}
+	output, ok := module.Memory().Read(outputPtr, outputLen)
+	if !ok {
+		return nil, errors.New("read memory out of range")
+	}
+	// copy output before releasing module memory
+	ret := make([]byte, len(output))
+	copy(ret, output)
+	if dealloc != nil {
+		dealloc.Call(ctx, uint64(outputPtr), uint64(outputLen))
+	}
+	return ret, nil
+}
+
+func (m *wasmModule) close() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.runtime.Close(context.Background())
+}
+
+// StopWasmModules release all compiled modules
+func StopWasmModules() {
+	wasmMutex.Lock()
+	defer wasmMutex.Unlock()
+
+	for path, mod := range wasmModules {
+		mod.close()
+		delete(wasmModules, path)
+	}
+}
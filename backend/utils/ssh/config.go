@@ -0,0 +1,302 @@
+package sshutil
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HostConfig options of a host in ssh config which are supported
+type HostConfig struct {
+	Alias         string   `json:"alias"`
+	HostName      string   `json:"hostName"`
+	User          string   `json:"user,omitempty"`
+	Port          int      `json:"port,omitempty"`
+	IdentityFiles []string `json:"identityFiles,omitempty"`
+	ProxyJump     string   `json:"proxyJump,omitempty"`
+}
+
+// JumpHost one hop parsed from ProxyJump, as [user@]host[:port]
+type JumpHost struct {
+	User string
+	Host string
+	Port int
+}
+
+type configBlock struct {
+	patterns []string
+	options  map[string][]string // lower case keyword to values, only the first occurrence is kept
+}
+
+const maxIncludeDepth = 8
+
+// ConfigPath returns path of ssh config of current user
+func ConfigPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".ssh", "config")
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// splitArgs split config line to arguments, double quoted argument is supported
+func splitArgs(line string) (args []string) {
+	var sb strings.Builder
+	var quoted, hasArg bool
+	for _, r := range line {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			hasArg = true
+		case !quoted && (r == ' ' || r == '\t'):
+			if hasArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				hasArg = false
+			}
+		default:
+			sb.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, sb.String())
+	}
+	return
+}
+
+// parseConfigFile read blocks from ssh config, options before the first "Host" belong to the patterns given
+func parseConfigFile(path string, patterns []string, depth int) (blocks []configBlock) {
+	file, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer file.Close()
+
+	current := &configBlock{patterns: patterns, options: map[string][]string{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) <= 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// keyword and arguments could be separated by "=" too
+		keyword, rest, _ := strings.Cut(line, " ")
+		if k, v, ok := strings.Cut(keyword, "="); ok {
+			keyword, rest = k, v+" "+rest
+		}
+		rest = strings.TrimLeft(strings.TrimSpace(rest), "=")
+		args := splitArgs(strings.TrimSpace(rest))
+		keyword = strings.ToLower(keyword)
+
+		switch keyword {
+		case "host":
+			blocks = append(blocks, *current)
+			current = &configBlock{patterns: args, options: map[string][]string{}}
+		case "match":
+			// match conditions are not supported, skip the whole block
+			blocks = append(blocks, *current)
+			current = &configBlock{options: map[string][]string{}}
+		case "include":
+			if depth >= maxIncludeDepth {
+				continue
+			}
+			blocks = append(blocks, *current)
+			for _, pattern := range args {
+				pattern = expandHome(pattern)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(filepath.Dir(ConfigPath()), pattern)
+				}
+				files, _ := filepath.Glob(pattern)
+				for _, f := range files {
+					blocks = append(blocks, parseConfigFile(f, current.patterns, depth+1)...)
+				}
+			}
+			// options after include still belong to the same host patterns
+			current = &configBlock{patterns: current.patterns, options: map[string][]string{}}
+		default:
+			if _, exists := current.options[keyword]; !exists || keyword == "identityfile" {
+				current.options[keyword] = append(current.options[keyword], args...)
+			}
+		}
+	}
+	blocks = append(blocks, *current)
+	return
+}
+
+// matchPattern match host with wildcard pattern which supports "*" and "?"
+func matchPattern(pattern, host string) bool {
+	matched, err := filepath.Match(pattern, host)
+	return err == nil && matched
+}
+
+func (b configBlock) match(host string) bool {
+	var matched bool
+	for _, pattern := range b.patterns {
+		if strings.HasPrefix(pattern, "!") {
+			if matchPattern(pattern[1:], host) {
+				return false
+			}
+		} else if matchPattern(pattern, host) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+func expandTokens(value string, cfg HostConfig) string {
+	if !strings.Contains(value, "%") {
+		return value
+	}
+	home, _ := os.UserHomeDir()
+	var localUser string
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+	port := cfg.Port
+	if port <= 0 {
+		port = 22
+	}
+	replacer := strings.NewReplacer(
+		"%%", "%",
+		"%d", home,
+		"%h", cfg.HostName,
+		"%n", cfg.Alias,
+		"%p", strconv.Itoa(port),
+		"%r", cfg.User,
+		"%u", localUser,
+	)
+	return replacer.Replace(value)
+}
+
+// LookupHost resolve options of host alias from ssh config of current user,
+// the first obtained value of each option is used like ssh does
+func LookupHost(alias string) (cfg HostConfig, found bool) {
+	cfg.Alias = alias
+	var identityFiles []string
+	for _, block := range parseConfigFile(ConfigPath(), []string{"*"}, 0) {
+		if !block.match(alias) {
+			continue
+		}
+		if !block.isWildcard() {
+			found = true
+		}
+		if v := block.options["hostname"]; len(v) > 0 && len(cfg.HostName) <= 0 {
+			cfg.HostName = v[0]
+		}
+		if v := block.options["user"]; len(v) > 0 && len(cfg.User) <= 0 {
+			cfg.User = v[0]
+		}
+		if v := block.options["port"]; len(v) > 0 && cfg.Port <= 0 {
+			cfg.Port, _ = strconv.Atoi(v[0])
+		}
+		if v := block.options["proxyjump"]; len(v) > 0 && len(cfg.ProxyJump) <= 0 {
+			cfg.ProxyJump = v[0]
+		}
+		identityFiles = append(identityFiles, block.options["identityfile"]...)
+	}
+
+	if len(cfg.HostName) <= 0 {
+		cfg.HostName = alias
+	} else {
+		// "%h" in HostName stands for the host given originally
+		original := cfg
+		original.HostName = alias
+		cfg.HostName = expandTokens(cfg.HostName, original)
+	}
+	for _, file := range identityFiles {
+		cfg.IdentityFiles = append(cfg.IdentityFiles, expandHome(expandTokens(file, cfg)))
+	}
+	if strings.EqualFold(cfg.ProxyJump, "none") {
+		cfg.ProxyJump = ""
+	}
+	return
+}
+
+func (b configBlock) isWildcard() bool {
+	for _, pattern := range b.patterns {
+		if !strings.ContainsAny(pattern, "*?!") {
+			return false
+		}
+	}
+	return true
+}
+
+// ListHosts list host aliases without wildcard in ssh config of current user
+func ListHosts() (hosts []string) {
+	seen := map[string]bool{}
+	for _, block := range parseConfigFile(ConfigPath(), []string{"*"}, 0) {
+		for _, pattern := range block.patterns {
+			if !strings.ContainsAny(pattern, "*?!") && !seen[pattern] {
+				seen[pattern] = true
+				hosts = append(hosts, pattern)
+			}
+		}
+	}
+	return
+}
+
+// ParseProxyJump parse hops of ProxyJump, separated by comma
+func ParseProxyJump(proxyJump string) (hops []JumpHost) {
+	for _, item := range strings.Split(proxyJump, ",") {
+		item = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(item), "ssh://"))
+		if len(item) <= 0 {
+			continue
+		}
+		var hop JumpHost
+		if at := strings.LastIndex(item, "@"); at >= 0 {
+			hop.User, item = item[:at], item[at+1:]
+		}
+		hop.Host = item
+		if strings.HasPrefix(item, "[") {
+			// [host]:port
+			if end := strings.Index(item, "]"); end > 0 {
+				hop.Host = item[1:end]
+				if port, ok := strings.CutPrefix(item[end+1:], ":"); ok {
+					hop.Port, _ = strconv.Atoi(port)
+				}
+			}
+		} else if host, port, ok := strings.Cut(item, ":"); ok && !strings.Contains(port, ":") {
+			hop.Host = host
+			hop.Port, _ = strconv.Atoi(port)
+		}
+		hops = append(hops, hop)
+	}
+	return
+}
+
+// ResolveProxyJump expand hops of ProxyJump for host in connecting order.
+// like ssh does, the first hop is connected through its own ProxyJump recursively,
+// and the following hops are connected through the previous one
+func ResolveProxyJump(host, proxyJump string) ([]JumpHost, error) {
+	return resolveProxyJump(proxyJump, map[string]bool{host: true})
+}
+
+func resolveProxyJump(proxyJump string, visited map[string]bool) ([]JumpHost, error) {
+	hops := ParseProxyJump(proxyJump)
+	if len(hops) <= 0 {
+		return nil, nil
+	}
+	first := hops[0].Host
+	if visited[first] {
+		return nil, fmt.Errorf("ProxyJump loop detected at host \"%s\"", first)
+	}
+	visited[first] = true
+	if cfg, _ := LookupHost(first); len(cfg.ProxyJump) > 0 {
+		prefix, err := resolveProxyJump(cfg.ProxyJump, visited)
+		if err != nil {
+			return nil, err
+		}
+		hops = append(prefix, hops...)
+	}
+	return hops, nil
+}
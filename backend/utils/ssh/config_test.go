@@ -0,0 +1,235 @@
+package sshutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+const testSSHConfig = `# global options
+User default
+
+Host web
+  HostName 10.0.0.1
+  User deploy
+  Port 2222
+  IdentityFile ~/.ssh/web_key
+  ProxyJump bastion
+
+Host bastion
+  HostName=bastion.example.com
+  Port = 2200
+  User admin
+
+Host db db-*
+  HostName %h.internal
+  IdentityFile "~/.ssh/db key"
+  ProxyJump none
+
+Host *.example.com !skip.example.com
+  User example
+
+Match host never
+  User matched
+
+Include conf.d/*
+
+Host *
+  IdentityFile ~/.ssh/id_ed25519
+  Port 22
+`
+
+const testSSHInclude = `Host included
+  HostName 192.168.1.1
+`
+
+const testSSHJump = `Host target
+  ProxyJump mid,other:2222
+Host mid
+  ProxyJump edge
+Host edge
+  HostName 1.2.3.4
+Host loop1
+  ProxyJump loop2
+Host loop2
+  ProxyJump loop1
+`
+
+// setup ssh config in a temporary home directory
+func setupSSHConfig(t *testing.T, content string, includes map[string]string) string {
+	if runtime.GOOS == "windows" {
+		t.Skip("home directory is not overridable")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ssh")
+	if err := os.MkdirAll(filepath.Join(dir, "conf.d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config"), []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for name, c := range includes {
+		if err := os.WriteFile(filepath.Join(dir, "conf.d", name), []byte(c), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+func TestLookupHost(t *testing.T) {
+	home := setupSSHConfig(t, testSSHConfig, map[string]string{"included": testSSHInclude})
+	tests := []struct {
+		alias     string
+		want      HostConfig
+		wantFound bool
+	}{
+		{
+			alias: "web",
+			want: HostConfig{
+				Alias:         "web",
+				HostName:      "10.0.0.1",
+				User:          "default",
+				Port:          2222,
+				IdentityFiles: []string{filepath.Join(home, ".ssh/web_key"), filepath.Join(home, ".ssh/id_ed25519")},
+				ProxyJump:     "bastion",
+			},
+			wantFound: true,
+		},
+		{
+			alias: "bastion",
+			want: HostConfig{
+				Alias:         "bastion",
+				HostName:      "bastion.example.com",
+				User:          "default",
+				Port:          2200,
+				IdentityFiles: []string{filepath.Join(home, ".ssh/id_ed25519")},
+			},
+			wantFound: true,
+		},
+		{
+			alias: "db-1",
+			want: HostConfig{
+				Alias:         "db-1",
+				HostName:      "db-1.internal",
+				User:          "default",
+				Port:          22,
+				IdentityFiles: []string{filepath.Join(home, ".ssh/db key"), filepath.Join(home, ".ssh/id_ed25519")},
+			},
+			wantFound: true,
+		},
+		{
+			alias: "included",
+			want: HostConfig{
+				Alias:         "included",
+				HostName:      "192.168.1.1",
+				User:          "default",
+				Port:          22,
+				IdentityFiles: []string{filepath.Join(home, ".ssh/id_ed25519")},
+			},
+			wantFound: true,
+		},
+		{
+			alias: "unknown",
+			want: HostConfig{
+				Alias:         "unknown",
+				HostName:      "unknown",
+				User:          "default",
+				Port:          22,
+				IdentityFiles: []string{filepath.Join(home, ".ssh/id_ed25519")},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alias, func(t *testing.T) {
+			got, found := LookupHost(tt.alias)
+			if found != tt.wantFound {
+				t.Errorf("LookupHost(%q) found = %v, want %v", tt.alias, found, tt.wantFound)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LookupHost(%q) = %+v, want %+v", tt.alias, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListHosts(t *testing.T) {
+	setupSSHConfig(t, testSSHConfig, map[string]string{"included": testSSHInclude})
+	want := []string{"web", "bastion", "db", "included"}
+	if got := ListHosts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("ListHosts() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"a b\tc", []string{"a", "b", "c"}},
+		{`"a b" c`, []string{"a b", "c"}},
+		{`  a   "" `, []string{"a", ""}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			if got := splitArgs(tt.line); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitArgs(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseProxyJump(t *testing.T) {
+	tests := []struct {
+		proxyJump string
+		want      []JumpHost
+	}{
+		{"bastion", []JumpHost{{Host: "bastion"}}},
+		{"user@host:2222", []JumpHost{{User: "user", Host: "host", Port: 2222}}},
+		{"a, b@c", []JumpHost{{Host: "a"}, {User: "b", Host: "c"}}},
+		{"ssh://u@h:22", []JumpHost{{User: "u", Host: "h", Port: 22}}},
+		{"[::1]:2200", []JumpHost{{Host: "::1", Port: 2200}}},
+		{"::1", []JumpHost{{Host: "::1"}}},
+		{"u@x@host", []JumpHost{{User: "u@x", Host: "host"}}},
+		{" , ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.proxyJump, func(t *testing.T) {
+			if got := ParseProxyJump(tt.proxyJump); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseProxyJump(%q) = %+v, want %+v", tt.proxyJump, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveProxyJump(t *testing.T) {
+	setupSSHConfig(t, testSSHJump, nil)
+	tests := []struct {
+		name      string
+		host      string
+		proxyJump string
+		want      []JumpHost
+		wantErr   bool
+	}{
+		{"no jump", "edge", "", nil, false},
+		{"without nested jump", "x", "edge", []JumpHost{{Host: "edge"}}, false},
+		{"nested jump of first hop", "target", "mid,other:2222",
+			[]JumpHost{{Host: "edge"}, {Host: "mid"}, {Host: "other", Port: 2222}}, false},
+		{"loop", "loop1", "loop2", nil, true},
+		{"jump to self", "mid", "edge,mid", []JumpHost{{Host: "edge"}, {Host: "mid"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveProxyJump(tt.host, tt.proxyJump)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveProxyJump() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ResolveProxyJump() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
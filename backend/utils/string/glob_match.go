@@ -0,0 +1,87 @@
+package strutil
+
+// MatchGlob reports whether str matches the redis style glob pattern
+// supports "*", "?", "[abc]", "[^a-z]" and backslash escape, same as "KEYS" and "SCAN MATCH"
+func MatchGlob(pattern, str string) bool {
+	p, s := []byte(pattern), []byte(str)
+	for len(p) > 0 {
+		switch p[0] {
+		case '*':
+			// collapse continuous stars
+			for len(p) > 1 && p[1] == '*' {
+				p = p[1:]
+			}
+			if len(p) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if MatchGlob(string(p[1:]), string(s[i:])) {
+					return true
+				}
+			}
+			return false
+
+		case '?':
+			if len(s) <= 0 {
+				return false
+			}
+			s = s[1:]
+
+		case '[':
+			if len(s) <= 0 {
+				return false
+			}
+			p = p[1:]
+			not := len(p) > 0 && p[0] == '^'
+			if not {
+				p = p[1:]
+			}
+			var matched bool
+			for len(p) > 0 && p[0] != ']' {
+				if p[0] == '\\' && len(p) >= 2 {
+					p = p[1:]
+					if p[0] == s[0] {
+						matched = true
+					}
+				} else if len(p) >= 3 && p[1] == '-' {
+					start, end := p[0], p[2]
+					if start > end {
+						start, end = end, start
+					}
+					if s[0] >= start && s[0] <= end {
+						matched = true
+					}
+					p = p[2:]
+				} else if p[0] == s[0] {
+					matched = true
+				}
+				p = p[1:]
+			}
+			if len(p) <= 0 {
+				// unclosed bracket, treat as end of pattern
+				return false
+			}
+			if not {
+				matched = !matched
+			}
+			if !matched {
+				return false
+			}
+			s = s[1:]
+
+		case '\\':
+			if len(p) >= 2 {
+				p = p[1:]
+			}
+			fallthrough
+
+		default:
+			if len(s) <= 0 || p[0] != s[0] {
+				return false
+			}
+			s = s[1:]
+		}
+		p = p[1:]
+	}
+	return len(s) <= 0
+}
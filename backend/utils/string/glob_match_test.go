@@ -0,0 +1,44 @@
+package strutil
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		str     string
+		want    bool
+	}{
+		{"*", "", true},
+		{"*", "user:1", true},
+		{"user:*", "user:1", true},
+		{"user:*", "order:1", false},
+		{"*:name", "user:1:name", true},
+		{"**:name", "user:1:name", true},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"user:?", "user:", false},
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hillo", false},
+		{"h[^e]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"h[c-a]llo", "hbllo", true},
+		{"h[a-c]llo", "hdllo", false},
+		{`h[\]]llo`, "h]llo", true},
+		{"h[abc", "ha", false},
+		{`user\*`, "user*", true},
+		{`user\*`, "user1", false},
+		{`user\?`, "user?", true},
+		{"", "", true},
+		{"", "a", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"|"+tt.str, func(t *testing.T) {
+			if got := MatchGlob(tt.pattern, tt.str); got != tt.want {
+				t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.str, got, tt.want)
+			}
+		})
+	}
+}
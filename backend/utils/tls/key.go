@@ -0,0 +1,174 @@
+package tlsutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/pbkdf2"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+var ErrIncorrectPassphrase = errors.New("incorrect passphrase of private key")
+var ErrPassphraseRequired = errors.New("private key is encrypted, passphrase is required")
+
+// maxPBKDF2Iterations upper limit of iteration count read from key file
+const maxPBKDF2Iterations = 10_000_000
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidDESEDE3CBC     = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+)
+
+type encryptedPrivateKeyInfo struct {
+	Algo          pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// DecryptPEMKey decrypt private key in PEM format, returns PEM of decrypted key which could be used by tls.X509KeyPair.
+// encrypted PKCS#8 key("ENCRYPTED PRIVATE KEY") with PBES2 and legacy encrypted PEM("Proc-Type: 4,ENCRYPTED") are supported,
+// key not encrypted is returned as it is
+func DecryptPEMKey(data []byte, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM data of private key found")
+	}
+
+	var der []byte
+	var err error
+	blockType := block.Type
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		if len(passphrase) <= 0 {
+			return nil, ErrPassphraseRequired
+		}
+		if der, err = decryptPKCS8(block.Bytes, []byte(passphrase)); err != nil {
+			return nil, err
+		}
+		if _, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+		blockType = "PRIVATE KEY"
+	case x509.IsEncryptedPEMBlock(block): // legacy format is still generated by openssl
+		if len(passphrase) <= 0 {
+			return nil, ErrPassphraseRequired
+		}
+		if der, err = x509.DecryptPEMBlock(block, []byte(passphrase)); err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+	default:
+		return data, nil
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
+}
+
+func decryptPKCS8(data, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid encrypted private key: %w", err)
+	}
+	if !info.Algo.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported encryption of private key: %s", info.Algo.Algorithm)
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algo.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("invalid PBES2 parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", params.KeyDerivationFunc.Algorithm)
+	}
+	var kdfParams pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+		return nil, fmt.Errorf("invalid PBKDF2 parameters: %w", err)
+	}
+	if kdfParams.IterationCount <= 0 || kdfParams.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("invalid PBKDF2 iteration count: %d", kdfParams.IterationCount)
+	}
+
+	var prf func() hash.Hash
+	switch prfOID := kdfParams.PRF.Algorithm; {
+	case len(prfOID) <= 0, prfOID.Equal(oidHMACWithSHA1):
+		prf = sha1.New
+	case prfOID.Equal(oidHMACWithSHA256):
+		prf = sha256.New
+	case prfOID.Equal(oidHMACWithSHA384):
+		prf = sha512.New384
+	case prfOID.Equal(oidHMACWithSHA512):
+		prf = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported PBKDF2 pseudorandom function: %s", prfOID)
+	}
+
+	var newCipher func(key []byte) (cipher.Block, error)
+	var keyLen int
+	switch scheme := params.EncryptionScheme.Algorithm; {
+	case scheme.Equal(oidAES128CBC):
+		newCipher, keyLen = aes.NewCipher, 16
+	case scheme.Equal(oidAES192CBC):
+		newCipher, keyLen = aes.NewCipher, 24
+	case scheme.Equal(oidAES256CBC):
+		newCipher, keyLen = aes.NewCipher, 32
+	case scheme.Equal(oidDESEDE3CBC):
+		newCipher, keyLen = des.NewTripleDESCipher, 24
+	default:
+		return nil, fmt.Errorf("unsupported encryption scheme: %s", scheme)
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("invalid encryption parameters: %w", err)
+	}
+
+	key, err := pbkdf2.Key(prf, string(passphrase), kdfParams.Salt, kdfParams.IterationCount, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	block, err := newCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != block.BlockSize() || len(info.EncryptedData) <= 0 || len(info.EncryptedData)%block.BlockSize() != 0 {
+		return nil, errors.New("invalid encrypted private key")
+	}
+	plain := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plain, info.EncryptedData)
+
+	// remove PKCS#7 padding, bad padding usually means wrong passphrase
+	padding := int(plain[len(plain)-1])
+	if padding <= 0 || padding > block.BlockSize() {
+		return nil, ErrIncorrectPassphrase
+	}
+	for _, b := range plain[len(plain)-padding:] {
+		if int(b) != padding {
+			return nil, ErrIncorrectPassphrase
+		}
+	}
+	return plain[:len(plain)-padding], nil
+}
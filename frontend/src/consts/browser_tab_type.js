@@ -9,4 +9,10 @@ export const BrowserTabType = {
     SlowLog: 'slow_log',
     CmdMonitor: 'cmd_monitor',
     PubMessage: 'pub_message',
+    Cluster: 'cluster',
+    Sentinel: 'sentinel',
+    Replication: 'replication',
+    ACL: 'acl',
+    Config: 'config',
+    Clients: 'clients',
 }
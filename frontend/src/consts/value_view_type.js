@@ -11,6 +11,9 @@ export const formatTypes = {
     HEX: 'Hex',
     BINARY: 'Binary',
     BITSET: 'BitSet',
+    TIMESTAMP: 'Timestamp',
+    JWT: 'JWT',
+    URL_ENCODED: 'URL Encoded',
 }
 
 /**
@@ -5,9 +5,13 @@ import {
     AddListItem,
     AddStreamValue,
     AddZSetValue,
+    AnalyzeSlowLogs,
     BatchSetTTL,
     CleanCmdHistory,
     CloseConnection,
+    ClusterFailover,
+    ClusterForget,
+    ClusterMeet,
     ConvertValue,
     DeleteKey,
     DeleteKeys,
@@ -15,20 +19,34 @@ import {
     ExportKey,
     FlushDB,
     GetClientList,
+    GetClusterTopology,
     GetCmdHistory,
     GetHashValue,
     GetKeyDetail,
     GetKeySummary,
     GetKeyType,
+    GetLatencyDoctor,
+    GetLatencyHistory,
+    GetLatestLatency,
+    GetReplicationStatus,
+    GetSentinelOverview,
     GetSlowLogs,
     ImportCSV,
     LoadAllKeys,
     LoadNextAllKeys,
     LoadNextKeys,
+    ListServerNodes,
+    MigrateClusterSlots,
     OpenConnection,
     OpenDatabase,
     RemoveStreamValues,
     RenameKey,
+    ReplicaOf,
+    ResetLatency,
+    ResetSlowLogs,
+    SentinelCheckQuorum,
+    SentinelFailover,
+    SentinelReset,
     ServerInfo,
     SetHashValue,
     SetKeyTTL,
@@ -245,7 +263,10 @@ const useBrowserStore = defineStore('browser', {
 
             const { data, success, msg } = await OpenConnection(name)
             if (!success) {
-                throw new Error(msg)
+                const err = new Error(msg)
+                err.locked = get(data, 'locked', false)
+                err.hostKey = get(data, 'hostKey')
+                throw err
             }
             // append to db node to current connection
             // const connNode = this.getConnection(name)
@@ -2074,6 +2095,190 @@ const useBrowserStore = defineStore('browser', {
             return []
         },
 
+        /**
+         * list nodes which browser could be pinned to
+         * @param {string} server
+         * @return {Promise<{nodes: {addr: string, role: string, healthy: boolean}[], pinned: string}>}
+         */
+        async listServerNodes(server) {
+            const { success, msg, data } = await ListServerNodes(server)
+            if (!success) {
+                throw new Error(msg)
+            }
+            const { nodes = [], pinned = '' } = data || {}
+            return { nodes, pinned }
+        },
+
+        /**
+         * pin browser, slow log and client list to specified node, reconnect and reload keys after pinned
+         * @param {string} server
+         * @param {string} addr empty to unpin
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async pinNode(server, addr) {
+            const connStore = useConnectionStore()
+            const { success, msg } = await connStore.savePinnedNode(server, addr)
+            if (!success) {
+                return { success: false, msg }
+            }
+            // connect to new node on next request
+            await CloseConnection(server)
+            this.reloadServer(server)
+            return { success: true }
+        },
+
+        /**
+         * get replication status of master and replicas
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {nodes: Object[]}}>}
+         */
+        async getReplicationStatus(server) {
+            return await GetReplicationStatus(server)
+        },
+
+        /**
+         * make node replicate from master, or promote it to master if host is empty
+         * @param {string} server
+         * @param {string} addr address of node
+         * @param {string} [host]
+         * @param {number} [port]
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async replicaOf(server, addr, host = '', port = 0) {
+            return await ReplicaOf(server, addr, host, port)
+        },
+
+        /**
+         * get masters monitored by sentinel with replicas and other sentinels
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string, [data]: Object}>}
+         */
+        async getSentinelOverview(server) {
+            return await GetSentinelOverview(server)
+        },
+
+        /**
+         * force failover of master monitored by sentinel
+         * @param {string} server
+         * @param {string} master
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async sentinelFailover(server, master) {
+            return await SentinelFailover(server, master)
+        },
+
+        /**
+         * reset masters matching pattern in sentinel
+         * @param {string} server
+         * @param {string} pattern
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {count: number}}>}
+         */
+        async sentinelReset(server, pattern) {
+            return await SentinelReset(server, pattern)
+        },
+
+        /**
+         * check if sentinels could reach quorum of master
+         * @param {string} server
+         * @param {string} master
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {ok: boolean, msg: string}}>}
+         */
+        async sentinelCheckQuorum(server, master) {
+            return await SentinelCheckQuorum(server, master)
+        },
+
+        /**
+         * get nodes and slots of cluster
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string, [data]: Object}>}
+         */
+        async getClusterTopology(server) {
+            return await GetClusterTopology(server)
+        },
+
+        /**
+         * promote replica to master
+         * @param {string} server
+         * @param {string} nodeId
+         * @param {string} [mode] empty, "force" or "takeover"
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async clusterFailover(server, nodeId, mode = '') {
+            return await ClusterFailover(server, nodeId, mode)
+        },
+
+        /**
+         * remove node from cluster
+         * @param {string} server
+         * @param {string} nodeId
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async clusterForget(server, nodeId) {
+            return await ClusterForget(server, nodeId)
+        },
+
+        /**
+         * add node to cluster
+         * @param {string} server
+         * @param {string} host
+         * @param {number} port
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async clusterMeet(server, host, port) {
+            return await ClusterMeet(server, host, port)
+        },
+
+        /**
+         * move slot range with keys from source master to target master
+         * @param {string} server
+         * @param {string} source id of source node
+         * @param {string} target id of target node
+         * @param {number} startSlot
+         * @param {number} endSlot
+         * @return {Promise<boolean>} all slots migrated
+         */
+        async migrateClusterSlots(server, source, target, startSlot, endSlot) {
+            const msgRef = $message.loading('', { duration: 0, closable: true })
+            const serialNo = Date.now().valueOf().toString()
+            const cancelEventFn = EventsOn('migrating:' + serialNo, ({ total, progress, slot, keys }) => {
+                msgRef.content = i18nGlobal.t('cluster.migrating', { slot, index: progress, count: total, keys })
+            })
+            msgRef.onClose = () => {
+                EventsEmit('migrate:stop:' + serialNo)
+            }
+            let result = {}
+            try {
+                const { success, msg, data } = await MigrateClusterSlots({
+                    server,
+                    source,
+                    target,
+                    startSlot,
+                    endSlot,
+                    serialNo,
+                })
+                if (!success) {
+                    $message.error(msg)
+                    return false
+                }
+                result = data || {}
+            } finally {
+                msgRef.destroy()
+                cancelEventFn()
+            }
+            const { canceled = false, migratedSlots = 0, migratedKeys = 0, error = '', unstableSlot } = result
+            if (unstableSlot != null) {
+                // keys are moved partially, slot is left in importing/migrating state
+                $notification.warning(i18nGlobal.t('cluster.migrate_unstable', { slot: unstableSlot, reason: error }))
+            } else if (canceled) {
+                $message.info(i18nGlobal.t('dialogue.handle_cancel'))
+            } else if (!isEmpty(error)) {
+                $message.error(error)
+            } else {
+                $message.success(i18nGlobal.t('cluster.migrate_completed', { slots: migratedSlots, keys: migratedKeys }))
+            }
+            return !canceled && isEmpty(error)
+        },
+
         /**
          * get slow log list
          * @param {string} server
@@ -2090,6 +2295,63 @@ const useBrowserStore = defineStore('browser', {
             }
         },
 
+        /**
+         * group slow logs by normalized command
+         * @param {string} server
+         * @param {number} num
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {groups: Object[], total: number}}>}
+         */
+        async analyzeSlowLog(server, num) {
+            return await AnalyzeSlowLogs(server, num)
+        },
+
+        /**
+         * clean slow logs of server
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async resetSlowLog(server) {
+            return await ResetSlowLogs(server)
+        },
+
+        /**
+         * get latest latency spike of each event
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {events: Object[]}}>}
+         */
+        async getLatestLatency(server) {
+            return await GetLatestLatency(server)
+        },
+
+        /**
+         * get latency samples of event
+         * @param {string} server
+         * @param {string} node empty for connected node
+         * @param {string} event
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {samples: Object[]}}>}
+         */
+        async getLatencyHistory(server, node, event) {
+            return await GetLatencyHistory(server, node, event)
+        },
+
+        /**
+         * get latency analysis report
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {report: string}}>}
+         */
+        async getLatencyDoctor(server) {
+            return await GetLatencyDoctor(server)
+        },
+
+        /**
+         * clean latency data of all events
+         * @param {string} server
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async resetLatency(server) {
+            return await ResetLatency(server)
+        },
+
         /**
          * get key filter pattern and filter type
          * @param {string} server
@@ -1,25 +1,34 @@
 import { defineStore } from 'pinia'
 import { get, isEmpty, isObject, union, uniq } from 'lodash'
 import {
+    ApplyImportConnections,
     CreateGroup,
     DeleteConnection,
     DeleteGroup,
     ExportConnections,
     GetConnection,
+    GetConnectionSnippets,
+    GetSecretStatus,
     ImportConnections,
     ListConnection,
+    ListSSHTunnels,
+    LockSecrets,
     ParseConnectURL,
     RenameGroup,
     SaveConnection,
     SaveLastDB,
+    SavePinnedNode,
     SaveRefreshInterval,
-    SaveSortedConnection
+    SaveSortedConnection,
+    SwitchSecretMode,
+    TrustSSHHostKey,
+    UnlockSecrets,
 } from 'wailsjs/go/services/connectionService.js'
 import { ConnectionType } from '@/consts/connection_type.js'
 import { KeyViewType } from '@/consts/key_view_type.js'
 import useBrowserStore from 'stores/browser.js'
 import { i18nGlobal } from '@/utils/i18n.js'
-import { ClipboardGetText } from 'wailsjs/runtime/runtime.js'
+import { ClipboardGetText, EventsOn } from 'wailsjs/runtime/runtime.js'
 
 const useConnectionStore = defineStore('connections', {
     /**
@@ -38,6 +47,8 @@ const useConnectionStore = defineStore('connections', {
      * @property {string} keySeparator
      * @property {string} markColor
      * @property {number} refreshInterval
+     * @property {boolean} cluster
+     * @property {boolean} sentinel
      */
 
     /**
@@ -55,6 +66,12 @@ const useConnectionStore = defineStore('connections', {
         groups: [], // all group name set
         connections: [], // all connections
         serverProfile: {}, // all server profile in flat list
+        secretStatus: {
+            mode: '', // empty means secrets are saved in connection profile
+            locked: false,
+            keyringAvailable: false,
+        },
+        tunnelStatus: {}, // status of ssh tunnels in use, key by connection name
     }),
     getters: {},
     actions: {
@@ -87,6 +104,8 @@ const useConnectionStore = defineStore('connections', {
                         keySeparator: conn.keySeparator,
                         markColor: conn.markColor,
                         refreshInterval: conn.refreshInterval,
+                        cluster: get(conn, 'cluster.enable', false),
+                        sentinel: get(conn, 'sentinel.enable', false),
                     }
                 } else {
                     // custom group
@@ -108,6 +127,8 @@ const useConnectionStore = defineStore('connections', {
                             keySeparator: item.keySeparator,
                             markColor: item.markColor,
                             refreshInterval: item.refreshInterval,
+                            cluster: get(item, 'cluster.enable', false),
+                            sentinel: get(item, 'sentinel.enable', false),
                         }
                     }
                     conns.push({
@@ -136,6 +157,8 @@ const useConnectionStore = defineStore('connections', {
                         defaultFilter: data.defaultFilter,
                         keySeparator: data.keySeparator,
                         markColor: data.markColor,
+                        cluster: get(data, 'cluster.enable', false),
+                        sentinel: get(data, 'sentinel.enable', false),
                     }
                     return data
                 }
@@ -168,7 +191,9 @@ const useConnectionStore = defineStore('connections', {
                 keyView: KeyViewType.Tree,
                 loadSize: 10000,
                 markColor: '',
+                exporter: false,
                 alias: {},
+                decoderRules: [],
                 ssl: {
                     enable: false,
                     allowInsecure: true,
@@ -176,6 +201,14 @@ const useConnectionStore = defineStore('connections', {
                     certFile: '',
                     keyFile: '',
                     caFile: '',
+                    cert: '',
+                    key: '',
+                    ca: '',
+                    keyPassphrase: '',
+                    minVersion: '',
+                    maxVersion: '',
+                    cipherSuites: [],
+                    pinnedSpki: [],
                 },
                 ssh: {
                     enable: false,
@@ -186,15 +219,21 @@ const useConnectionStore = defineStore('connections', {
                     password: '',
                     pkFile: '',
                     passphrase: '',
+                    alias: '',
+                    jumpHosts: [],
                 },
                 sentinel: {
                     enable: false,
                     master: 'mymaster',
                     username: '',
                     password: '',
+                    replicaPreferred: false,
                 },
                 cluster: {
                     enable: false,
+                    readOnly: false,
+                    routeByLatency: false,
+                    routeRandomly: false,
                 },
                 proxy: {
                     type: 0,
@@ -217,6 +256,8 @@ const useConnectionStore = defineStore('connections', {
                         destObj[k] = srcObj[k] || destObj[k] || ''
                     } else if (t === 'number') {
                         destObj[k] = srcObj[k] || destObj[k] || 0
+                    } else if (Array.isArray(srcObj[k])) {
+                        destObj[k] = srcObj[k]
                     } else if (t === 'object') {
                         mergeObj(destObj[k], srcObj[k] || {})
                     } else {
@@ -372,6 +413,20 @@ const useConnectionStore = defineStore('connections', {
             return { success: true }
         },
 
+        /**
+         * save node which browser connect to directly
+         * @param {string} name
+         * @param {string} addr empty to unpin
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async savePinnedNode(name, addr) {
+            const { success, msg } = await SavePinnedNode(name, addr)
+            if (!success) {
+                return { success: false, msg }
+            }
+            return { success: true }
+        },
+
         /**
          * get default key filter pattern by server name
          * @param name
@@ -392,6 +447,26 @@ const useConnectionStore = defineStore('connections', {
             return keySeparator
         },
 
+        /**
+         * check if server is connected as cluster
+         * @param {string} name
+         * @return {boolean}
+         */
+        isCluster(name) {
+            const { cluster = false } = this.serverProfile[name] || {}
+            return cluster === true
+        },
+
+        /**
+         * check if server is connected via sentinel
+         * @param {string} name
+         * @return {boolean}
+         */
+        isSentinel(name) {
+            const { sentinel = false } = this.serverProfile[name] || {}
+            return sentinel === true
+        },
+
         /**
          * get default status refresh interval by server name
          * @param {string} name
@@ -419,39 +494,143 @@ const useConnectionStore = defineStore('connections', {
         },
 
         /**
-         * export connections to zip
-         * @return {Promise<void>}
+         * ask for trusting unknown ssh host key on first use
+         * @param {{host: string, keyType: string, fingerprint: string}} hostKey
+         * @param {function} [onTrusted] invoke after trusted
          */
-        async exportConnections() {
-            const {
-                success,
-                msg,
-                data: { path = '' },
-            } = await ExportConnections()
+        confirmSSHHostKey(hostKey, onTrusted) {
+            $dialog.warning(i18nGlobal.t('dialogue.ssh_host_key.unknown_tip', hostKey), async () => {
+                const { success, msg } = await TrustSSHHostKey(hostKey.host, hostKey.fingerprint)
+                if (!success) {
+                    $message.error(msg)
+                    return
+                }
+                onTrusted && onTrusted()
+            })
+        },
+
+        /**
+         * generate url, redis-cli command and client snippets of connection
+         * @param {string} name
+         * @param {boolean} mask mask password
+         * @return {Promise<{success: boolean, msg: string, [data]: {url: string, cli: string, goRedis: string, redisPy: string, ioredis: string}}>}
+         */
+        async getConnectionSnippets(name, mask) {
+            return await GetConnectionSnippets(name, mask)
+        },
+
+        /**
+         * export selected connections to zip
+         * @param {string[]} names connection or group names, empty means all
+         * @param {string} secret keep, strip or encrypt secrets
+         * @param {string} [passphrase] required if encrypt secrets
+         * @return {Promise<boolean>}
+         */
+        async exportConnections({ names = [], secret = 'keep', passphrase = '' }) {
+            const { success, msg } = await ExportConnections({ names, secret, passphrase })
             if (!success) {
                 if (!isEmpty(msg)) {
                     $message.error(msg)
                 }
-                return
+                return false
             }
 
             $message.success(i18nGlobal.t('dialogue.handle_succ'))
+            return true
         },
 
         /**
-         * import connections from zip
-         * @return {Promise<void>}
+         * choose zip file to import connections
+         * @return {Promise<{connections: string[], collisions: string[], encrypted: boolean}|null>}
          */
         async importConnections() {
-            const { success, msg } = await ImportConnections()
+            const { success, msg, data } = await ImportConnections()
             if (!success) {
                 if (!isEmpty(msg)) {
                     $message.error(msg)
                 }
-                return
+                return null
             }
+            return data
+        },
 
-            $message.success(i18nGlobal.t('dialogue.handle_succ'))
+        /**
+         * merge chosen connections into local profile
+         * @param {string} token identify pending import
+         * @param {string} passphrase
+         * @param {Object.<string, string>} resolutions
+         * @return {Promise<{success: boolean, [msg]: string, [data]: {}}>}
+         */
+        async applyImportConnections({ token = '', passphrase = '', resolutions = {} }) {
+            const resp = await ApplyImportConnections({ token, passphrase, resolutions })
+            if (resp.success) {
+                await this.initConnections(true)
+            }
+            return resp
+        },
+
+        /**
+         * load status of ssh tunnels and keep updated by events
+         * @return {Promise<void>}
+         */
+        async watchSSHTunnels() {
+            const updateStatus = (status) => {
+                if (status.status === 'closed') {
+                    delete this.tunnelStatus[status.name]
+                } else {
+                    this.tunnelStatus[status.name] = status
+                }
+            }
+            const { success, data } = await ListSSHTunnels()
+            if (success) {
+                for (const status of data || []) {
+                    updateStatus(status)
+                }
+            }
+            EventsOn('ssh_tunnel:status', updateStatus)
+        },
+
+        /**
+         * load storage mode of connection secrets
+         * @return {Promise<void>}
+         */
+        async loadSecretStatus() {
+            const { success, data } = await GetSecretStatus()
+            if (success) {
+                this.secretStatus = data
+            }
+        },
+
+        /**
+         * unlock saved secrets with master password
+         * @param {string} password
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async unlockSecrets(password) {
+            const { success, msg } = await UnlockSecrets(password)
+            await this.loadSecretStatus()
+            return { success, msg }
+        },
+
+        /**
+         * lock saved secrets
+         * @return {Promise<void>}
+         */
+        async lockSecrets() {
+            await LockSecrets()
+            await this.loadSecretStatus()
+        },
+
+        /**
+         * move saved secrets to system keyring or encrypt with new master password
+         * @param {string} mode
+         * @param {string} [password]
+         * @return {Promise<{success: boolean, [msg]: string}>}
+         */
+        async switchSecretMode(mode, password = '') {
+            const { success, msg } = await SwitchSecretMode(mode, password)
+            await this.loadSecretStatus()
+            return { success, msg }
         },
 
         /**
@@ -95,17 +95,40 @@ const useDialogStore = defineStore('dialog', {
         decodeDialogVisible: false,
         decodeParam: {
             name: '',
+            type: '',
             auto: true,
             decodePath: '',
             decodeArgs: [],
             encodePath: '',
             encodeArgs: [],
+            timeout: 0,
+            cipher: {},
         },
 
         preferencesDialogVisible: false,
         preferencesTag: '',
 
         aboutDialogVisible: false,
+
+        snippetDialogVisible: false,
+        snippetParam: {
+            name: '',
+        },
+
+        exportConnDialogVisible: false,
+        importConnDialogVisible: false,
+        importConnParam: {
+            connections: [],
+            collisions: [],
+            encrypted: false,
+            source: '',
+            unmapped: {},
+            token: '',
+        },
+
+        secretDialogVisible: false,
+        secretDialogUnlock: true, // unlock saved secrets, or set a new master password
+        secretUnlockCallback: null,
     }),
     actions: {
         openNewDialog() {
@@ -336,9 +359,15 @@ const useDialogStore = defineStore('dialog', {
             decodeArgs = [],
             encodePath = '',
             encodeArgs = [],
+            type = '',
+            timeout = 0,
+            cipher = {},
         } = {}) {
             this.decodeDialogVisible = true
             this.decodeParam.name = name
+            this.decodeParam.type = type || ''
+            this.decodeParam.timeout = timeout || 0
+            this.decodeParam.cipher = cipher || {}
             this.decodeParam.auto = auto !== false
             this.decodeParam.decodePath = decodePath
             this.decodeParam.decodeArgs = decodeArgs || []
@@ -365,6 +394,67 @@ const useDialogStore = defineStore('dialog', {
         closeAboutDialog() {
             this.aboutDialogVisible = false
         },
+
+        openExportConnDialog() {
+            this.exportConnDialogVisible = true
+        },
+        closeExportConnDialog() {
+            this.exportConnDialogVisible = false
+        },
+
+        /**
+         * @param {string[]} connections
+         * @param {string[]} collisions
+         * @param {boolean} encrypted
+         */
+        openImportConnDialog({
+            connections = [],
+            collisions = [],
+            encrypted = false,
+            source = '',
+            unmapped = {},
+            token = '',
+        }) {
+            this.importConnParam.connections = connections
+            this.importConnParam.collisions = collisions
+            this.importConnParam.encrypted = encrypted
+            this.importConnParam.source = source
+            this.importConnParam.unmapped = unmapped || {}
+            this.importConnParam.token = token
+            this.importConnDialogVisible = true
+        },
+        closeImportConnDialog() {
+            this.importConnDialogVisible = false
+        },
+
+        /**
+         * @param {string} name
+         */
+        openSnippetDialog(name) {
+            this.snippetParam.name = name
+            this.snippetDialogVisible = true
+        },
+        closeSnippetDialog() {
+            this.snippetDialogVisible = false
+        },
+
+        /**
+         * @param {function} [callback] invoke after unlocked
+         */
+        openUnlockSecretDialog(callback = null) {
+            this.secretDialogUnlock = true
+            this.secretUnlockCallback = callback
+            this.secretDialogVisible = true
+        },
+        openMasterPasswordDialog() {
+            this.secretDialogUnlock = false
+            this.secretUnlockCallback = null
+            this.secretDialogVisible = true
+        },
+        closeSecretDialog() {
+            this.secretDialogVisible = false
+            this.secretUnlockCallback = null
+        },
     },
 })
 
@@ -3,6 +3,7 @@ import { lang } from '@/langs/index.js'
 import { cloneDeep, findIndex, get, isEmpty, join, map, pick, set, some, split } from 'lodash'
 import {
     CheckForUpdate,
+    DeleteCipherKey,
     GetAppVersion,
     GetBuildInDecoder,
     GetFontList,
@@ -66,12 +67,19 @@ const usePreferencesStore = defineStore('preferences', {
             dropText: true,
             links: true,
             entryTextAlign: TextAlignType.Center,
+            timezone: '',
         },
         cli: {
             fontFamily: [],
             fontSize: 14,
             cursorStyle: 'block',
         },
+        metrics: {
+            enable: true,
+            interval: 5,
+            capacity: 720,
+            persist: false,
+        },
         buildInDecoder: [],
         decoder: [],
         lastPref: {},
@@ -379,9 +387,13 @@ const usePreferencesStore = defineStore('preferences', {
          * @returns {Promise<boolean>}
          */
         async savePreferences() {
-            const pf = pick(this, ['behavior', 'general', 'editor', 'cli', 'decoder'])
-            const { success } = await SetPreferences(pf)
-            return success === true
+            const pf = pick(this, ['behavior', 'general', 'editor', 'cli', 'metrics', 'decoder'])
+            const { success, msg } = await SetPreferences(pf)
+            if (success !== true) {
+                $message.error(msg)
+                return false
+            }
+            return true
         },
 
         /**
@@ -411,19 +423,44 @@ const usePreferencesStore = defineStore('preferences', {
         /**
          * add a new custom decoder
          * @param {string} name
+         * @param {string} [type] empty means external command
          * @param {boolean} enable
          * @param {boolean} auto
          * @param {string} encodePath
          * @param {string[]} encodeArgs
          * @param {string} decodePath
          * @param {string[]} decodeArgs
+         * @param {number} [timeout]
+         * @param {{algorithm: string, ivPosition: string, iv: string, encoding: string}} [cipher]
          */
-        addCustomDecoder({ name, enable = true, auto = true, encodePath, encodeArgs, decodePath, decodeArgs }) {
+        addCustomDecoder({
+            name,
+            type = '',
+            enable = true,
+            auto = true,
+            encodePath,
+            encodeArgs,
+            decodePath,
+            decodeArgs,
+            timeout = 0,
+            cipher = {},
+        }) {
             if (some(this.decoder, { name })) {
                 return false
             }
             this.decoder = this.decoder || []
-            this.decoder.push({ name, enable, auto, encodePath, encodeArgs, decodePath, decodeArgs })
+            this.decoder.push({
+                name,
+                type,
+                enable,
+                auto,
+                encodePath,
+                encodeArgs,
+                decodePath,
+                decodeArgs,
+                timeout,
+                cipher,
+            })
             return true
         },
 
@@ -437,6 +474,9 @@ const usePreferencesStore = defineStore('preferences', {
          * @param {string[]} encodeArgs
          * @param {string} decodePath
          * @param {string[]} decodeArgs
+         * @param {string} [type]
+         * @param {number} [timeout]
+         * @param {{algorithm: string, ivPosition: string, iv: string, encoding: string}} [cipher]
          */
         updateCustomDecoder({
             newName,
@@ -447,6 +487,9 @@ const usePreferencesStore = defineStore('preferences', {
             encodeArgs,
             decodePath,
             decodeArgs,
+            type = '',
+            timeout = 0,
+            cipher = {},
         }) {
             const idx = findIndex(this.decoder, { name })
             if (idx === -1) {
@@ -465,6 +508,9 @@ const usePreferencesStore = defineStore('preferences', {
             selDecoder.encodeArgs = encodeArgs
             selDecoder.decodePath = decodePath
             selDecoder.decodeArgs = decodeArgs
+            selDecoder.type = type
+            selDecoder.timeout = timeout
+            selDecoder.cipher = cipher
             this.decoder[idx] = selDecoder
             return true
         },
@@ -479,6 +525,9 @@ const usePreferencesStore = defineStore('preferences', {
             if (idx === -1) {
                 return false
             }
+            if (this.decoder[idx].type === 'cipher') {
+                DeleteCipherKey(name)
+            }
             this.decoder.splice(idx, 1)
             return true
         },
@@ -83,15 +83,22 @@ export function SaveLastDB(name, db) {
     return post('/connection/save-last-db', { name, db })
 }
 
+export function SavePinnedNode(name, addr) {
+    return post('/connection/save-pinned-node', { name, addr })
+}
+
 export function SaveRefreshInterval(name, interval) {
     return post('/connection/save-refresh-interval', { name, interval })
 }
 
-export async function ExportConnections() {
+export async function ExportConnections(param) {
     // Web mode: trigger browser download of connections zip
     try {
         const resp = await fetch(`${API_BASE}/connection/export-download`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
             credentials: 'same-origin',
+            body: JSON.stringify(param),
         })
         if (resp.status === 401) {
             window.dispatchEvent(new Event('rdm:unauthorized'))
@@ -117,11 +124,11 @@ export async function ExportConnections() {
 }
 
 export async function ImportConnections() {
-    // Web mode: open file picker, upload zip to backend
+    // Web mode: open file picker, upload file to backend
     return new Promise((resolve) => {
         const input = document.createElement('input')
         input.type = 'file'
-        input.accept = '.zip'
+        input.accept = '.zip,.json,.ardm,.txt'
         input.onchange = async () => {
             if (input.files && input.files[0]) {
                 const formData = new FormData()
@@ -151,6 +158,30 @@ export async function ImportConnections() {
     })
 }
 
+export function ApplyImportConnections(param) {
+    return post('/connection/import-apply', param)
+}
+
+export function TrustSSHHostKey(host, fingerprint) {
+    return post('/connection/trust-host-key', { host, fingerprint })
+}
+
+export function ListSSHTunnels() {
+    return get('/connection/ssh-tunnels')
+}
+
+export function ListSSHConfigHosts() {
+    return get('/connection/ssh-config-hosts')
+}
+
+export function ListCipherSuites() {
+    return get('/connection/cipher-suites')
+}
+
+export function GetConnectionSnippets(name, mask) {
+    return post('/connection/snippets', { name, mask })
+}
+
 export function ParseConnectURL(url) {
     return post('/connection/parse-url', { url })
 }
@@ -159,6 +190,22 @@ export function ListSentinelMasters(param) {
     return post('/connection/list-sentinel-masters', param)
 }
 
+export function GetSecretStatus() {
+    return get('/connection/secret-status')
+}
+
+export function UnlockSecrets(password) {
+    return post('/connection/unlock-secrets', { password })
+}
+
+export function LockSecrets() {
+    return post('/connection/lock-secrets')
+}
+
+export function SwitchSecretMode(mode, password) {
+    return post('/connection/switch-secret-mode', { mode, password })
+}
+
 // ==================== Browser Service ====================
 
 export function OpenConnection(name) {
@@ -289,14 +336,86 @@ export function FlushDB(server, db, async) {
     return post('/browser/flush-db', { server, db, async })
 }
 
-export function GetSlowLogs(server, db, num) {
-    return post('/browser/get-slow-logs', { server, db, num })
+export function GetSlowLogs(server, num) {
+    return post('/browser/get-slow-logs', { server, num })
+}
+
+export function AnalyzeSlowLogs(server, num) {
+    return post('/browser/analyze-slow-logs', { server, num })
+}
+
+export function ResetSlowLogs(server) {
+    return post('/browser/reset-slow-logs', { server })
+}
+
+export function GetLatestLatency(server) {
+    return post('/browser/latest-latency', { server })
+}
+
+export function GetLatencyHistory(server, node, event) {
+    return post('/browser/latency-history', { server, node, event })
+}
+
+export function GetLatencyDoctor(server) {
+    return post('/browser/latency-doctor', { server })
+}
+
+export function ResetLatency(server) {
+    return post('/browser/reset-latency', { server })
 }
 
 export function GetClientList(server, db) {
     return post('/browser/get-client-list', { server, db })
 }
 
+export function ListServerNodes(server) {
+    return post('/browser/server-nodes', { server })
+}
+
+export function GetReplicationStatus(server) {
+    return post('/browser/replication-status', { server })
+}
+
+export function ReplicaOf(server, addr, host, port) {
+    return post('/browser/replica-of', { server, addr, host, port })
+}
+
+export function GetSentinelOverview(server) {
+    return post('/browser/sentinel-overview', { server })
+}
+
+export function SentinelFailover(server, master) {
+    return post('/browser/sentinel-failover', { server, master })
+}
+
+export function SentinelReset(server, pattern) {
+    return post('/browser/sentinel-reset', { server, pattern })
+}
+
+export function SentinelCheckQuorum(server, master) {
+    return post('/browser/sentinel-ckquorum', { server, master })
+}
+
+export function GetClusterTopology(server) {
+    return post('/browser/cluster-topology', { server })
+}
+
+export function ClusterFailover(server, nodeId, mode) {
+    return post('/browser/cluster-failover', { server, nodeId, mode })
+}
+
+export function ClusterForget(server, nodeId) {
+    return post('/browser/cluster-forget', { server, nodeId })
+}
+
+export function ClusterMeet(server, host, port) {
+    return post('/browser/cluster-meet', { server, host, port })
+}
+
+export function MigrateClusterSlots(param) {
+    return post('/browser/migrate-cluster-slots', param)
+}
+
 export function GetCmdHistory() {
     return post('/browser/get-cmd-history')
 }
@@ -343,6 +462,156 @@ export function StopSubscribe(server) {
     return post('/pubsub/unsubscribe', { server })
 }
 
+export function GetKeyspaceNotifyConfig(server, events) {
+    return post('/pubsub/keyspace-config', { server, events })
+}
+
+export function EnableKeyspaceNotify(server, events) {
+    return post('/pubsub/enable-keyspace-notify', { server, events })
+}
+
+export function StartKeyspaceSubscribe(server, db, pattern, events) {
+    return post('/pubsub/keyspace-subscribe', { server, db, pattern, events })
+}
+
+export function StopKeyspaceSubscribe(server) {
+    return post('/pubsub/keyspace-unsubscribe', { server })
+}
+
+// ==================== ACL Service ====================
+
+export function ListUsers(server) {
+    return post('/acl/users', { server })
+}
+
+export function SaveUser(server, user) {
+    return post('/acl/save-user', { server, user })
+}
+
+export function DeleteUser(server, name) {
+    return post('/acl/delete-user', { server, name })
+}
+
+export function DryRun(server, name, cmd) {
+    return post('/acl/dry-run', { server, name, cmd })
+}
+
+export function GetLog(server, count) {
+    return post('/acl/log', { server, count })
+}
+
+export function ResetLog(server) {
+    return post('/acl/reset-log', { server })
+}
+
+export function SaveACL(server, load) {
+    return post('/acl/save', { server, load })
+}
+
+// ==================== Config Service ====================
+
+export function GetConfig(server, node) {
+    return post('/config/get', { server, node })
+}
+
+export function SetConfig(server, node, params) {
+    return post('/config/set', { server, node, params })
+}
+
+export function RewriteConfig(server, node) {
+    return post('/config/rewrite', { server, node })
+}
+
+export async function ExportConfig(server, node, withSecrets) {
+    // Web mode: trigger browser download of config snapshot
+    try {
+        const resp = await fetch(`${API_BASE}/config/export-download`, {
+            method: 'POST',
+            headers: { 'Content-Type': 'application/json' },
+            credentials: 'same-origin',
+            body: JSON.stringify({ server, node, withSecrets }),
+        })
+        if (resp.status === 401) {
+            window.dispatchEvent(new Event('rdm:unauthorized'))
+            return { success: false, msg: 'unauthorized' }
+        }
+        if (!resp.ok) {
+            const err = await resp.json().catch(() => ({}))
+            return { success: false, msg: err.msg || 'export failed' }
+        }
+        const blob = await resp.blob()
+        const url = URL.createObjectURL(blob)
+        const a = document.createElement('a')
+        const disposition = resp.headers.get('Content-Disposition') || ''
+        const match = disposition.match(/filename=(.+)/)
+        a.download = match ? match[1] : 'redis.conf'
+        a.href = url
+        a.click()
+        URL.revokeObjectURL(url)
+        return { success: true }
+    } catch {
+        return { success: false, msg: 'export failed' }
+    }
+}
+
+// ==================== Client Service ====================
+
+export function ListClients(server, node) {
+    return post('/client/list', { server, node })
+}
+
+export function GetClientInfo(server, node, id) {
+    return post('/client/info', { server, node, id })
+}
+
+export function KillClients(server, node, filterType, value) {
+    return post('/client/kill', { server, node, filterType, value })
+}
+
+export function PauseClients(server, node, timeout, mode) {
+    return post('/client/pause', { server, node, timeout, mode })
+}
+
+export function UnpauseClients(server, node) {
+    return post('/client/unpause', { server, node })
+}
+
+export function SetNoEvict(server, enable) {
+    return post('/client/no-evict', { server, enable })
+}
+
+// ==================== Metrics Service ====================
+
+export function GetMetrics(server, from, to) {
+    return post('/metrics/get', { server, from, to })
+}
+
+export function ClearMetrics(server) {
+    return post('/metrics/clear', { server })
+}
+
+// ==================== Alert Service ====================
+
+export function GetAlertSettings() {
+    return get('/alert/settings')
+}
+
+export function SaveAlertSettings(settings) {
+    return post('/alert/save-settings', { settings })
+}
+
+export function TestAlertNotification(settings) {
+    return post('/alert/test', { settings })
+}
+
+export function GetAlertHistory() {
+    return get('/alert/history')
+}
+
+export function CleanAlertHistory() {
+    return post('/alert/clean-history')
+}
+
 // ==================== Preferences Service ====================
 
 export function GetPreferences() {
@@ -398,6 +667,18 @@ export function GetBuildInDecoder() {
     return get('/preferences/buildin-decoder')
 }
 
+export function SetCipherKey(name, key) {
+    return post('/preferences/cipher-key/set', { name, key })
+}
+
+export function DeleteCipherKey(name) {
+    return del('/preferences/cipher-key/delete', { name })
+}
+
+export function ListCipherKeys() {
+    return get('/preferences/cipher-key/list')
+}
+
 export function GetAppVersion() {
     return get('/preferences/version')
 }
@@ -38,6 +38,11 @@ export default defineConfig({
                       'wailsjs/go/services/cliService.js': rootPath + 'src/utils/api.js',
                       'wailsjs/go/services/monitorService.js': rootPath + 'src/utils/api.js',
                       'wailsjs/go/services/pubsubService.js': rootPath + 'src/utils/api.js',
+                      'wailsjs/go/services/aclService.js': rootPath + 'src/utils/api.js',
+                      'wailsjs/go/services/configService.js': rootPath + 'src/utils/api.js',
+                      'wailsjs/go/services/clientService.js': rootPath + 'src/utils/api.js',
+                      'wailsjs/go/services/metricsService.js': rootPath + 'src/utils/api.js',
+                      'wailsjs/go/services/alertService.js': rootPath + 'src/utils/api.js',
                       'wailsjs/go/services/preferencesService.js': rootPath + 'src/utils/api.js',
                       'wailsjs/go/services/systemService.js': rootPath + 'src/utils/api.js',
                   }
@@ -40,6 +40,11 @@ func main() {
 	cliSvc := services.Cli()
 	monitorSvc := services.Monitor()
 	pubsubSvc := services.Pubsub()
+	aclSvc := services.ACL()
+	configSvc := services.Config()
+	clientSvc := services.Client()
+	metricsSvc := services.Metrics()
+	alertSvc := services.Alert()
 	prefSvc := services.Preferences()
 	prefSvc.SetAppVersion(version)
 	prefSvc.UpdateEnv()
@@ -81,6 +86,11 @@ func main() {
 			cliSvc.Start(ctx)
 			monitorSvc.Start(ctx)
 			pubsubSvc.Start(ctx)
+			aclSvc.Start(ctx)
+			configSvc.Start(ctx)
+			clientSvc.Start(ctx)
+			metricsSvc.Start(ctx)
+			alertSvc.Start(ctx)
 
 			services.GA().SetSecretKey(gaMeasurementID, gaSecretKey)
 			services.GA().Startup(version)
@@ -96,10 +106,12 @@ func main() {
 			return false
 		},
 		OnShutdown: func(ctx context.Context) {
+			metricsSvc.StopAll()
 			browserSvc.Stop()
 			cliSvc.CloseAll()
 			monitorSvc.StopAll()
 			pubsubSvc.StopAll()
+			prefSvc.Stop()
 		},
 		Bind: []interface{}{
 			sysSvc,
@@ -108,6 +120,11 @@ func main() {
 			cliSvc,
 			monitorSvc,
 			pubsubSvc,
+			aclSvc,
+			configSvc,
+			clientSvc,
+			metricsSvc,
+			alertSvc,
 			prefSvc,
 		},
 		Mac: &mac.Options{
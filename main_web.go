@@ -32,6 +32,11 @@ func main() {
 	cliSvc := services.Cli()
 	monitorSvc := services.Monitor()
 	pubsubSvc := services.Pubsub()
+	aclSvc := services.ACL()
+	configSvc := services.Config()
+	clientSvc := services.Client()
+	metricsSvc := services.Metrics()
+	alertSvc := services.Alert()
 	prefSvc := services.Preferences()
 	prefSvc.SetAppVersion(version)
 	prefSvc.UpdateEnv()
@@ -43,6 +48,11 @@ func main() {
 	cliSvc.Start(ctx)
 	monitorSvc.Start(ctx)
 	pubsubSvc.Start(ctx)
+	aclSvc.Start(ctx)
+	configSvc.Start(ctx)
+	clientSvc.Start(ctx)
+	metricsSvc.Start(ctx)
+	alertSvc.Start(ctx)
 
 	services.GA().SetSecretKey("", "")
 
@@ -68,10 +78,13 @@ func main() {
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		<-sigCh
 		log.Println("Shutting down...")
+		metricsSvc.StopAll()
+		metricsSvc.StopExporter()
 		browserSvc.Stop()
 		cliSvc.CloseAll()
 		monitorSvc.StopAll()
 		pubsubSvc.StopAll()
+		prefSvc.Stop()
 		srv.Close()
 	}()
 